@@ -58,7 +58,7 @@ BuildWinArm64() {
   export CC=$(pwd)/wrapper/zcc-arm64
   export CXX=$(pwd)/wrapper/zcxx-arm64
   export CGO_ENABLED=1
-  go build -o "$1" -ldflags="$ldflags" -tags=jsoniter .
+  go build -o "$1" -ldflags="$ldflags" -tags=jsoniter,nosqlite .
 }
 
 BuildDev() {
@@ -82,9 +82,9 @@ BuildDev() {
     export GOARCH=${os_arch##*-}
     export CC=${cgo_cc}
     export CGO_ENABLED=1
-    go build -o ./dist/$appName-$os_arch -ldflags="$muslflags" -tags=jsoniter .
+    go build -o ./dist/$appName-$os_arch -ldflags="$muslflags" -tags=jsoniter,nosqlite .
   done
-  xgo -targets=windows/amd64,darwin/amd64,darwin/arm64 -out "$appName" -ldflags="$ldflags" -tags=jsoniter .
+  xgo -targets=windows/amd64,darwin/amd64,darwin/arm64 -out "$appName" -ldflags="$ldflags" -tags=jsoniter,nosqlite .
   mv "$appName"-* dist
   cd dist
   cp ./"$appName"-windows-amd64.exe ./"$appName"-windows-amd64-upx.exe
@@ -94,7 +94,7 @@ BuildDev() {
 }
 
 BuildDocker() {
-  go build -o ./bin/"$appName" -ldflags="$ldflags" -tags=jsoniter .
+  go build -o ./bin/"$appName" -ldflags="$ldflags" -tags=jsoniter,nosqlite .
 }
 
 PrepareBuildDockerMusl() {
@@ -130,7 +130,7 @@ BuildDockerMultiplatform() {
     export GOARCH=$arch
     export CC=${cgo_cc}
     echo "building for $os_arch"
-    go build -o build/$os/$arch/"$appName" -ldflags="$docker_lflags" -tags=jsoniter .
+    go build -o build/$os/$arch/"$appName" -ldflags="$docker_lflags" -tags=jsoniter,nosqlite .
   done
 
   DOCKER_ARM_ARCHES=(linux-arm/v6 linux-arm/v7)
@@ -144,7 +144,7 @@ BuildDockerMultiplatform() {
     export GOARM=${GO_ARM[$i]}
     export CC=${cgo_cc}
     echo "building for $docker_arch"
-    go build -o build/${docker_arch%%-*}/${docker_arch##*-}/"$appName" -ldflags="$docker_lflags" -tags=jsoniter .
+    go build -o build/${docker_arch%%-*}/${docker_arch##*-}/"$appName" -ldflags="$docker_lflags" -tags=jsoniter,nosqlite .
   done
 }
 
@@ -152,7 +152,7 @@ BuildRelease() {
   rm -rf .git/
   mkdir -p "build"
   BuildWinArm64 ./build/"$appName"-windows-arm64.exe
-  xgo -out "$appName" -ldflags="$ldflags" -tags=jsoniter .
+  xgo -out "$appName" -ldflags="$ldflags" -tags=jsoniter,nosqlite .
   # why? Because some target platforms seem to have issues with upx compression
   upx -9 ./"$appName"-linux-amd64
   cp ./"$appName"-windows-amd64.exe ./"$appName"-windows-amd64-upx.exe
@@ -182,7 +182,7 @@ BuildReleaseLinuxMusl() {
     export GOARCH=${os_arch##*-}
     export CC=${cgo_cc}
     export CGO_ENABLED=1
-    go build -o ./build/$appName-$os_arch -ldflags="$muslflags" -tags=jsoniter .
+    go build -o ./build/$appName-$os_arch -ldflags="$muslflags" -tags=jsoniter,nosqlite .
   done
 }
 
@@ -215,7 +215,7 @@ BuildReleaseLinuxMuslArm() {
     export CC=${cgo_cc}
     export CGO_ENABLED=1
     export GOARM=${arm}
-    go build -o ./build/$appName-$os_arch -ldflags="$muslflags" -tags=jsoniter .
+    go build -o ./build/$appName-$os_arch -ldflags="$muslflags" -tags=jsoniter,nosqlite .
   done
 }
 
@@ -235,7 +235,7 @@ BuildReleaseAndroid() {
     export GOARCH=${os_arch##*-}
     export CC=${cgo_cc}
     export CGO_ENABLED=1
-    go build -o ./build/$appName-android-$os_arch -ldflags="$ldflags" -tags=jsoniter .
+    go build -o ./build/$appName-android-$os_arch -ldflags="$ldflags" -tags=jsoniter,nosqlite .
     android-ndk-r26b/toolchains/llvm/prebuilt/linux-x86_64/bin/llvm-strip ./build/$appName-android-$os_arch
   done
 }
@@ -259,7 +259,7 @@ BuildReleaseFreeBSD() {
     export CC=${cgo_cc}
     export CGO_ENABLED=1
     export CGO_LDFLAGS="-fuse-ld=lld"
-    go build -o ./build/$appName-freebsd-$os_arch -ldflags="$ldflags" -tags=jsoniter .
+    go build -o ./build/$appName-freebsd-$os_arch -ldflags="$ldflags" -tags=jsoniter,nosqlite .
   done
 }
 
@@ -7,6 +7,8 @@ import (
 
 	"github.com/OpenListTeam/OpenList/internal/bootstrap"
 	"github.com/OpenListTeam/OpenList/internal/bootstrap/data"
+	"github.com/OpenListTeam/OpenList/internal/cache"
+	"github.com/OpenListTeam/OpenList/internal/cluster"
 	"github.com/OpenListTeam/OpenList/internal/db"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
 	log "github.com/sirupsen/logrus"
@@ -15,6 +17,7 @@ import (
 func Init() {
 	bootstrap.InitConfig()
 	bootstrap.Log()
+	bootstrap.InitCache()
 	bootstrap.InitDB()
 	data.InitData()
 	bootstrap.InitStreamLimit()
@@ -23,7 +26,9 @@ func Init() {
 }
 
 func Release() {
+	cluster.Close()
 	db.Close()
+	cache.Close()
 }
 
 var pid = -1
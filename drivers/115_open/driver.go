@@ -42,6 +42,7 @@ func (d *Open115) Init(ctx context.Context) error {
 			d.Addition.RefreshToken = s2
 			op.MustSaveDriverStorage(d)
 		}))
+	d.client.SetHttpClient(&http.Client{Transport: &tokenTransport{d: d, base: http.DefaultTransport}})
 	if flags.Debug || flags.Dev {
 		d.client.SetDebug(true)
 	}
@@ -1,3 +1,49 @@
 package _115_open
 
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/OpenListTeam/OpenList/internal/op"
+	sdk "github.com/xhofe/115-sdk-go"
+)
+
 // do others that not defined in Driver interface
+
+// tokenTransport refreshes the token with the lock of the driver, as the sdk
+// refreshes it by itself when the access token expires
+type tokenTransport struct {
+	d    *Open115
+	base http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.String() != sdk.ApiRefreshToken {
+		return t.base.RoundTrip(req)
+	}
+	unlock, err := op.LockDriverToken(t.d)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	// saved before the unlock, so the other nodes reload it instead of refreshing
+	var r sdk.AuthResp[sdk.RefreshTokenResp]
+	if json.Unmarshal(body, &r) == nil && r.Code == 0 && r.Data.RefreshToken != "" {
+		t.d.Addition.AccessToken = r.Data.AccessToken
+		t.d.Addition.RefreshToken = r.Data.RefreshToken
+		op.MustSaveDriverStorage(t.d)
+	}
+	return resp, nil
+}
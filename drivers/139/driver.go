@@ -70,7 +70,7 @@ func (d *Yun139) Init(ctx context.Context) error {
 			return fmt.Errorf("PersonalCloudHost is empty")
 		}
 
-		d.cron = cron.NewCron(time.Hour * 12)
+		d.cron = cron.NewCron(time.Hour * 12).LeaderOnly()
 		d.cron.Do(func() {
 			err := d.refreshToken()
 			if err != nil {
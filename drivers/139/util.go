@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/drivers/base"
+	"github.com/OpenListTeam/OpenList/internal/cluster"
 	"github.com/OpenListTeam/OpenList/internal/model"
 	"github.com/OpenListTeam/OpenList/internal/op"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
@@ -84,6 +85,15 @@ func (d *Yun139) refreshToken() error {
 	if expiration < 0 {
 		return fmt.Errorf("authorization has expired")
 	}
+	if !cluster.IsLeader() {
+		// the leader refreshes it and the other nodes reload the saved one
+		return nil
+	}
+	unlock, err := op.LockDriverToken(d)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	url := "https://aas.caiyun.feixin.10086.cn:443/tellin/authTokenRefresh.do"
 	var resp RefreshTokenResp
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/drivers/base"
+	"github.com/OpenListTeam/OpenList/internal/cluster"
 	"github.com/OpenListTeam/OpenList/internal/conf"
 	"github.com/OpenListTeam/OpenList/internal/driver"
 	"github.com/OpenListTeam/OpenList/internal/errs"
@@ -29,10 +30,9 @@ import (
 type AliDrive struct {
 	model.Storage
 	Addition
-	AccessToken string
-	cron        *cron.Cron
-	DriveId     string
-	UserID      string
+	cron    *cron.Cron
+	DriveId string
+	UserID  string
 }
 
 func (d *AliDrive) Config() driver.Config {
@@ -46,9 +46,12 @@ func (d *AliDrive) GetAddition() driver.Additional {
 func (d *AliDrive) Init(ctx context.Context) error {
 	// TODO login / refresh token
 	//op.MustSaveDriverStorage(d)
-	err := d.refreshToken()
-	if err != nil {
-		return err
+	// the other nodes use the token refreshed by the leader
+	if d.AccessToken == "" || cluster.IsLeader() {
+		err := d.refreshToken()
+		if err != nil {
+			return err
+		}
 	}
 	// get driver id
 	res, err, _ := d.request("https://api.alipan.com/v2/user/get", http.MethodPost, nil, nil)
@@ -57,7 +60,7 @@ func (d *AliDrive) Init(ctx context.Context) error {
 	}
 	d.DriveId = utils.Json.Get(res, "default_drive_id").ToString()
 	d.UserID = utils.Json.Get(res, "user_id").ToString()
-	d.cron = cron.NewCron(time.Hour * 2)
+	d.cron = cron.NewCron(time.Hour * 2).LeaderOnly()
 	d.cron.Do(func() {
 		err := d.refreshToken()
 		if err != nil {
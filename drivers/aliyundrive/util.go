@@ -58,10 +58,15 @@ func (d *AliDrive) sign() {
 // do others that not defined in Driver interface
 
 func (d *AliDrive) refreshToken() error {
+	unlock, err := op.LockDriverToken(d)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	url := "https://auth.alipan.com/v2/account/token"
 	var resp base.TokenResp
 	var e RespErr
-	_, err := base.RestyClient.R().
+	_, err = base.RestyClient.R().
 		//ForceContentType("application/json").
 		SetBody(base.Json{"refresh_token": d.RefreshToken, "grant_type": "refresh_token"}).
 		SetResult(&resp).
@@ -78,6 +78,11 @@ func (d *AliyundriveOpen) refreshToken() error {
 	if d.ref != nil {
 		return d.ref.refreshToken()
 	}
+	unlock, err := op.LockDriverToken(d)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	refresh, access, err := d._refreshToken()
 	for i := 0; i < 3; i++ {
 		if err == nil {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/OpenListTeam/OpenList/drivers/base"
+	"github.com/OpenListTeam/OpenList/internal/cluster"
 	"github.com/OpenListTeam/OpenList/internal/driver"
 	"github.com/OpenListTeam/OpenList/internal/errs"
 	"github.com/OpenListTeam/OpenList/internal/model"
@@ -20,10 +21,9 @@ import (
 type AliyundriveShare struct {
 	model.Storage
 	Addition
-	AccessToken string
-	ShareToken  string
-	DriveId     string
-	cron        *cron.Cron
+	ShareToken string
+	DriveId    string
+	cron       *cron.Cron
 
 	limitList func(ctx context.Context, dir model.Obj) ([]model.Obj, error)
 	limitLink func(ctx context.Context, file model.Obj) (*model.Link, error)
@@ -38,15 +38,18 @@ func (d *AliyundriveShare) GetAddition() driver.Additional {
 }
 
 func (d *AliyundriveShare) Init(ctx context.Context) error {
-	err := d.refreshToken()
-	if err != nil {
-		return err
+	// the other nodes use the token refreshed by the leader
+	if d.AccessToken == "" || cluster.IsLeader() {
+		err := d.refreshToken()
+		if err != nil {
+			return err
+		}
 	}
-	err = d.getShareToken()
+	err := d.getShareToken()
 	if err != nil {
 		return err
 	}
-	d.cron = cron.NewCron(time.Hour * 2)
+	d.cron = cron.NewCron(time.Hour * 2).LeaderOnly()
 	d.cron.Do(func() {
 		err := d.refreshToken()
 		if err != nil {
@@ -16,10 +16,15 @@ const (
 )
 
 func (d *AliyundriveShare) refreshToken() error {
+	unlock, err := op.LockDriverToken(d)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	url := "https://auth.alipan.com/v2/account/token"
 	var resp base.TokenResp
 	var e ErrorResp
-	_, err := base.RestyClient.R().
+	_, err = base.RestyClient.R().
 		SetBody(base.Json{"refresh_token": d.RefreshToken, "grant_type": "refresh_token"}).
 		SetResult(&resp).
 		SetError(&e).
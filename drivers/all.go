@@ -17,24 +17,30 @@ import (
 	_ "github.com/OpenListTeam/OpenList/drivers/aliyundrive"
 	_ "github.com/OpenListTeam/OpenList/drivers/aliyundrive_open"
 	_ "github.com/OpenListTeam/OpenList/drivers/aliyundrive_share"
+	_ "github.com/OpenListTeam/OpenList/drivers/archive"
 	_ "github.com/OpenListTeam/OpenList/drivers/azure_blob"
 	_ "github.com/OpenListTeam/OpenList/drivers/baidu_netdisk"
 	_ "github.com/OpenListTeam/OpenList/drivers/baidu_photo"
 	_ "github.com/OpenListTeam/OpenList/drivers/baidu_share"
 	_ "github.com/OpenListTeam/OpenList/drivers/chaoxing"
+	_ "github.com/OpenListTeam/OpenList/drivers/chunker"
 	_ "github.com/OpenListTeam/OpenList/drivers/cloudreve"
 	_ "github.com/OpenListTeam/OpenList/drivers/cloudreve_v4"
+	_ "github.com/OpenListTeam/OpenList/drivers/compress"
 	_ "github.com/OpenListTeam/OpenList/drivers/crypt"
 	_ "github.com/OpenListTeam/OpenList/drivers/doubao"
 	_ "github.com/OpenListTeam/OpenList/drivers/doubao_share"
 	_ "github.com/OpenListTeam/OpenList/drivers/dropbox"
 	_ "github.com/OpenListTeam/OpenList/drivers/febbox"
 	_ "github.com/OpenListTeam/OpenList/drivers/ftp"
+	_ "github.com/OpenListTeam/OpenList/drivers/git"
 	_ "github.com/OpenListTeam/OpenList/drivers/github"
 	_ "github.com/OpenListTeam/OpenList/drivers/github_releases"
 	_ "github.com/OpenListTeam/OpenList/drivers/google_drive"
 	_ "github.com/OpenListTeam/OpenList/drivers/google_photo"
 	_ "github.com/OpenListTeam/OpenList/drivers/halalcloud"
+	_ "github.com/OpenListTeam/OpenList/drivers/hasher"
+	_ "github.com/OpenListTeam/OpenList/drivers/http_index"
 	_ "github.com/OpenListTeam/OpenList/drivers/ilanzou"
 	_ "github.com/OpenListTeam/OpenList/drivers/ipfs_api"
 	_ "github.com/OpenListTeam/OpenList/drivers/kodbox"
@@ -46,6 +52,7 @@ import (
 	_ "github.com/OpenListTeam/OpenList/drivers/misskey"
 	_ "github.com/OpenListTeam/OpenList/drivers/mopan"
 	_ "github.com/OpenListTeam/OpenList/drivers/netease_music"
+	_ "github.com/OpenListTeam/OpenList/drivers/nfs"
 	_ "github.com/OpenListTeam/OpenList/drivers/onedrive"
 	_ "github.com/OpenListTeam/OpenList/drivers/onedrive_app"
 	_ "github.com/OpenListTeam/OpenList/drivers/onedrive_sharelink"
@@ -58,14 +65,17 @@ import (
 	_ "github.com/OpenListTeam/OpenList/drivers/seafile"
 	_ "github.com/OpenListTeam/OpenList/drivers/sftp"
 	_ "github.com/OpenListTeam/OpenList/drivers/smb"
+	_ "github.com/OpenListTeam/OpenList/drivers/swift"
 	_ "github.com/OpenListTeam/OpenList/drivers/teambition"
 	_ "github.com/OpenListTeam/OpenList/drivers/terabox"
 	_ "github.com/OpenListTeam/OpenList/drivers/thunder"
 	_ "github.com/OpenListTeam/OpenList/drivers/thunder_browser"
 	_ "github.com/OpenListTeam/OpenList/drivers/thunderx"
 	_ "github.com/OpenListTeam/OpenList/drivers/trainbit"
+	_ "github.com/OpenListTeam/OpenList/drivers/union"
 	_ "github.com/OpenListTeam/OpenList/drivers/url_tree"
 	_ "github.com/OpenListTeam/OpenList/drivers/uss"
+	_ "github.com/OpenListTeam/OpenList/drivers/versioning"
 	_ "github.com/OpenListTeam/OpenList/drivers/virtual"
 	_ "github.com/OpenListTeam/OpenList/drivers/webdav"
 	_ "github.com/OpenListTeam/OpenList/drivers/weiyun"
@@ -0,0 +1,140 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+)
+
+type Archive struct {
+	model.Storage
+	Addition
+	remoteStorage driver.Driver
+	remotePath    string
+}
+
+func (d *Archive) Config() driver.Config {
+	return config
+}
+
+func (d *Archive) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Archive) Init(ctx context.Context) error {
+	d.ArchivePath = utils.FixAndCleanPath(d.ArchivePath)
+	storage, remotePath, err := op.GetStorageAndActualPath(d.ArchivePath)
+	if err != nil {
+		return fmt.Errorf("can't find the storage of the archive: %w", err)
+	}
+	obj, err := op.Get(ctx, storage, remotePath)
+	if err != nil {
+		return fmt.Errorf("can't find the archive: %w", err)
+	}
+	if obj.IsDir() {
+		return errs.NotFile
+	}
+	d.remoteStorage = storage
+	d.remotePath = remotePath
+	return nil
+}
+
+func (d *Archive) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *Archive) innerArgs(path string, args model.LinkArgs) model.ArchiveInnerArgs {
+	return model.ArchiveInnerArgs{
+		ArchiveArgs: model.ArchiveArgs{
+			Password: d.Password,
+			LinkArgs: args,
+		},
+		InnerPath: utils.FixAndCleanPath(path),
+	}
+}
+
+func (d *Archive) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			Name:     "Root",
+			IsFolder: true,
+			Path:     "/",
+		}, nil
+	}
+	_, obj, err := op.ArchiveGet(ctx, d.remoteStorage, d.remotePath, model.ArchiveListArgs{
+		ArchiveInnerArgs: d.innerArgs(path, model.LinkArgs{}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toObj(path, obj), nil
+}
+
+func (d *Archive) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	objs, err := op.ListArchive(ctx, d.remoteStorage, d.remotePath, model.ArchiveListArgs{
+		ArchiveInnerArgs: d.innerArgs(dir.GetPath(), model.LinkArgs{}),
+		Refresh:          args.Refresh,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return utils.SliceConvert(objs, func(src model.Obj) (model.Obj, error) {
+		return toObj(stdpath.Join(dir.GetPath(), src.GetName()), src), nil
+	})
+}
+
+func (d *Archive) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	innerArgs := d.innerArgs(file.GetPath(), args)
+	extracted := &extractedFile{extract: func(ctx context.Context) (io.ReadCloser, int64, error) {
+		return op.InternalExtract(ctx, d.remoteStorage, d.remotePath, innerArgs)
+	}}
+	// entries can only be read from the beginning, reads from the start are
+	// streamed and the other ranges are read from the extracted temp file
+	rangeReader := func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		if httpRange.Start == 0 && !extracted.cached() {
+			rc, _, err := extracted.extract(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if httpRange.Length < 0 {
+				return rc, nil
+			}
+			return utils.NewLimitReadCloser(rc, rc.Close, httpRange.Length), nil
+		}
+		f, size, err := extracted.get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		length := httpRange.Length
+		if length < 0 || httpRange.Start+length > size {
+			length = size - httpRange.Start
+		}
+		return io.NopCloser(io.NewSectionReader(f, httpRange.Start, length)), nil
+	}
+	return &model.Link{
+		RangeReadCloser: &model.RangeReadCloser{RangeReader: rangeReader, Closers: utils.NewClosers(extracted)},
+	}, nil
+}
+
+func toObj(path string, obj model.Obj) model.Obj {
+	return &model.Object{
+		Path:     path,
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		Ctime:    obj.CreateTime(),
+		IsFolder: obj.IsDir(),
+		HashInfo: obj.GetHash(),
+	}
+}
+
+var _ driver.Driver = (*Archive)(nil)
+var _ driver.Getter = (*Archive)(nil)
@@ -0,0 +1,146 @@
+package archive_test
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	_ "github.com/OpenListTeam/OpenList/drivers/archive"
+	_ "github.com/OpenListTeam/OpenList/drivers/local"
+	_ "github.com/OpenListTeam/OpenList/internal/archive/zip"
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+)
+
+const content = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// newArchive mounts a local storage of a temporary dir holding a.zip on
+// /local and an archive storage of a.zip on /archive
+func newArchive(t *testing.T) driver.Driver {
+	db.Init(dbtest.Open(t))
+	root := t.TempDir()
+	conf.Conf.TempDir = t.TempDir()
+	f, err := os.Create(filepath.Join(root, "a.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	_, _ = zw.Create("docs/")
+	w, _ := zw.Create("docs/hello.txt")
+	_, _ = w.Write([]byte(content))
+	w, _ = zw.Create("readme.md")
+	_, _ = w.Write([]byte("readme"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+	ctx := context.Background()
+	localID, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed to create local storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, localID) })
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Archive",
+		MountPath: "/archive",
+		Addition:  `{"archive_path":"/local/a.zip"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create archive storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, id) })
+	d, err := op.GetStorageByMountPath("/archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestListAndGet(t *testing.T) {
+	d := newArchive(t)
+	ctx := context.Background()
+	objs, err := op.List(ctx, d, "/", model.ListArgs{})
+	if err != nil {
+		t.Fatalf("failed to list: %+v", err)
+	}
+	var names []string
+	for _, obj := range objs {
+		names = append(names, obj.GetName())
+	}
+	if got := strings.Join(names, ","); got != "docs,readme.md" {
+		t.Errorf("expected docs,readme.md listed, got %s", got)
+	}
+	obj, err := op.Get(ctx, d, "/docs/hello.txt")
+	if err != nil {
+		t.Fatalf("failed to get hello.txt: %+v", err)
+	}
+	if obj.IsDir() || obj.GetSize() != int64(len(content)) {
+		t.Errorf("unexpected hello.txt: %+v", obj)
+	}
+}
+
+func readRange(t *testing.T, rrc model.RangeReadCloserIF, r http_range.Range) string {
+	t.Helper()
+	rc, err := rrc.RangeRead(context.Background(), r)
+	if err != nil {
+		t.Fatalf("failed to read %+v: %+v", r, err)
+	}
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestLinkRanges(t *testing.T) {
+	d := newArchive(t)
+	link, _, err := op.Link(context.Background(), d, "/docs/hello.txt", model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed to link hello.txt: %+v", err)
+	}
+	rrc := link.RangeReadCloser
+	if got := readRange(t, rrc, http_range.Range{Length: -1}); got != content {
+		t.Errorf("expected the whole entry, got %q", got)
+	}
+	if entries, _ := os.ReadDir(conf.Conf.TempDir); len(entries) != 0 {
+		t.Errorf("expected a read from the start not to extract to a temp file, got %d", len(entries))
+	}
+	for _, r := range []http_range.Range{
+		{Start: 10, Length: 5},
+		{Start: 30, Length: -1},
+		{Start: 0, Length: 4},
+		{Start: 20, Length: 100},
+	} {
+		end := int64(len(content))
+		if r.Length >= 0 && r.Start+r.Length < end {
+			end = r.Start + r.Length
+		}
+		if got := readRange(t, rrc, r); got != content[r.Start:end] {
+			t.Errorf("expected %q for %+v, got %q", content[r.Start:end], r, got)
+		}
+	}
+	if entries, _ := os.ReadDir(conf.Conf.TempDir); len(entries) != 1 {
+		t.Errorf("expected the entry extracted to a temp file once, got %d", len(entries))
+	}
+	if err := rrc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(conf.Conf.TempDir); len(entries) != 0 {
+		t.Errorf("expected the temp file removed on close, got %d", len(entries))
+	}
+}
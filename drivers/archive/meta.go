@@ -0,0 +1,25 @@
+package archive
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/op"
+)
+
+type Addition struct {
+	ArchivePath string `json:"archive_path" required:"true" help:"the path of the archive file in OpenList, e.g. /local/images/debian.iso"`
+	Password    string `json:"password" help:"the password of the encrypted archive"`
+}
+
+var config = driver.Config{
+	Name:        "Archive",
+	LocalSort:   true,
+	OnlyProxy:   true,
+	NoUpload:    true,
+	DefaultRoot: "/",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Archive{}
+	})
+}
@@ -0,0 +1,62 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+)
+
+// extractedFile extracts an entry to a temp file on first use, so that the
+// ranges of a link don't extract the entry and skip the leading bytes again
+type extractedFile struct {
+	mu      sync.Mutex
+	file    *os.File
+	size    int64
+	extract func(ctx context.Context) (io.ReadCloser, int64, error)
+}
+
+func (e *extractedFile) cached() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.file != nil
+}
+
+func (e *extractedFile) get(ctx context.Context) (*os.File, int64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file != nil {
+		return e.file, e.size, nil
+	}
+	rc, size, err := e.extract(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rc.Close()
+	f, err := utils.CreateTempFile(rc, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, 0, err
+	}
+	e.file, e.size = f, stat.Size()
+	return e.file, e.size, nil
+}
+
+func (e *extractedFile) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file == nil {
+		return nil
+	}
+	err := e.file.Close()
+	_ = os.Remove(e.file.Name())
+	e.file = nil
+	return err
+}
@@ -23,7 +23,12 @@ import (
 // do others that not defined in Driver interface
 
 func (d *BaiduNetdisk) refreshToken() error {
-	err := d._refreshToken()
+	unlock, err := op.LockDriverToken(d)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	err = d._refreshToken()
 	if err != nil && errors.Is(err, errs.EmptyToken) {
 		err = d._refreshToken()
 	}
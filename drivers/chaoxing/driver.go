@@ -56,7 +56,7 @@ func (d *ChaoXing) Init(ctx context.Context) error {
 	if err != nil {
 		log.Errorf(ctx, err.Error())
 	}
-	d.cron = cron.NewCron(time.Hour * 12)
+	d.cron = cron.NewCron(time.Hour * 12).LeaderOnly()
 	d.cron.Do(func() {
 		err = d.refreshCookie()
 		if err != nil {
@@ -0,0 +1,365 @@
+package chunker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/fs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+type Chunker struct {
+	model.Storage
+	Addition
+	remoteStorage driver.Driver
+}
+
+func (d *Chunker) Config() driver.Config {
+	return config
+}
+
+func (d *Chunker) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Chunker) Init(ctx context.Context) error {
+	if d.ChunkSize <= 0 {
+		return fmt.Errorf("chunk size must be greater than 0")
+	}
+	d.RemotePath = utils.FixAndCleanPath(d.RemotePath)
+	//need remote storage exist
+	storage, err := fs.GetStorage(d.RemotePath, &fs.GetStoragesArgs{})
+	if err != nil {
+		return fmt.Errorf("can't find remote storage: %w", err)
+	}
+	d.remoteStorage = storage
+	return nil
+}
+
+func (d *Chunker) Drop(ctx context.Context) error {
+	return nil
+}
+
+// actual path is used for internal only
+func (d *Chunker) getActualPath(path string) (string, error) {
+	_, remoteActualPath, err := op.GetStorageAndActualPath(stdpath.Join(d.RemotePath, path))
+	return remoteActualPath, err
+}
+
+func (d *Chunker) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	remoteDir, err := d.getActualPath(dir.GetPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	entries, err := d.list(ctx, remoteDir)
+	if err != nil {
+		return nil, err
+	}
+	var result []model.Obj
+	for _, e := range entries {
+		if e.broken {
+			continue
+		}
+		if !d.ShowHidden && strings.HasPrefix(e.obj.GetName(), ".") {
+			continue
+		}
+		result = append(result, e.toObj(stdpath.Join(dir.GetPath(), e.obj.GetName())))
+	}
+	return result, nil
+}
+
+func (d *Chunker) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			Name:     "Root",
+			IsFolder: true,
+			Path:     "/",
+		}, nil
+	}
+	dir, name := stdpath.Split(path)
+	remoteDir, err := d.getActualPath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	e, err := d.getEntry(ctx, remoteDir, name)
+	if err != nil {
+		return nil, err
+	}
+	return e.toObj(path), nil
+}
+
+func (d *Chunker) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	dir, name := stdpath.Split(file.GetPath())
+	remoteDir, err := d.getActualPath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	e, err := d.getEntry(ctx, remoteDir, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(e.parts) == 0 {
+		link, _, err := op.Link(ctx, d.remoteStorage, stdpath.Join(remoteDir, name), args)
+		return link, err
+	}
+
+	size := e.size()
+	sizes := make([]int64, len(e.parts))
+	for i, p := range e.parts {
+		sizes[i] = p.GetSize()
+	}
+	remoteClosers := utils.EmptyClosers()
+	openPart := func(ctx context.Context, index int, offset, length int64) (io.ReadCloser, error) {
+		part := e.parts[index]
+		partLink, _, err := op.Link(ctx, d.remoteStorage, stdpath.Join(remoteDir, part.GetName()), args)
+		if err != nil {
+			return nil, err
+		}
+		if offset+length >= part.GetSize() {
+			length = -1
+		}
+		rrc := partLink.RangeReadCloser
+		if len(partLink.URL) > 0 {
+			converted, err := stream.GetRangeReadCloserFromLink(part.GetSize(), partLink)
+			if err != nil {
+				return nil, err
+			}
+			rrc = converted
+		}
+		if rrc != nil {
+			remoteReader, err := rrc.RangeRead(ctx, http_range.Range{Start: offset, Length: length})
+			remoteClosers.AddClosers(rrc.GetClosers())
+			if err != nil {
+				return nil, err
+			}
+			return remoteReader, nil
+		}
+		if partLink.MFile != nil {
+			//keep reuse same MFile and close at last.
+			remoteClosers.Add(partLink.MFile)
+			return io.NopCloser(io.NewSectionReader(partLink.MFile, offset, part.GetSize()-offset)), nil
+		}
+		return nil, errs.NotSupport
+	}
+	rangeReader := func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		end := size
+		if httpRange.Length >= 0 && httpRange.Start+httpRange.Length < size {
+			end = httpRange.Start + httpRange.Length
+		}
+		return &chunkReader{
+			ctx:   ctx,
+			sizes: sizes,
+			open:  openPart,
+			pos:   httpRange.Start,
+			end:   end,
+		}, nil
+	}
+	return &model.Link{
+		RangeReadCloser: &model.RangeReadCloser{RangeReader: rangeReader, Closers: remoteClosers},
+	}, nil
+}
+
+func (d *Chunker) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	remoteDir, err := d.getActualPath(parentDir.GetPath())
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	return op.MakeDir(ctx, d.remoteStorage, stdpath.Join(remoteDir, dirName))
+}
+
+// objEntry returns the remote dir and the entry of the obj
+func (d *Chunker) objEntry(ctx context.Context, obj model.Obj) (string, *entry, error) {
+	dir, name := stdpath.Split(obj.GetPath())
+	remoteDir, err := d.getActualPath(dir)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	e, err := d.getEntry(ctx, remoteDir, name)
+	if err != nil {
+		return "", nil, err
+	}
+	return remoteDir, e, nil
+}
+
+func (d *Chunker) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	remoteDir, e, err := d.objEntry(ctx, srcObj)
+	if err != nil {
+		return err
+	}
+	dstRemoteDir, err := d.getActualPath(dstDir.GetPath())
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	for _, name := range e.names() {
+		if err := op.Move(ctx, d.remoteStorage, stdpath.Join(remoteDir, name), dstRemoteDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Chunker) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	if _, _, _, ok := parsePartName(newName); ok {
+		return fmt.Errorf("the name [%s] is reserved for chunks", newName)
+	}
+	remoteDir, e, err := d.objEntry(ctx, srcObj)
+	if err != nil {
+		return err
+	}
+	if err := op.Rename(ctx, d.remoteStorage, stdpath.Join(remoteDir, e.obj.GetName()), newName); err != nil {
+		return err
+	}
+	for i, p := range e.parts {
+		if err := op.Rename(ctx, d.remoteStorage, stdpath.Join(remoteDir, p.GetName()), partName(newName, e.gen, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Chunker) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	remoteDir, e, err := d.objEntry(ctx, srcObj)
+	if err != nil {
+		return err
+	}
+	dstRemoteDir, err := d.getActualPath(dstDir.GetPath())
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	for _, name := range e.names() {
+		if err := op.Copy(ctx, d.remoteStorage, stdpath.Join(remoteDir, name), dstRemoteDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Chunker) Remove(ctx context.Context, obj model.Obj) error {
+	remoteDir, e, err := d.objEntry(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return d.remove(ctx, remoteDir, append(e.names(), e.staleNames()...))
+}
+
+func (d *Chunker) remove(ctx context.Context, remoteDir string, names []string) error {
+	for _, name := range names {
+		if err := op.Remove(ctx, d.remoteStorage, stdpath.Join(remoteDir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Chunker) Put(ctx context.Context, dstDir model.Obj, streamer model.FileStreamer, up driver.UpdateProgress) error {
+	name := streamer.GetName()
+	if _, _, _, ok := parsePartName(name); ok {
+		return fmt.Errorf("the name [%s] is reserved for chunks", name)
+	}
+	remoteDir, err := d.getActualPath(dstDir.GetPath())
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	// the parts of the old file are removed after the metadata is switched
+	// to the new one, so a failed upload keeps the old file
+	var oldGen string
+	var oldParts []string
+	if old, err := d.getEntry(ctx, remoteDir, name); err == nil {
+		oldGen = old.gen
+		oldParts = append(old.names()[1:], old.staleNames()...)
+	}
+
+	size := streamer.GetSize()
+	chunkSize := d.ChunkSize * utils.MB
+	if size <= chunkSize {
+		if err := op.Put(ctx, d.remoteStorage, remoteDir, streamer, up, false); err != nil {
+			return err
+		}
+		return d.removeOld(ctx, remoteDir, name, oldParts)
+	}
+
+	gen := newGen(oldGen)
+	var uploaded []string
+	for i := 0; int64(i)*chunkSize < size; i++ {
+		offset := int64(i) * chunkSize
+		partSize := min(chunkSize, size-offset)
+		part := &stream.FileStream{
+			Obj: &model.Object{
+				Name:     partName(name, gen, i),
+				Size:     partSize,
+				Modified: streamer.ModTime(),
+			},
+			Reader:            io.LimitReader(streamer, partSize),
+			Mimetype:          "application/octet-stream",
+			WebPutAsTask:      streamer.NeedStore(),
+			ForceStreamUpload: true,
+		}
+		partUp := func(p float64) {
+			up((float64(offset) + p/100*float64(partSize)) * 100 / float64(size))
+		}
+		err = op.Put(ctx, d.remoteStorage, remoteDir, part, partUp, false)
+		if err != nil {
+			d.cleanUp(ctx, remoteDir, name, uploaded)
+			return fmt.Errorf("failed to upload chunk %d: %w", i, err)
+		}
+		uploaded = append(uploaded, part.GetName())
+	}
+
+	data, err := json.Marshal(chunkMeta{
+		Version:   metaVersion,
+		Size:      size,
+		ChunkSize: chunkSize,
+		Chunks:    len(uploaded),
+		Gen:       gen,
+	})
+	if err != nil {
+		d.cleanUp(ctx, remoteDir, name, uploaded)
+		return err
+	}
+	meta := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     int64(len(data)),
+			Modified: streamer.ModTime(),
+		},
+		Reader:   bytes.NewReader(data),
+		Mimetype: "application/json",
+	}
+	err = op.Put(ctx, d.remoteStorage, remoteDir, meta, nil, false)
+	if err != nil {
+		d.cleanUp(ctx, remoteDir, name, uploaded)
+		return fmt.Errorf("failed to upload chunks metadata: %w", err)
+	}
+	up(100)
+	return d.removeOld(ctx, remoteDir, name, oldParts)
+}
+
+// cleanUp removes the parts uploaded by a failed upload, the parts left are
+// ignored as stale ones and removed by the next upload of the file
+func (d *Chunker) cleanUp(ctx context.Context, remoteDir, name string, parts []string) {
+	if err := d.remove(ctx, remoteDir, parts); err != nil {
+		log.Warnf("failed to clean up chunks of [%s]: %+v", name, err)
+	}
+}
+
+// removeOld removes the parts of the old file replaced by the new one
+func (d *Chunker) removeOld(ctx context.Context, remoteDir, name string, oldParts []string) error {
+	if err := d.remove(ctx, remoteDir, oldParts); err != nil {
+		return fmt.Errorf("failed to remove old chunks of [%s]: %w", name, err)
+	}
+	return nil
+}
+
+var _ driver.Driver = (*Chunker)(nil)
@@ -0,0 +1,140 @@
+package chunker_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/drivers/chunker"
+	_ "github.com/OpenListTeam/OpenList/drivers/local"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+)
+
+// newChunker mounts a local storage of a temporary dir on /remote and a
+// chunker storage of 1MB chunks of it on /chunker
+func newChunker(t *testing.T) (driver.Driver, string) {
+	db.Init(dbtest.Open(t))
+	root := t.TempDir()
+	ctx := context.Background()
+	remoteID, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/remote",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed to create local storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, remoteID) })
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Chunker",
+		MountPath: "/chunker",
+		Addition:  `{"remote_path":"/remote","chunk_size":1}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create chunker storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, id) })
+	d, err := op.GetStorageByMountPath("/chunker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, root
+}
+
+// failingReader fails after reading n bytes
+type failingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, errors.New("read failed")
+	}
+	if len(p) > r.n {
+		p = p[:r.n]
+	}
+	n, err := r.r.Read(p)
+	r.n -= n
+	return n, err
+}
+
+func put(d driver.Driver, data []byte, r io.Reader) error {
+	return op.Put(context.Background(), d, "/", &stream.FileStream{
+		Obj:    &model.Object{Name: "a.bin", Size: int64(len(data)), Modified: time.Now()},
+		Reader: io.NopCloser(r),
+	}, nil)
+}
+
+func read(t *testing.T, d driver.Driver) []byte {
+	t.Helper()
+	ctx := context.Background()
+	link, _, err := op.Link(ctx, d, "/a.bin", model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed to link a.bin: %+v", err)
+	}
+	rc, err := link.RangeReadCloser.RangeRead(ctx, http_range.Range{Length: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPutFailedOverwrite(t *testing.T) {
+	d, root := newChunker(t)
+	old := make([]byte, 2*utils.MB+100)
+	_, _ = rand.Read(old)
+	if err := put(d, old, bytes.NewReader(old)); err != nil {
+		t.Fatalf("failed to put a.bin: %+v", err)
+	}
+	// the second part of the new file fails to upload
+	data := make([]byte, 3*utils.MB)
+	_, _ = rand.Read(data)
+	if err := put(d, data, &failingReader{r: bytes.NewReader(data), n: utils.MB + 100}); err == nil {
+		t.Fatal("expected the upload to fail")
+	}
+	op.ClearCache(d, "/")
+	if got := read(t, d); !bytes.Equal(got, old) {
+		t.Fatalf("the old file is not kept after a failed overwrite")
+	}
+
+	if err := put(d, data, bytes.NewReader(data)); err != nil {
+		t.Fatalf("failed to overwrite a.bin: %+v", err)
+	}
+	op.ClearCache(d, "/")
+	if got := read(t, d); !bytes.Equal(got, data) {
+		t.Fatalf("the new file is not read after an overwrite")
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts int
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), "a.bin.chunk.") {
+			parts++
+		}
+	}
+	if parts != 3 {
+		t.Errorf("expected the 3 parts of the new file only, got %d", parts)
+	}
+}
@@ -0,0 +1,26 @@
+package chunker
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/op"
+)
+
+type Addition struct {
+	RemotePath string `json:"remote_path" required:"true" help:"This is where the chunks stores"`
+	ChunkSize  int64  `json:"chunk_size" type:"number" required:"true" default:"1024" help:"files larger than this will be split into parts (unit: MB)"`
+	ShowHidden bool   `json:"show_hidden" default:"true" required:"false" help:"show hidden directories and files"`
+}
+
+var config = driver.Config{
+	Name:        "Chunker",
+	LocalSort:   true,
+	OnlyProxy:   true,
+	NoCache:     true,
+	DefaultRoot: "/",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Chunker{}
+	})
+}
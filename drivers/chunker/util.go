@@ -0,0 +1,354 @@
+package chunker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	stdpath "path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	"github.com/OpenListTeam/OpenList/pkg/utils/random"
+	"github.com/Xhofe/go-cache"
+	log "github.com/sirupsen/logrus"
+)
+
+var metaCache = cache.NewMemCache(cache.WithShards[*chunkMeta](16))
+
+const (
+	metaVersion = 1
+	// maxMetaSize is larger than any metadata object, larger files are never
+	// treated as split files
+	maxMetaSize = 1024
+)
+
+// chunkMeta is stored under the name of a split file, next to its parts
+type chunkMeta struct {
+	Version   int   `json:"ver"`
+	Size      int64 `json:"size"`
+	ChunkSize int64 `json:"chunk_size"`
+	Chunks    int   `json:"nchunks"`
+	// Gen is the generation in the names of the parts, every upload writes
+	// the parts of a new generation, so the parts of the old file are kept
+	// until the metadata is switched to the new ones. The files split
+	// before it was added have no generation.
+	Gen string `json:"gen,omitempty"`
+}
+
+// check returns an error if the parts don't make up the file the metadata
+// describes, the metadata is overwritten last so it doesn't match the
+// parts left by a failed upload
+func (m *chunkMeta) check(e *entry) error {
+	if m.Version > metaVersion {
+		return fmt.Errorf("unsupported metadata version %d", m.Version)
+	}
+	if m.Chunks != len(e.parts) {
+		return fmt.Errorf("expect %d chunks, got %d", m.Chunks, len(e.parts))
+	}
+	if size := e.size(); m.Size != size {
+		return fmt.Errorf("expect size %d, got %d", m.Size, size)
+	}
+	return nil
+}
+
+var partRegexp = regexp.MustCompile(`^(.+)\.chunk\.(?:([0-9a-z]+)\.)?(\d{3,})$`)
+
+func partName(name, gen string, index int) string {
+	if gen == "" {
+		return fmt.Sprintf("%s.chunk.%03d", name, index)
+	}
+	return fmt.Sprintf("%s.chunk.%s.%03d", name, gen, index)
+}
+
+func parsePartName(name string) (string, string, int, bool) {
+	m := partRegexp.FindStringSubmatch(name)
+	if m == nil {
+		return "", "", 0, false
+	}
+	index, err := strconv.Atoi(m[3])
+	if err != nil {
+		return "", "", 0, false
+	}
+	return m[1], m[2], index, true
+}
+
+// newGen returns a generation different from the old one, in lower case for
+// the case-insensitive remotes
+func newGen(old string) string {
+	for {
+		if gen := strings.ToLower(random.String(8)); gen != old {
+			return gen
+		}
+	}
+}
+
+// entry is a file or dir on the remote, a split file is the metadata
+// object together with its parts
+type entry struct {
+	obj model.Obj
+	// gens is the generation -> the index -> the part
+	gens  map[string]map[int]model.Obj
+	gen   string
+	parts []model.Obj
+	// stale is the parts of the other generations, which are left by a
+	// failed or an unfinished upload
+	stale  []model.Obj
+	broken bool
+}
+
+// useGen picks the parts of the generation, it returns false if they are not
+// continuous
+func (e *entry) useGen(gen string) bool {
+	e.gen, e.parts, e.stale = gen, nil, nil
+	for g, parts := range e.gens {
+		if g == gen {
+			continue
+		}
+		for _, p := range parts {
+			e.stale = append(e.stale, p)
+		}
+	}
+	p := e.gens[gen]
+	for i := 0; i < len(p); i++ {
+		part, ok := p[i]
+		if !ok {
+			break
+		}
+		e.parts = append(e.parts, part)
+	}
+	return len(e.parts) == len(p)
+}
+
+func (e *entry) size() int64 {
+	if len(e.parts) == 0 {
+		return e.obj.GetSize()
+	}
+	var size int64
+	for _, p := range e.parts {
+		size += p.GetSize()
+	}
+	return size
+}
+
+// names returns the remote names of all the objects of the entry, except the
+// stale parts
+func (e *entry) names() []string {
+	names := []string{e.obj.GetName()}
+	for _, p := range e.parts {
+		names = append(names, p.GetName())
+	}
+	return names
+}
+
+func (e *entry) staleNames() []string {
+	var names []string
+	for _, p := range e.stale {
+		names = append(names, p.GetName())
+	}
+	return names
+}
+
+func (e *entry) toObj(path string) model.Obj {
+	obj := &model.Object{
+		Path:     path,
+		Name:     e.obj.GetName(),
+		Size:     e.size(),
+		Modified: e.obj.ModTime(),
+		Ctime:    e.obj.CreateTime(),
+		IsFolder: e.obj.IsDir(),
+	}
+	if len(e.parts) == 0 {
+		obj.HashInfo = e.obj.GetHash()
+	}
+	return obj
+}
+
+// groupObjs attaches the parts to the file they belong to, parts without
+// the metadata object are left over by a failed upload and are dropped,
+// so are the parts of a file too large to be the metadata. The parts without
+// generation are picked until the metadata is read.
+func groupObjs(objs []model.Obj) []*entry {
+	gens := make(map[string]map[string]map[int]model.Obj)
+	var res []*entry
+	for _, obj := range objs {
+		if !obj.IsDir() {
+			if name, gen, index, ok := parsePartName(obj.GetName()); ok {
+				if gens[name] == nil {
+					gens[name] = make(map[string]map[int]model.Obj)
+				}
+				if gens[name][gen] == nil {
+					gens[name][gen] = make(map[int]model.Obj)
+				}
+				gens[name][gen][index] = obj
+				continue
+			}
+		}
+		res = append(res, &entry{obj: obj})
+	}
+	for _, e := range res {
+		g, ok := gens[e.obj.GetName()]
+		if !ok || e.obj.IsDir() || e.obj.GetSize() > maxMetaSize {
+			continue
+		}
+		e.gens = g
+		if !e.useGen("") {
+			log.Warnf("chunker: parts of [%s] are not continuous", e.obj.GetName())
+			e.broken = true
+		}
+	}
+	return res
+}
+
+func (d *Chunker) list(ctx context.Context, remoteDir string) ([]*entry, error) {
+	objs, err := op.List(ctx, d.remoteStorage, remoteDir, model.ListArgs{})
+	if err != nil {
+		return nil, err
+	}
+	entries := groupObjs(objs)
+	for _, e := range entries {
+		if len(e.gens) == 0 {
+			continue
+		}
+		remotePath := stdpath.Join(remoteDir, e.obj.GetName())
+		err := d.applyMeta(ctx, remotePath, e, true)
+		if err != nil {
+			// the metadata of the same size may be overwritten within the
+			// precision of the modified time
+			err = d.applyMeta(ctx, remotePath, e, false)
+		}
+		if err != nil {
+			log.Warnf("chunker: invalid metadata of [%s]: %+v", e.obj.GetName(), err)
+			e.broken = true
+		}
+	}
+	return entries, nil
+}
+
+// applyMeta picks the parts of the entry by its metadata
+func (d *Chunker) applyMeta(ctx context.Context, remotePath string, e *entry, cached bool) error {
+	meta, err := d.readMeta(ctx, remotePath, e.obj, cached)
+	if err != nil {
+		return err
+	}
+	e.broken = false
+	if !e.useGen(meta.Gen) {
+		return fmt.Errorf("parts of generation [%s] are not continuous", meta.Gen)
+	}
+	return meta.check(e)
+}
+
+// readMeta reads the metadata object, the result is cached by the path, size
+// and modified time of the object
+func (d *Chunker) readMeta(ctx context.Context, remotePath string, obj model.Obj, cached bool) (*chunkMeta, error) {
+	key := fmt.Sprintf("%s:%d:%d", remotePath, obj.GetSize(), obj.ModTime().UnixMilli())
+	if m, ok := metaCache.Get(key); ok && cached {
+		return m, nil
+	}
+	link, _, err := op.Link(ctx, d.remoteStorage, remotePath, model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Ctx: ctx, Obj: obj}, link)
+	if err != nil {
+		return nil, err
+	}
+	defer ss.Close()
+	data, err := io.ReadAll(io.LimitReader(ss, maxMetaSize))
+	if err != nil {
+		return nil, err
+	}
+	var m chunkMeta
+	if err = json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	metaCache.Set(key, &m, cache.WithEx[*chunkMeta](time.Hour))
+	return &m, nil
+}
+
+func (d *Chunker) getEntry(ctx context.Context, remoteDir, name string) (*entry, error) {
+	entries, err := d.list(ctx, remoteDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.obj.GetName() == name && !e.broken {
+			return e, nil
+		}
+	}
+	return nil, errs.ObjectNotFound
+}
+
+// chunkReader reads the range [pos, end) of a split file, the parts are
+// opened one after another only when they are reached
+type chunkReader struct {
+	ctx    context.Context
+	sizes  []int64
+	open   func(ctx context.Context, index int, offset, length int64) (io.ReadCloser, error)
+	pos    int64
+	end    int64
+	cur    io.ReadCloser
+	curEnd int64
+}
+
+// locate returns the part containing the offset and the offset in it
+func (r *chunkReader) locate(offset int64) (int, int64) {
+	for i, size := range r.sizes {
+		if offset < size {
+			return i, offset
+		}
+		offset -= size
+	}
+	return len(r.sizes), 0
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if r.pos >= r.end {
+				return 0, io.EOF
+			}
+			index, offset := r.locate(r.pos)
+			if index >= len(r.sizes) {
+				return 0, io.EOF
+			}
+			partEnd := min(r.pos-offset+r.sizes[index], r.end)
+			rc, err := r.open(r.ctx, index, offset, partEnd-r.pos)
+			if err != nil {
+				return 0, err
+			}
+			r.cur, r.curEnd = rc, partEnd
+		}
+		if remain := r.curEnd - r.pos; int64(len(p)) > remain {
+			p = p[:remain]
+		}
+		n, err := r.cur.Read(p)
+		r.pos += int64(n)
+		if r.pos < r.curEnd && err != io.EOF {
+			return n, err
+		}
+		_ = r.cur.Close()
+		r.cur = nil
+		if r.pos < r.curEnd {
+			return n, io.ErrUnexpectedEOF
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	r.cur = nil
+	return err
+}
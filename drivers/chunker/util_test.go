@@ -0,0 +1,140 @@
+package chunker
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+)
+
+func newReader(data [][]byte, start, end int64) *chunkReader {
+	sizes := make([]int64, len(data))
+	for i, d := range data {
+		sizes[i] = int64(len(d))
+	}
+	return &chunkReader{
+		ctx:   context.Background(),
+		sizes: sizes,
+		open: func(ctx context.Context, index int, offset, length int64) (io.ReadCloser, error) {
+			part := data[index][offset:]
+			if length >= 0 && length < int64(len(part)) {
+				part = part[:length]
+			}
+			return io.NopCloser(bytes.NewReader(part)), nil
+		},
+		pos: start,
+		end: end,
+	}
+}
+
+func TestChunkReader(t *testing.T) {
+	data := [][]byte{[]byte("0123"), []byte("4567"), []byte("89")}
+	all := bytes.Join(data, nil)
+	for start := int64(0); start <= int64(len(all)); start++ {
+		for end := start; end <= int64(len(all)); end++ {
+			r := newReader(data, start, end)
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("failed to read [%d, %d): %+v", start, end, err)
+			}
+			if !bytes.Equal(got, all[start:end]) {
+				t.Errorf("expected %q of [%d, %d), got %q", all[start:end], start, end, got)
+			}
+			_ = r.Close()
+		}
+	}
+}
+
+func TestChunkReaderShortPart(t *testing.T) {
+	r := newReader([][]byte{[]byte("0123"), []byte("4567")}, 0, 8)
+	r.sizes[0] = 5
+	if _, err := io.ReadAll(r); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected unexpected EOF for a short part, got %v", err)
+	}
+}
+
+func obj(name string, size int64) model.Obj {
+	return &model.Object{Name: name, Size: size}
+}
+
+func TestGroupObjs(t *testing.T) {
+	entries := groupObjs([]model.Obj{
+		obj("a.bin", 60),
+		obj("a.bin.chunk.000", 10),
+		obj("a.bin.chunk.001", 5),
+		obj("b.bin", 60),
+		obj("b.bin.chunk.000", 10),
+		obj("b.bin.chunk.002", 10),
+		obj("left.bin.chunk.000", 10),
+		obj("large.bin", maxMetaSize+1),
+		obj("large.bin.chunk.000", 10),
+		&model.Object{Name: "dir", IsFolder: true},
+		obj("plain.txt", 3),
+	})
+	if len(entries) != 5 {
+		t.Fatalf("expected 5 entries, got %d", len(entries))
+	}
+	a := entries[0]
+	if len(a.parts) != 2 || a.broken || a.size() != 15 {
+		t.Errorf("expected a.bin of 2 parts and 15 bytes, got %+v", a)
+	}
+	if names := a.names(); len(names) != 3 || names[2] != "a.bin.chunk.001" {
+		t.Errorf("unexpected names of a.bin: %v", names)
+	}
+	if b := entries[1]; !b.broken {
+		t.Errorf("expected b.bin with a missing part to be broken")
+	}
+	if large := entries[2]; len(large.parts) != 0 || large.size() != maxMetaSize+1 {
+		t.Errorf("expected large.bin to be a plain file, got %+v", large)
+	}
+	if plain := entries[4]; plain.obj.GetName() != "plain.txt" || len(plain.parts) != 0 {
+		t.Errorf("expected plain.txt to be a plain file, got %+v", plain)
+	}
+}
+
+func TestGroupObjsGens(t *testing.T) {
+	entries := groupObjs([]model.Obj{
+		obj("a.bin", 60),
+		obj("a.bin.chunk.000", 10),
+		obj("a.bin.chunk.001", 5),
+		obj("a.bin.chunk.k3x9q2mz.000", 10),
+		obj("a.bin.chunk.k3x9q2mz.001", 10),
+		obj("a.bin.chunk.k3x9q2mz.002", 1),
+	})
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	a := entries[0]
+	if a.broken || len(a.parts) != 2 || len(a.stale) != 3 {
+		t.Fatalf("expected the parts without generation picked, got %+v", a)
+	}
+	if !a.useGen("k3x9q2mz") || a.size() != 21 || len(a.stale) != 2 {
+		t.Errorf("expected the parts of generation k3x9q2mz picked, got %+v", a)
+	}
+	if names := a.names(); names[1] != partName("a.bin", "k3x9q2mz", 0) {
+		t.Errorf("unexpected names of a.bin: %v", names)
+	}
+	if name, gen, index, ok := parsePartName("a.chunk.b.chunk.k3x9q2mz.012"); !ok || name != "a.chunk.b" || gen != "k3x9q2mz" || index != 12 {
+		t.Errorf("unexpected part %s %s %d", name, gen, index)
+	}
+}
+
+func TestChunkMetaCheck(t *testing.T) {
+	e := &entry{obj: obj("a.bin", 60), parts: []model.Obj{obj("a.bin.chunk.000", 10), obj("a.bin.chunk.001", 5)}}
+	tests := []struct {
+		meta chunkMeta
+		ok   bool
+	}{
+		{chunkMeta{Version: metaVersion, Size: 15, ChunkSize: 10, Chunks: 2}, true},
+		{chunkMeta{Version: metaVersion, Size: 15, ChunkSize: 10, Chunks: 3}, false},
+		{chunkMeta{Version: metaVersion, Size: 20, ChunkSize: 10, Chunks: 2}, false},
+		{chunkMeta{Version: metaVersion + 1, Size: 15, ChunkSize: 10, Chunks: 2}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.meta.check(e); (err == nil) != tt.ok {
+			t.Errorf("expected ok %v for %+v, got %v", tt.ok, tt.meta, err)
+		}
+	}
+}
@@ -48,6 +48,11 @@ func (d *CloudreveV4) Init(ctx context.Context) error {
 		return d.refreshToken()
 	}
 	if d.Username != "" {
+		unlock, err := op.LockDriverToken(d)
+		if err != nil {
+			return err
+		}
+		defer unlock()
 		return d.login()
 	}
 	return nil
@@ -175,6 +175,11 @@ func (d *CloudreveV4) doLogin(needCaptcha bool) error {
 }
 
 func (d *CloudreveV4) refreshToken() error {
+	unlock, err := op.LockDriverToken(d)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	var token Token
 	if token.RefreshToken == "" {
 		if d.Username != "" {
@@ -185,7 +190,7 @@ func (d *CloudreveV4) refreshToken() error {
 		}
 		return nil
 	}
-	err := d.request(http.MethodPost, "/session/token/refresh", func(req *resty.Request) {
+	err = d.request(http.MethodPost, "/session/token/refresh", func(req *resty.Request) {
 		req.SetBody(base.Json{
 			"refresh_token": d.RefreshToken,
 		})
@@ -0,0 +1,404 @@
+package compress
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	stdpath "path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/fs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/Xhofe/go-cache"
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+)
+
+type Compress struct {
+	model.Storage
+	Addition
+	remoteStorage driver.Driver
+	algorithm     byte
+	encoder       *zstd.Encoder
+}
+
+var headerCache = cache.NewMemCache(cache.WithShards[*header](16))
+
+func (d *Compress) Config() driver.Config {
+	return config
+}
+
+func (d *Compress) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Compress) Init(ctx context.Context) error {
+	isSuffix := regexp.MustCompile(`^[.][A-Za-z0-9-_]{2,}$`).MatchString
+	if !isSuffix(d.CompressedSuffix) {
+		return fmt.Errorf("CompressedSuffix is Illegal")
+	}
+	if d.BlockSize <= 0 {
+		return fmt.Errorf("block size must be greater than 0")
+	}
+	switch d.Algorithm {
+	case "gzip":
+		d.algorithm = algGzip
+	default:
+		d.algorithm = algZstd
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return fmt.Errorf("failed to create zstd encoder: %w", err)
+		}
+		d.encoder = encoder
+	}
+	d.RemotePath = utils.FixAndCleanPath(d.RemotePath)
+	//need remote storage exist
+	storage, err := fs.GetStorage(d.RemotePath, &fs.GetStoragesArgs{})
+	if err != nil {
+		return fmt.Errorf("can't find remote storage: %w", err)
+	}
+	d.remoteStorage = storage
+	return nil
+}
+
+func (d *Compress) Drop(ctx context.Context) error {
+	if d.encoder != nil {
+		return d.encoder.Close()
+	}
+	return nil
+}
+
+// actual path is used for internal only
+func (d *Compress) getActualPath(path string) (string, error) {
+	_, remoteActualPath, err := op.GetStorageAndActualPath(stdpath.Join(d.RemotePath, path))
+	return remoteActualPath, err
+}
+
+// toObj converts the remote obj, the compressed files are shown with the
+// original name, size and modified time from the header
+func (d *Compress) toObj(ctx context.Context, remoteDir string, obj model.Obj) (model.Obj, error) {
+	name := obj.GetName()
+	if obj.IsDir() || !strings.HasSuffix(name, d.CompressedSuffix) {
+		return &model.Object{
+			Name:     name,
+			Size:     obj.GetSize(),
+			Modified: obj.ModTime(),
+			Ctime:    obj.CreateTime(),
+			IsFolder: obj.IsDir(),
+			HashInfo: obj.GetHash(),
+		}, nil
+	}
+	h, err := d.getHeader(ctx, stdpath.Join(remoteDir, name), obj)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Object{
+		Name:     strings.TrimSuffix(name, d.CompressedSuffix),
+		Size:     h.Size,
+		Modified: h.Modified,
+		Ctime:    obj.CreateTime(),
+	}, nil
+}
+
+func (d *Compress) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	remoteDir, err := d.getActualPath(dir.GetPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	objs, err := op.List(ctx, d.remoteStorage, remoteDir, model.ListArgs{})
+	if err != nil {
+		return nil, err
+	}
+	// reading the headers costs a request per file, so do it concurrently
+	res := make([]model.Obj, len(objs))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 8)
+	for i, obj := range objs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			o, err := d.toObj(ctx, remoteDir, obj)
+			if err != nil {
+				//filter illegal files
+				log.Warnf("failed to read header of [%s]: %+v", stdpath.Join(remoteDir, obj.GetName()), err)
+				return
+			}
+			res[i] = o
+		}()
+	}
+	wg.Wait()
+
+	var result []model.Obj
+	names := make(map[string]int)
+	for _, obj := range res {
+		if obj == nil {
+			continue
+		}
+		if !d.ShowHidden && strings.HasPrefix(obj.GetName(), ".") {
+			continue
+		}
+		// the compressed file takes the place of the plain one with the same name
+		if i, ok := names[obj.GetName()]; ok {
+			if !obj.IsDir() {
+				result[i] = obj
+			}
+			continue
+		}
+		names[obj.GetName()] = len(result)
+		result = append(result, obj)
+	}
+	return result, nil
+}
+
+// getRemote returns the remote path and obj of the path, the compressed
+// file is preferred
+func (d *Compress) getRemote(ctx context.Context, path string) (string, model.Obj, error) {
+	remotePath, err := d.getActualPath(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	obj, err := op.Get(ctx, d.remoteStorage, remotePath+d.CompressedSuffix)
+	if err == nil && !obj.IsDir() {
+		return remotePath + d.CompressedSuffix, obj, nil
+	}
+	obj, err = op.Get(ctx, d.remoteStorage, remotePath)
+	if err != nil {
+		return "", nil, err
+	}
+	return remotePath, obj, nil
+}
+
+func (d *Compress) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			Name:     "Root",
+			IsFolder: true,
+			Path:     "/",
+		}, nil
+	}
+	remotePath, remoteObj, err := d.getRemote(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := d.toObj(ctx, stdpath.Dir(remotePath), remoteObj)
+	if err != nil {
+		return nil, err
+	}
+	obj.(*model.Object).Path = path
+	return obj, nil
+}
+
+func (d *Compress) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	remotePath, _, err := d.getRemote(ctx, file.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	remoteLink, remoteFile, err := op.Link(ctx, d.remoteStorage, remotePath, args)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(remotePath, d.CompressedSuffix) {
+		return remoteLink, nil
+	}
+
+	ss, err := stream.NewSeekableStream(stream.FileStream{Ctx: ctx, Obj: remoteFile}, remoteLink)
+	if err != nil {
+		return nil, err
+	}
+	h, err := readHeader(ss, true)
+	if err != nil {
+		_ = ss.Close()
+		return nil, err
+	}
+	// offsets[i] is where the i-th block starts in the compressed data
+	offsets := make([]int64, h.Count+1)
+	for i, l := range h.Blocks {
+		offsets[i+1] = offsets[i] + int64(l)
+	}
+	rangeReader := func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		start, end := httpRange.Start, h.Size
+		if httpRange.Length >= 0 && start+httpRange.Length < end {
+			end = start + httpRange.Length
+		}
+		if start >= end {
+			return io.NopCloser(bytes.NewReader(nil)), nil
+		}
+		first, last := start/h.BlockSize, (end-1)/h.BlockSize
+		remoteReader, err := ss.RangeRead(http_range.Range{
+			Start:  h.dataOffset() + offsets[first],
+			Length: offsets[last+1] - offsets[first],
+		})
+		if err != nil {
+			return nil, err
+		}
+		dec, err := newDecompressor(h.Algorithm, remoteReader)
+		if err != nil {
+			closeReader(remoteReader)
+			return nil, err
+		}
+		closeAll := func() error {
+			err := dec.Close()
+			closeReader(remoteReader)
+			return err
+		}
+		// skip the head of the first block
+		if _, err = io.CopyN(io.Discard, dec, start-first*h.BlockSize); err != nil {
+			_ = closeAll()
+			return nil, err
+		}
+		return utils.NewLimitReadCloser(dec, closeAll, end-start), nil
+	}
+	remoteClosers := utils.EmptyClosers()
+	remoteClosers.Add(ss)
+	return &model.Link{
+		RangeReadCloser: &model.RangeReadCloser{RangeReader: rangeReader, Closers: remoteClosers},
+	}, nil
+}
+
+func (d *Compress) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	remoteDir, err := d.getActualPath(parentDir.GetPath())
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	return op.MakeDir(ctx, d.remoteStorage, stdpath.Join(remoteDir, dirName))
+}
+
+func (d *Compress) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcRemotePath, _, err := d.getRemote(ctx, srcObj.GetPath())
+	if err != nil {
+		return err
+	}
+	dstRemoteDir, err := d.getActualPath(dstDir.GetPath())
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	return op.Move(ctx, d.remoteStorage, srcRemotePath, dstRemoteDir)
+}
+
+func (d *Compress) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	remotePath, _, err := d.getRemote(ctx, srcObj.GetPath())
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(remotePath, d.CompressedSuffix) {
+		newName += d.CompressedSuffix
+	}
+	return op.Rename(ctx, d.remoteStorage, remotePath, newName)
+}
+
+func (d *Compress) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcRemotePath, _, err := d.getRemote(ctx, srcObj.GetPath())
+	if err != nil {
+		return err
+	}
+	dstRemoteDir, err := d.getActualPath(dstDir.GetPath())
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	return op.Copy(ctx, d.remoteStorage, srcRemotePath, dstRemoteDir)
+}
+
+func (d *Compress) Remove(ctx context.Context, obj model.Obj) error {
+	remotePath, _, err := d.getRemote(ctx, obj.GetPath())
+	if err != nil {
+		return err
+	}
+	return op.Remove(ctx, d.remoteStorage, remotePath)
+}
+
+func (d *Compress) Put(ctx context.Context, dstDir model.Obj, streamer model.FileStreamer, up driver.UpdateProgress) error {
+	remoteDir, err := d.getActualPath(dstDir.GetPath())
+	if err != nil {
+		return fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+
+	// the compressed size must be known before uploading, so compress into a temp file first
+	tmpF, err := os.CreateTemp(conf.Conf.TempDir, "file-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tmpF.Close()
+		_ = os.Remove(tmpF.Name())
+	}()
+	h := &header{
+		Algorithm: d.algorithm,
+		Modified:  streamer.ModTime(),
+		BlockSize: d.BlockSize * utils.KB,
+	}
+	size := streamer.GetSize()
+	buf := make([]byte, h.BlockSize)
+	var compressedSize int64
+	for {
+		n, err := io.ReadFull(streamer, buf)
+		if n > 0 {
+			block, err := d.compressBlock(buf[:n])
+			if err != nil {
+				return fmt.Errorf("failed to compress: %w", err)
+			}
+			if _, err = tmpF.Write(block); err != nil {
+				return err
+			}
+			h.Size += int64(n)
+			h.Blocks = append(h.Blocks, uint32(len(block)))
+			compressedSize += int64(len(block))
+			if size > 0 {
+				up(float64(h.Size) / float64(size) * 50)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	h.Count = len(h.Blocks)
+	if _, err = tmpF.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	headerBytes := h.marshal()
+	streamOut := &stream.FileStream{
+		Obj: &model.Object{
+			ID:       streamer.GetID(),
+			Name:     streamer.GetName() + d.CompressedSuffix,
+			Size:     int64(len(headerBytes)) + compressedSize,
+			Modified: streamer.ModTime(),
+		},
+		Reader:            io.MultiReader(bytes.NewReader(headerBytes), tmpF),
+		Mimetype:          "application/octet-stream",
+		WebPutAsTask:      streamer.NeedStore(),
+		ForceStreamUpload: true,
+	}
+	err = op.Put(ctx, d.remoteStorage, remoteDir, streamOut, func(p float64) {
+		up(50 + p/2)
+	}, false)
+	if err != nil {
+		return err
+	}
+	// remove the plain file which is replaced by the compressed one
+	plainPath := stdpath.Join(remoteDir, streamer.GetName())
+	if obj, err := op.Get(ctx, d.remoteStorage, plainPath); err == nil && !obj.IsDir() {
+		if err := op.Remove(ctx, d.remoteStorage, plainPath); err != nil {
+			log.Warnf("failed to remove the replaced plain file [%s]: %+v", plainPath, err)
+		}
+	}
+	return nil
+}
+
+var _ driver.Driver = (*Compress)(nil)
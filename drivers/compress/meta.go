@@ -0,0 +1,28 @@
+package compress
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/op"
+)
+
+type Addition struct {
+	RemotePath       string `json:"remote_path" required:"true" help:"This is where the compressed data stores"`
+	Algorithm        string `json:"algorithm" type:"select" required:"true" options:"zstd,gzip" default:"zstd"`
+	BlockSize        int64  `json:"block_size" type:"number" required:"true" default:"1024" help:"files are compressed in independent blocks of this size so that they can be read from the middle (unit: KB)"`
+	CompressedSuffix string `json:"compressed_suffix" required:"true" default:".cmp" help:"for advanced user only! compressed files will have this suffix, files without it are served as-is"`
+	ShowHidden       bool   `json:"show_hidden" default:"true" required:"false" help:"show hidden directories and files"`
+}
+
+var config = driver.Config{
+	Name:        "Compress",
+	LocalSort:   true,
+	OnlyProxy:   true,
+	NoCache:     true,
+	DefaultRoot: "/",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Compress{}
+	})
+}
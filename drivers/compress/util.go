@@ -0,0 +1,190 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+	"github.com/Xhofe/go-cache"
+	"github.com/klauspost/compress/zstd"
+)
+
+// The compressed file starts with a fixed prelude, followed by the
+// compressed length of every block (the seek index) and the blocks.
+//
+//	0  magic "OLCZ"
+//	4  format version
+//	5  algorithm
+//	8  original size
+//	16 original modified time in milliseconds
+//	24 block size
+//	28 block count
+const (
+	magic         = "OLCZ"
+	formatVersion = 1
+	preludeSize   = 32
+)
+
+const (
+	algGzip byte = iota + 1
+	algZstd
+)
+
+type header struct {
+	Algorithm byte
+	Size      int64
+	Modified  time.Time
+	BlockSize int64
+	Count     int
+	// Blocks is the compressed length of each block, it's only read when needed
+	Blocks []uint32
+}
+
+func (h *header) dataOffset() int64 {
+	return preludeSize + 4*int64(h.Count)
+}
+
+func (h *header) marshal() []byte {
+	b := make([]byte, h.dataOffset())
+	copy(b, magic)
+	b[4] = formatVersion
+	b[5] = h.Algorithm
+	binary.BigEndian.PutUint64(b[8:], uint64(h.Size))
+	binary.BigEndian.PutUint64(b[16:], uint64(h.Modified.UnixMilli()))
+	binary.BigEndian.PutUint32(b[24:], uint32(h.BlockSize))
+	binary.BigEndian.PutUint32(b[28:], uint32(h.Count))
+	for i, l := range h.Blocks {
+		binary.BigEndian.PutUint32(b[preludeSize+4*i:], l)
+	}
+	return b
+}
+
+func parsePrelude(b []byte) (*header, error) {
+	if len(b) < preludeSize || string(b[:4]) != magic {
+		return nil, fmt.Errorf("not a compressed file")
+	}
+	if b[4] != formatVersion {
+		return nil, fmt.Errorf("unsupported format version %d", b[4])
+	}
+	h := &header{
+		Algorithm: b[5],
+		Size:      int64(binary.BigEndian.Uint64(b[8:])),
+		Modified:  time.UnixMilli(int64(binary.BigEndian.Uint64(b[16:]))),
+		BlockSize: int64(binary.BigEndian.Uint32(b[24:])),
+		Count:     int(binary.BigEndian.Uint32(b[28:])),
+	}
+	if h.Algorithm != algGzip && h.Algorithm != algZstd {
+		return nil, fmt.Errorf("unsupported algorithm %d", h.Algorithm)
+	}
+	if h.BlockSize <= 0 || (h.Size+h.BlockSize-1)/h.BlockSize != int64(h.Count) {
+		return nil, fmt.Errorf("illegal header")
+	}
+	return h, nil
+}
+
+// readHeader reads the header of the compressed file, with the seek index
+// if withIndex is true
+func readHeader(ss *stream.SeekableStream, withIndex bool) (*header, error) {
+	size := int64(preludeSize)
+	if ss.GetSize() < size {
+		return nil, fmt.Errorf("not a compressed file")
+	}
+	r, err := ss.RangeRead(http_range.Range{Start: 0, Length: size})
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, size)
+	_, err = io.ReadFull(r, b)
+	closeReader(r)
+	if err != nil {
+		return nil, err
+	}
+	h, err := parsePrelude(b)
+	if err != nil || !withIndex || h.Count == 0 {
+		return h, err
+	}
+	if h.dataOffset() > ss.GetSize() {
+		return nil, fmt.Errorf("illegal header")
+	}
+	r, err = ss.RangeRead(http_range.Range{Start: preludeSize, Length: 4 * int64(h.Count)})
+	if err != nil {
+		return nil, err
+	}
+	b = make([]byte, 4*h.Count)
+	_, err = io.ReadFull(r, b)
+	closeReader(r)
+	if err != nil {
+		return nil, err
+	}
+	h.Blocks = make([]uint32, h.Count)
+	for i := range h.Blocks {
+		h.Blocks[i] = binary.BigEndian.Uint32(b[4*i:])
+	}
+	return h, nil
+}
+
+func closeReader(r io.Reader) {
+	if c, ok := r.(io.Closer); ok {
+		_ = c.Close()
+	}
+}
+
+// getHeader reads the prelude of the remote file, the result is cached by
+// the path, size and modified time of the remote file
+func (d *Compress) getHeader(ctx context.Context, remotePath string, remoteObj model.Obj) (*header, error) {
+	key := fmt.Sprintf("%s:%d:%d", remotePath, remoteObj.GetSize(), remoteObj.ModTime().UnixMilli())
+	if h, ok := headerCache.Get(key); ok {
+		return h, nil
+	}
+	link, _, err := op.Link(ctx, d.remoteStorage, remotePath, model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	ss, err := stream.NewSeekableStream(stream.FileStream{Ctx: ctx, Obj: remoteObj}, link)
+	if err != nil {
+		return nil, err
+	}
+	defer ss.Close()
+	h, err := readHeader(ss, false)
+	if err != nil {
+		return nil, err
+	}
+	headerCache.Set(key, h, cache.WithEx[*header](time.Hour))
+	return h, nil
+}
+
+func (d *Compress) compressBlock(block []byte) ([]byte, error) {
+	if d.algorithm == algZstd {
+		return d.encoder.EncodeAll(block, nil), nil
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(block); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newDecompressor reads the concatenated blocks as one stream, both gzip
+// members and zstd frames can be concatenated
+func newDecompressor(algorithm byte, r io.Reader) (io.ReadCloser, error) {
+	if algorithm == algZstd {
+		dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return dec.IOReadCloser(), nil
+	}
+	return gzip.NewReader(r)
+}
@@ -0,0 +1,205 @@
+package compress
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/drivers/local"
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+)
+
+type memFile struct {
+	*bytes.Reader
+}
+
+func (memFile) Close() error { return nil }
+
+func memStream(t *testing.T, b []byte) *stream.SeekableStream {
+	t.Helper()
+	ss, err := stream.NewSeekableStream(stream.FileStream{
+		Ctx: context.Background(),
+		Obj: &model.Object{Name: "a.cmp", Size: int64(len(b))},
+	}, &model.Link{MFile: memFile{bytes.NewReader(b)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ss
+}
+
+func testHeader() *header {
+	return &header{
+		Algorithm: algZstd,
+		Size:      2500,
+		Modified:  time.UnixMilli(1700000000123),
+		BlockSize: 1024,
+		Count:     3,
+		Blocks:    []uint32{100, 200, 50},
+	}
+}
+
+func TestHeaderRoundTrip(t *testing.T) {
+	h := testHeader()
+	b := h.marshal()
+	if int64(len(b)) != h.dataOffset() {
+		t.Fatalf("expected %d bytes, got %d", h.dataOffset(), len(b))
+	}
+	got, err := readHeader(memStream(t, b), true)
+	if err != nil {
+		t.Fatalf("failed to read the header: %+v", err)
+	}
+	if got.Algorithm != h.Algorithm || got.Size != h.Size || !got.Modified.Equal(h.Modified) ||
+		got.BlockSize != h.BlockSize || got.Count != h.Count || fmt.Sprint(got.Blocks) != fmt.Sprint(h.Blocks) {
+		t.Fatalf("expected %+v, got %+v", h, got)
+	}
+	// the seek index is only read when asked
+	if got, err = readHeader(memStream(t, b), false); err != nil || got.Blocks != nil {
+		t.Fatalf("expected no seek index, got %+v, %v", got, err)
+	}
+}
+
+func TestParsePrelude(t *testing.T) {
+	valid := testHeader().marshal()[:preludeSize]
+	corrupt := func(f func(b []byte)) []byte {
+		b := bytes.Clone(valid)
+		f(b)
+		return b
+	}
+	tests := []struct {
+		name string
+		b    []byte
+		ok   bool
+	}{
+		{"valid", valid, true},
+		{"short", valid[:preludeSize-1], false},
+		{"magic", corrupt(func(b []byte) { b[0] = 'X' }), false},
+		{"version", corrupt(func(b []byte) { b[4] = formatVersion + 1 }), false},
+		{"algorithm", corrupt(func(b []byte) { b[5] = 0 }), false},
+		{"block size", corrupt(func(b []byte) { copy(b[24:28], []byte{0, 0, 0, 0}) }), false},
+		{"count", corrupt(func(b []byte) { b[31] = 4 }), false},
+	}
+	for _, tt := range tests {
+		if _, err := parsePrelude(tt.b); (err == nil) != tt.ok {
+			t.Errorf("%s: expected ok %v, got %v", tt.name, tt.ok, err)
+		}
+	}
+}
+
+func TestReadHeaderTruncated(t *testing.T) {
+	b := testHeader().marshal()
+	for _, n := range []int{0, preludeSize - 1, len(b) - 1} {
+		if _, err := readHeader(memStream(t, b[:n]), true); err == nil {
+			t.Errorf("expected an error reading %d bytes of the header", n)
+		}
+	}
+	// the prelude alone is enough without the seek index
+	if _, err := readHeader(memStream(t, b[:preludeSize]), false); err != nil {
+		t.Errorf("expected the prelude read, got %v", err)
+	}
+}
+
+// newCompress mounts a local storage of a temporary dir and a compress
+// storage of it using the algorithm with 1 KB blocks
+func newCompress(t *testing.T, algorithm string) (driver.Driver, model.Obj) {
+	db.Init(dbtest.Open(t))
+	ctx := context.Background()
+	// the blocks are compressed into a temporary file before the upload
+	conf.Conf.TempDir = t.TempDir()
+	remote := "/remote_" + algorithm
+	remoteID, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: remote,
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, t.TempDir()),
+	})
+	if err != nil {
+		t.Fatalf("failed to create local storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, remoteID) })
+	mount := "/compress_" + algorithm
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Compress",
+		MountPath: mount,
+		Addition: fmt.Sprintf(`{"remote_path":%q,"algorithm":%q,"block_size":1,"compressed_suffix":".cmp"}`,
+			remote, algorithm),
+	})
+	if err != nil {
+		t.Fatalf("failed to create compress storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, id) })
+	d, err := op.GetStorageByMountPath(mount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 5000)
+	for i := range data {
+		data[i] = byte(i * 7 % 251)
+	}
+	err = op.Put(ctx, d, "/", &stream.FileStream{
+		Obj:    &model.Object{Name: "a.bin", Size: int64(len(data)), Modified: time.Now()},
+		Reader: io.NopCloser(bytes.NewReader(data)),
+	}, func(float64) {})
+	if err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	file, err := op.Get(ctx, d, "/a.bin")
+	if err != nil {
+		t.Fatalf("failed to get: %+v", err)
+	}
+	if file.GetSize() != int64(len(data)) {
+		t.Fatalf("expected the original size %d, got %d", len(data), file.GetSize())
+	}
+	return d, file
+}
+
+func TestBlockSeek(t *testing.T) {
+	for _, algorithm := range []string{"zstd", "gzip"} {
+		t.Run(algorithm, func(t *testing.T) {
+			d, file := newCompress(t, algorithm)
+			link, err := d.Link(context.Background(), file, model.LinkArgs{})
+			if err != nil {
+				t.Fatalf("failed to link: %+v", err)
+			}
+			defer link.RangeReadCloser.Close()
+			for _, r := range []http_range.Range{
+				{Start: 0, Length: -1},
+				{Start: 0, Length: 1024},
+				{Start: 1000, Length: 100},
+				{Start: 1024, Length: 1024},
+				{Start: 2047, Length: 2000},
+				{Start: 4999, Length: 10},
+				{Start: 5000, Length: 10},
+			} {
+				rc, err := link.RangeReadCloser.RangeRead(context.Background(), r)
+				if err != nil {
+					t.Fatalf("failed to read %+v: %+v", r, err)
+				}
+				got, err := io.ReadAll(rc)
+				_ = rc.Close()
+				if err != nil {
+					t.Fatalf("failed to read %+v: %+v", r, err)
+				}
+				end := int64(5000)
+				if r.Length >= 0 && r.Start+r.Length < end {
+					end = r.Start + r.Length
+				}
+				expected := make([]byte, 0, max(end-r.Start, 0))
+				for i := r.Start; i < end; i++ {
+					expected = append(expected, byte(i*7%251))
+				}
+				if !bytes.Equal(got, expected) {
+					t.Errorf("unexpected content of %+v, got %d bytes", r, len(got))
+				}
+			}
+		})
+	}
+}
@@ -15,6 +15,11 @@ import (
 )
 
 func (d *Dropbox) refreshToken() error {
+	unlock, err := op.LockDriverToken(d)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	url := d.base + "/oauth2/token"
 	if utils.SliceContains([]string{"", DefaultClientID}, d.ClientID) {
 		url = d.OauthTokenURL
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/internal/op"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/clientcredentials"
 )
@@ -17,9 +18,15 @@ type customTokenSource struct {
 	config       *clientcredentials.Config
 	ctx          context.Context
 	refreshToken string
+	driver       *FebBox
 }
 
 func (c *customTokenSource) Token() (*oauth2.Token, error) {
+	unlock, err := op.LockDriverToken(c.driver)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
 	v := url.Values{}
 	if c.refreshToken != "" {
 		v.Set("grant_type", "refresh_token")
@@ -68,6 +75,9 @@ func (c *customTokenSource) Token() (*oauth2.Token, error) {
 	}
 
 	c.refreshToken = tokenResp.Data.RefreshToken
+	// saved before the unlock, so the other nodes reload it instead of refreshing
+	c.driver.Addition.RefreshToken = c.refreshToken
+	op.MustSaveDriverStorage(c.driver)
 
 	token := &oauth2.Token{
 		AccessToken:  tokenResp.Data.AccessToken,
@@ -84,5 +94,6 @@ func (d *FebBox) initializeOAuth2Token(ctx context.Context, oauth2Config *client
 		config:       oauth2Config,
 		ctx:          ctx,
 		refreshToken: refreshToken,
+		driver:       d,
 	})
 }
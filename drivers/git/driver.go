@@ -0,0 +1,127 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	stdpath "path"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/pkg/cron"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+type Git struct {
+	model.Storage
+	Addition
+	cron *cron.Cron
+}
+
+func (d *Git) Config() driver.Config {
+	return config
+}
+
+func (d *Git) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Git) Init(ctx context.Context) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("git is required: %w", err)
+	}
+	if d.RemoteURL != "" {
+		if err := checkRemoteURL(d.RemoteURL); err != nil {
+			return err
+		}
+	}
+	if d.RemoteURL != "" && !utils.Exists(d.RepoPath) {
+		out, err := gitCommand(ctx, "clone", "--mirror", "--quiet", "--", d.RemoteURL, d.RepoPath).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to clone %s: %w: %s", d.RemoteURL, err, out)
+		}
+	}
+	if _, err := d.run(ctx, "rev-parse", "--git-dir"); err != nil {
+		return fmt.Errorf("invalid repository: %w", err)
+	}
+	if d.RemoteURL != "" && d.RefreshInterval > 0 {
+		d.cron = cron.NewCron(time.Minute * time.Duration(d.RefreshInterval))
+		d.cron.Do(d.fetch)
+	}
+	return nil
+}
+
+func (d *Git) Drop(ctx context.Context) error {
+	if d.cron != nil {
+		d.cron.Stop()
+	}
+	return nil
+}
+
+// fetch updates the branches and the tags from the remote
+func (d *Git) fetch() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	_, err := d.run(ctx, "fetch", "--prune", "--quiet", "--", d.RemoteURL,
+		"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*")
+	if err != nil {
+		log.Warnf("failed to fetch %s: %+v", d.RemoteURL, err)
+		return
+	}
+	for _, dir := range []string{"/", "/" + branchesDir, "/" + tagsDir, "/" + commitsDir} {
+		op.ClearCache(d, dir)
+	}
+}
+
+func (d *Git) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	var objs []model.Obj
+	if utils.PathEqual(dir.GetPath(), "/") {
+		for _, name := range []string{branchesDir, tagsDir, commitsDir} {
+			objs = append(objs, &model.Object{Name: name, IsFolder: true})
+		}
+	} else if utils.PathEqual(dir.GetPath(), "/"+commitsDir) {
+		commits, err := d.commits(ctx)
+		if err != nil {
+			return nil, err
+		}
+		objs = commits
+	} else {
+		t, err := d.resolve(ctx, dir.GetPath())
+		if err != nil {
+			return nil, err
+		}
+		if t.rev != "" {
+			objs, err = d.lsTree(ctx, t.rev, t.treePath)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			objs = refsLevel(t.refs, t.prefix)
+		}
+	}
+	for _, obj := range objs {
+		obj.(*model.Object).Path = stdpath.Join(dir.GetPath(), obj.GetName())
+	}
+	return objs, nil
+}
+
+func (d *Git) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	oid := file.GetID()
+	if oid == "" {
+		return nil, errs.ObjectNotFound
+	}
+	rangeReader := func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		return d.openBlob(ctx, oid, httpRange.Start, httpRange.Length)
+	}
+	return &model.Link{
+		RangeReadCloser: &model.RangeReadCloser{RangeReader: rangeReader},
+	}, nil
+}
+
+var _ driver.Driver = (*Git)(nil)
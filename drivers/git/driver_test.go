@@ -0,0 +1,113 @@
+package git
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+)
+
+// newRemote creates a repository with a file on the main branch and a tag
+func newRemote(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet", "--initial-branch=main"},
+		{"add", "a.txt"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+		{"tag", "v1"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", args[0], err, out)
+		}
+	}
+	return dir
+}
+
+func names(objs []model.Obj) map[string]model.Obj {
+	res := make(map[string]model.Obj)
+	for _, obj := range objs {
+		res[obj.GetName()] = obj
+	}
+	return res
+}
+
+func TestClone(t *testing.T) {
+	remote := newRemote(t)
+	// the local repository is only allowed in the tests
+	allowedProtocols = append(allowedProtocols, "file")
+	t.Cleanup(func() { allowedProtocols = allowedProtocols[:len(allowedProtocols)-1] })
+	d := &Git{Addition: Addition{
+		RepoPath:     filepath.Join(t.TempDir(), "repo.git"),
+		RemoteURL:    "file://" + remote,
+		CommitsLimit: 10,
+	}}
+	ctx := context.Background()
+	if err := d.Init(ctx); err != nil {
+		t.Fatalf("failed to init: %+v", err)
+	}
+	t.Cleanup(func() { _ = d.Drop(ctx) })
+	for dir, name := range map[string]string{"/branches": "main", "/tags": "v1"} {
+		objs, err := d.List(ctx, &model.Object{Path: dir, IsFolder: true}, model.ListArgs{})
+		if err != nil {
+			t.Fatalf("failed to list %s: %+v", dir, err)
+		}
+		if _, ok := names(objs)[name]; !ok {
+			t.Fatalf("expected %s in %s, got %v", name, dir, objs)
+		}
+	}
+	objs, err := d.List(ctx, &model.Object{Path: "/branches/main", IsFolder: true}, model.ListArgs{})
+	if err != nil {
+		t.Fatalf("failed to list the main branch: %+v", err)
+	}
+	file, ok := names(objs)["a.txt"]
+	if !ok {
+		t.Fatalf("expected a.txt in the main branch, got %v", objs)
+	}
+	link, err := d.Link(ctx, file, model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed to link: %+v", err)
+	}
+	rc, err := link.RangeReadCloser.RangeRead(ctx, http_range.Range{Length: -1})
+	if err != nil {
+		t.Fatalf("failed to read: %+v", err)
+	}
+	defer rc.Close()
+	if b, _ := io.ReadAll(rc); string(b) != "hello" {
+		t.Fatalf("expected hello, got %q", b)
+	}
+}
+
+func TestCheckRemoteURL(t *testing.T) {
+	tests := []struct {
+		url string
+		ok  bool
+	}{
+		{"https://example.com/owner/repo.git", true},
+		{"http://example.com/owner/repo.git", true},
+		{"ssh://git@example.com/owner/repo.git", true},
+		{"git://example.com/owner/repo.git", true},
+		{"git@example.com:owner/repo.git", true},
+		{"ext::sh -c touch% /tmp/pwned", false},
+		{"file:///etc", false},
+		{"/srv/repo.git", false},
+		{"--upload-pack=touch /tmp/pwned", false},
+	}
+	for _, tt := range tests {
+		if err := checkRemoteURL(tt.url); (err == nil) != tt.ok {
+			t.Errorf("expected ok %v for %s, got %v", tt.ok, tt.url, err)
+		}
+	}
+}
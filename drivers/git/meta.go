@@ -0,0 +1,27 @@
+package git
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/op"
+)
+
+type Addition struct {
+	driver.RootPath
+	RepoPath        string `json:"repo_path" required:"true" help:"the local path of the bare repository, it will be cloned from the remote url if it doesn't exist"`
+	RemoteURL       string `json:"remote_url" help:"e.g. https://gitea.example.com/owner/repo.git, leave it empty to only read the local repository"`
+	RefreshInterval int    `json:"refresh_interval" type:"number" default:"0" help:"fetch from the remote url every n minutes, 0 to disable"`
+	CommitsLimit    int    `json:"commits_limit" type:"number" default:"100" help:"the number of the latest commits shown in the commits folder"`
+}
+
+var config = driver.Config{
+	Name:        "Git",
+	LocalSort:   true,
+	NoUpload:    true,
+	DefaultRoot: "/",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Git{}
+	})
+}
@@ -0,0 +1,292 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+)
+
+const (
+	branchesDir = "branches"
+	tagsDir     = "tags"
+	commitsDir  = "commits"
+	shortHash   = 12
+)
+
+var hashRegexp = regexp.MustCompile(`^[0-9a-f]{4,64}$`)
+
+// allowedProtocols are the transports allowed for the remote url, the others
+// such as ext:: may run arbitrary commands
+var allowedProtocols = []string{"http", "https", "ssh", "git"}
+
+// scpRegexp matches the scp-like syntax of ssh, e.g. git@example.com:owner/repo.git
+var scpRegexp = regexp.MustCompile(`^[\w.-]+@[\w.-]+:[^/]`)
+
+func checkRemoteURL(remote string) error {
+	if scpRegexp.MatchString(remote) {
+		return nil
+	}
+	u, err := url.Parse(remote)
+	if err != nil {
+		return fmt.Errorf("invalid remote url: %w", err)
+	}
+	if !utils.SliceContains(allowedProtocols, strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("the protocol of the remote url must be one of %s", strings.Join(allowedProtocols, ", "))
+	}
+	return nil
+}
+
+// gitCommand restricts the transports of git too, e.g. of the redirects
+func gitCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_ALLOW_PROTOCOL="+strings.Join(allowedProtocols, ":"))
+	return cmd
+}
+
+func (d *Git) command(ctx context.Context, args ...string) *exec.Cmd {
+	return gitCommand(ctx, append([]string{"--git-dir", d.RepoPath}, args...)...)
+}
+
+func (d *Git) run(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := d.command(ctx, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+type ref struct {
+	name     string
+	modified time.Time
+}
+
+// refs returns the branches or the tags, kind is heads or tags
+func (d *Git) refs(ctx context.Context, kind string) ([]ref, error) {
+	out, err := d.run(ctx, "for-each-ref", "--format=%(refname:strip=2)%00%(creatordate:unix)", "refs/"+kind)
+	if err != nil {
+		return nil, err
+	}
+	var refs []ref
+	for _, line := range strings.Split(string(out), "\n") {
+		name, unix, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		refs = append(refs, ref{name: name, modified: parseUnix(unix)})
+	}
+	return refs, nil
+}
+
+func parseUnix(s string) time.Time {
+	sec, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// commits returns the latest commits of HEAD
+func (d *Git) commits(ctx context.Context) ([]model.Obj, error) {
+	limit := d.CommitsLimit
+	if limit <= 0 {
+		limit = 100
+	}
+	out, err := d.run(ctx, "log", "--format=%H%x00%ct", "-n", strconv.Itoa(limit), "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	var objs []model.Obj
+	for _, line := range strings.Split(string(out), "\n") {
+		hash, unix, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		objs = append(objs, &model.Object{
+			ID:       hash,
+			Name:     hash[:min(shortHash, len(hash))],
+			Modified: parseUnix(unix),
+			IsFolder: true,
+		})
+	}
+	return objs, nil
+}
+
+func (d *Git) commitTime(ctx context.Context, rev string) time.Time {
+	out, err := d.run(ctx, "log", "-1", "--format=%ct", rev)
+	if err != nil {
+		return time.Time{}
+	}
+	return parseUnix(string(out))
+}
+
+// lsTree lists the tree of the path at the rev, the id of a file is its blob
+func (d *Git) lsTree(ctx context.Context, rev, path string) ([]model.Obj, error) {
+	out, err := d.run(ctx, "ls-tree", "-l", "-z", rev+":"+path)
+	if err != nil {
+		return nil, errs.ObjectNotFound
+	}
+	modified := d.commitTime(ctx, rev)
+	var objs []model.Obj
+	for _, item := range strings.Split(string(out), "\x00") {
+		// <mode> SP <type> SP <object> SP+ <size> TAB <file>
+		info, name, ok := strings.Cut(item, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(info)
+		if len(fields) != 4 {
+			continue
+		}
+		switch fields[1] {
+		case "tree":
+			objs = append(objs, &model.Object{
+				ID:       fields[2],
+				Name:     name,
+				Modified: modified,
+				IsFolder: true,
+			})
+		case "blob":
+			size, _ := strconv.ParseInt(fields[3], 10, 64)
+			objs = append(objs, &model.Object{
+				ID:       fields[2],
+				Name:     name,
+				Size:     size,
+				Modified: modified,
+			})
+		}
+		// submodules are skipped as their commits are not in this repository
+	}
+	return objs, nil
+}
+
+// target is what a path of the storage points to
+type target struct {
+	// rev and treePath are set if the path is in the tree of a ref or a commit
+	rev      string
+	treePath string
+	// prefix is set if the path is a level of the ref names containing slashes
+	kind   string
+	prefix string
+	refs   []ref
+}
+
+func (d *Git) resolve(ctx context.Context, path string) (*target, error) {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	switch segs[0] {
+	case branchesDir, tagsDir:
+		kind := "heads"
+		if segs[0] == tagsDir {
+			kind = "tags"
+		}
+		refs, err := d.refs(ctx, kind)
+		if err != nil {
+			return nil, err
+		}
+		names := make(map[string]bool, len(refs))
+		for _, r := range refs {
+			names[r.name] = true
+		}
+		// the longest ref name matching the path wins
+		for i := len(segs); i > 1; i-- {
+			name := strings.Join(segs[1:i], "/")
+			if names[name] {
+				return &target{
+					rev:      "refs/" + kind + "/" + name,
+					treePath: strings.Join(segs[i:], "/"),
+				}, nil
+			}
+		}
+		prefix := strings.Join(segs[1:], "/")
+		if prefix != "" {
+			prefix += "/"
+		}
+		for _, r := range refs {
+			if strings.HasPrefix(r.name, prefix) {
+				return &target{kind: kind, prefix: prefix, refs: refs}, nil
+			}
+		}
+	case commitsDir:
+		if len(segs) > 1 && hashRegexp.MatchString(segs[1]) {
+			return &target{rev: segs[1], treePath: strings.Join(segs[2:], "/")}, nil
+		}
+	}
+	return nil, errs.ObjectNotFound
+}
+
+// refsLevel lists the next level of the ref names under the prefix
+func refsLevel(refs []ref, prefix string) []model.Obj {
+	var objs []model.Obj
+	index := make(map[string]*model.Object)
+	for _, r := range refs {
+		rest, ok := strings.CutPrefix(r.name, prefix)
+		if !ok {
+			continue
+		}
+		name, _, _ := strings.Cut(rest, "/")
+		if obj, ok := index[name]; ok {
+			if r.modified.After(obj.Modified) {
+				obj.Modified = r.modified
+			}
+			continue
+		}
+		obj := &model.Object{
+			Name:     name,
+			Modified: r.modified,
+			IsFolder: true,
+		}
+		index[name] = obj
+		objs = append(objs, obj)
+	}
+	return objs
+}
+
+// blobReader reads a blob from the output of git cat-file
+type blobReader struct {
+	io.Reader
+	stdout io.ReadCloser
+	cmd    *exec.Cmd
+}
+
+func (r *blobReader) Close() error {
+	_ = r.stdout.Close()
+	_ = r.cmd.Process.Kill()
+	_ = r.cmd.Wait()
+	return nil
+}
+
+func (d *Git) openBlob(ctx context.Context, oid string, start, length int64) (io.ReadCloser, error) {
+	cmd := d.command(ctx, "cat-file", "blob", oid)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	r := &blobReader{Reader: stdout, stdout: stdout, cmd: cmd}
+	if start > 0 {
+		if _, err := io.CopyN(io.Discard, stdout, start); err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+	}
+	if length >= 0 {
+		r.Reader = io.LimitReader(stdout, length)
+	}
+	return r, nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/OpenListTeam/OpenList/drivers/base"
 	"github.com/OpenListTeam/OpenList/internal/driver"
 	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
 	"github.com/OpenListTeam/OpenList/pkg/http_range"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
 	"github.com/go-resty/resty/v2"
@@ -37,6 +38,11 @@ type googleDriveServiceAccount struct {
 }
 
 func (d *GoogleDrive) refreshToken() error {
+	unlock, err := op.LockDriverToken(d)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	// googleDriveServiceAccountFile gdsaFile
 	gdsaFile, gdsaFileErr := os.Stat(d.RefreshToken)
 	if gdsaFileErr == nil {
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/OpenListTeam/OpenList/drivers/base"
+	"github.com/OpenListTeam/OpenList/internal/op"
 	"github.com/go-resty/resty/v2"
 )
 
@@ -18,10 +19,15 @@ const (
 )
 
 func (d *GooglePhoto) refreshToken() error {
+	unlock, err := op.LockDriverToken(d)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	url := "https://www.googleapis.com/oauth2/v4/token"
 	var resp base.TokenResp
 	var e TokenError
-	_, err := base.RestyClient.R().SetResult(&resp).SetError(&e).
+	_, err = base.RestyClient.R().SetResult(&resp).SetError(&e).
 		SetFormData(map[string]string{
 			"client_id":     d.ClientID,
 			"client_secret": d.ClientSecret,
@@ -0,0 +1,236 @@
+package hasher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/fs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+type Hasher struct {
+	model.Storage
+	Addition
+	remoteStorage driver.Driver
+	hashTypes     []*utils.HashType
+}
+
+func (d *Hasher) Config() driver.Config {
+	return config
+}
+
+func (d *Hasher) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Hasher) Init(ctx context.Context) error {
+	hashTypes, err := parseHashTypes(d.Hashes)
+	if err != nil {
+		return err
+	}
+	d.hashTypes = hashTypes
+	d.RemotePath = utils.FixAndCleanPath(d.RemotePath)
+	//need remote storage exist
+	storage, err := fs.GetStorage(d.RemotePath, &fs.GetStoragesArgs{})
+	if err != nil {
+		return fmt.Errorf("can't find remote storage: %w", err)
+	}
+	d.remoteStorage = storage
+	return nil
+}
+
+func (d *Hasher) Drop(ctx context.Context) error {
+	return nil
+}
+
+// actual path is used for internal only
+func (d *Hasher) getActualPath(path string) (string, error) {
+	_, remoteActualPath, err := op.GetStorageAndActualPath(stdpath.Join(d.RemotePath, path))
+	if err != nil {
+		return "", fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	return remoteActualPath, nil
+}
+
+func (d *Hasher) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	remoteDir, err := d.getActualPath(dir.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	objs, err := op.List(ctx, d.remoteStorage, remoteDir, model.ListArgs{})
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(objs))
+	for i, obj := range objs {
+		paths[i] = stdpath.Join(dir.GetPath(), obj.GetName())
+	}
+	return d.withHashes(paths, objs), nil
+}
+
+func (d *Hasher) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			Name:     "Root",
+			IsFolder: true,
+			Path:     "/",
+		}, nil
+	}
+	remotePath, err := d.getActualPath(path)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := op.Get(ctx, d.remoteStorage, remotePath)
+	if err != nil {
+		return nil, err
+	}
+	return d.withHashes([]string{path}, []model.Obj{obj})[0], nil
+}
+
+func (d *Hasher) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	remotePath, err := d.getActualPath(file.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	remoteLink, remoteFile, err := op.Link(ctx, d.remoteStorage, remotePath, args)
+	if err != nil {
+		return nil, err
+	}
+	// the data only passes through here when it's proxied
+	if !d.HashOnRead || args.Redirect || !d.missing(file.GetHash()) {
+		return remoteLink, nil
+	}
+	size := remoteFile.GetSize()
+	rrc, err := toRangeReadCloser(remoteLink, size)
+	if err != nil {
+		return remoteLink, nil
+	}
+	path := file.GetPath()
+	rangeReader := func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		rc, err := rrc.RangeRead(ctx, httpRange)
+		if err != nil {
+			return nil, err
+		}
+		if httpRange.Start != 0 || (httpRange.Length >= 0 && httpRange.Length < size) {
+			return rc, nil
+		}
+		return &hashingReader{
+			ReadCloser: rc,
+			hasher:     utils.NewMultiHasher(d.hashTypes),
+			size:       size,
+			done: func(hi *utils.HashInfo) {
+				d.saveHashes(path, remoteFile, utils.NewHashInfoByMap(mergeHash(mergeHash(nil, remoteFile.GetHash()), *hi)))
+			},
+		}, nil
+	}
+	return &model.Link{
+		RangeReadCloser: &model.RangeReadCloser{RangeReader: rangeReader, Closers: utils.NewClosers(rrc)},
+	}, nil
+}
+
+func (d *Hasher) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	remoteDir, err := d.getActualPath(parentDir.GetPath())
+	if err != nil {
+		return err
+	}
+	return op.MakeDir(ctx, d.remoteStorage, stdpath.Join(remoteDir, dirName))
+}
+
+func (d *Hasher) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcRemotePath, err := d.getActualPath(srcObj.GetPath())
+	if err != nil {
+		return err
+	}
+	dstRemoteDir, err := d.getActualPath(dstDir.GetPath())
+	if err != nil {
+		return err
+	}
+	if err := op.Move(ctx, d.remoteStorage, srcRemotePath, dstRemoteDir); err != nil {
+		return err
+	}
+	if err := op.MoveHashCaches(d.ID, srcObj.GetPath(), stdpath.Join(dstDir.GetPath(), srcObj.GetName())); err != nil {
+		log.Warnf("failed to move hash caches of [%s]: %+v", srcObj.GetPath(), err)
+	}
+	return nil
+}
+
+func (d *Hasher) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	remotePath, err := d.getActualPath(srcObj.GetPath())
+	if err != nil {
+		return err
+	}
+	if err := op.Rename(ctx, d.remoteStorage, remotePath, newName); err != nil {
+		return err
+	}
+	if err := op.MoveHashCaches(d.ID, srcObj.GetPath(), stdpath.Join(stdpath.Dir(srcObj.GetPath()), newName)); err != nil {
+		log.Warnf("failed to move hash caches of [%s]: %+v", srcObj.GetPath(), err)
+	}
+	return nil
+}
+
+func (d *Hasher) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcRemotePath, err := d.getActualPath(srcObj.GetPath())
+	if err != nil {
+		return err
+	}
+	dstRemoteDir, err := d.getActualPath(dstDir.GetPath())
+	if err != nil {
+		return err
+	}
+	if err := op.Copy(ctx, d.remoteStorage, srcRemotePath, dstRemoteDir); err != nil {
+		return err
+	}
+	d.copyHashes(ctx, srcObj.GetPath(), stdpath.Join(dstDir.GetPath(), srcObj.GetName()))
+	return nil
+}
+
+func (d *Hasher) Remove(ctx context.Context, obj model.Obj) error {
+	remotePath, err := d.getActualPath(obj.GetPath())
+	if err != nil {
+		return err
+	}
+	if err := op.Remove(ctx, d.remoteStorage, remotePath); err != nil {
+		return err
+	}
+	if err := op.DeleteHashCaches(d.ID, obj.GetPath()); err != nil {
+		log.Warnf("failed to delete hash caches of [%s]: %+v", obj.GetPath(), err)
+	}
+	return nil
+}
+
+func (d *Hasher) Put(ctx context.Context, dstDir model.Obj, streamer model.FileStreamer, up driver.UpdateProgress) error {
+	remoteDir, err := d.getActualPath(dstDir.GetPath())
+	if err != nil {
+		return err
+	}
+	hashes := mergeHash(nil, streamer.GetHash())
+	if d.missing(streamer.GetHash()) {
+		hasher := utils.NewMultiHasher(d.hashTypes)
+		if _, err := stream.CacheFullInTempFileAndWriter(streamer, hasher); err != nil {
+			return err
+		}
+		hashes = mergeHash(hashes, *hasher.GetHashInfo())
+	}
+	name := streamer.GetName()
+	if err := op.Put(ctx, d.remoteStorage, remoteDir, streamer, up, false); err != nil {
+		return err
+	}
+	remoteObj, err := op.Get(ctx, d.remoteStorage, stdpath.Join(remoteDir, name))
+	if err != nil {
+		log.Warnf("failed to get the uploaded file [%s]: %+v", stdpath.Join(remoteDir, name), err)
+		return nil
+	}
+	hi := utils.NewHashInfoByMap(mergeHash(hashes, remoteObj.GetHash()))
+	d.saveHashes(stdpath.Join(dstDir.GetPath(), name), remoteObj, hi)
+	return nil
+}
+
+var _ driver.Driver = (*Hasher)(nil)
@@ -0,0 +1,150 @@
+package hasher_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/drivers/hasher"
+	_ "github.com/OpenListTeam/OpenList/drivers/local"
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+)
+
+// newHasher mounts a local storage of a temporary dir on /remote<n> and a
+// hasher storage of it on /h<n>
+func newHasher(t *testing.T, n int) (driver.Driver, string) {
+	db.Init(dbtest.Open(t))
+	root := t.TempDir()
+	conf.Conf.TempDir = t.TempDir()
+	ctx := context.Background()
+	remote := fmt.Sprintf("/remote%d", n)
+	remoteID, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: remote,
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed to create local storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, remoteID) })
+	mount := fmt.Sprintf("/h%d", n)
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Hasher",
+		MountPath: mount,
+		Addition:  fmt.Sprintf(`{"remote_path":%q,"hashes":"md5","hash_on_read":true}`, remote),
+	})
+	if err != nil {
+		t.Fatalf("failed to create hasher storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, id) })
+	d, err := op.GetStorageByMountPath(mount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, root
+}
+
+func md5Of(data string) string {
+	sum := md5.Sum([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashOf(t *testing.T, d driver.Driver, path string) string {
+	t.Helper()
+	op.ClearCache(d, utils.FixAndCleanPath(filepath.ToSlash(filepath.Dir(path))))
+	obj, err := op.Get(context.Background(), d, path)
+	if err != nil {
+		t.Fatalf("failed to get %s: %+v", path, err)
+	}
+	return obj.GetHash().GetHash(utils.MD5)
+}
+
+func TestPutCopyMoveRemove(t *testing.T) {
+	d, _ := newHasher(t, 1)
+	ctx := context.Background()
+	data := "hello hasher"
+	err := op.Put(ctx, d, "/", &stream.FileStream{
+		Obj:    &model.Object{Name: "a.txt", Size: int64(len(data)), Modified: time.Now()},
+		Reader: io.NopCloser(bytes.NewReader([]byte(data))),
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to put a.txt: %+v", err)
+	}
+	if h := hashOf(t, d, "/a.txt"); h != md5Of(data) {
+		t.Fatalf("expected the hash computed on put, got %q", h)
+	}
+	if err := op.MakeDir(ctx, d, "/dir"); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.Copy(ctx, d, "/a.txt", "/dir"); err != nil {
+		t.Fatal(err)
+	}
+	if h := hashOf(t, d, "/dir/a.txt"); h != md5Of(data) {
+		t.Errorf("expected the hash copied, got %q", h)
+	}
+	if err := op.MakeDir(ctx, d, "/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if err := op.Move(ctx, d, "/dir", "/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if h := hashOf(t, d, "/moved/dir/a.txt"); h != md5Of(data) {
+		t.Errorf("expected the hash moved, got %q", h)
+	}
+	if err := op.Remove(ctx, d, "/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if caches, err := op.GetHashCachesUnder(d.GetStorage().ID, "/moved"); err != nil || len(caches) != 0 {
+		t.Errorf("expected the hash caches removed, got %+v: %v", caches, err)
+	}
+}
+
+func TestHashOnRead(t *testing.T) {
+	d, root := newHasher(t, 2)
+	ctx := context.Background()
+	data := "written to the remote directly"
+	if err := os.WriteFile(filepath.Join(root, "b.txt"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if h := hashOf(t, d, "/b.txt"); h != "" {
+		t.Fatalf("expected no hash before reading, got %q", h)
+	}
+	link, _, err := op.Link(ctx, d, "/b.txt", model.LinkArgs{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a partial read doesn't save the hash
+	rc, err := link.RangeReadCloser.RangeRead(ctx, http_range.Range{Start: 1, Length: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.ReadAll(rc)
+	_ = rc.Close()
+	if h := hashOf(t, d, "/b.txt"); h != "" {
+		t.Fatalf("expected no hash after a partial read, got %q", h)
+	}
+	rc, err = link.RangeReadCloser.RangeRead(ctx, http_range.Range{Length: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.ReadAll(rc)
+	_ = rc.Close()
+	if h := hashOf(t, d, "/b.txt"); h != md5Of(data) {
+		t.Fatalf("expected the hash computed on a full read, got %q", h)
+	}
+}
@@ -0,0 +1,25 @@
+package hasher
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/op"
+)
+
+type Addition struct {
+	RemotePath string `json:"remote_path" required:"true" help:"This is where the files stores"`
+	Hashes     string `json:"hashes" required:"true" default:"md5" help:"the hashes to compute, separated by commas, supports md5, sha1 and sha256"`
+	HashOnRead bool   `json:"hash_on_read" default:"true" help:"compute the missing hashes when a file is fully downloaded through the proxy"`
+}
+
+var config = driver.Config{
+	Name:        "Hasher",
+	LocalSort:   true,
+	NoCache:     true,
+	DefaultRoot: "/",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Hasher{}
+	})
+}
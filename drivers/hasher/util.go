@@ -0,0 +1,195 @@
+package hasher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+func parseHashTypes(str string) ([]*utils.HashType, error) {
+	var res []*utils.HashType
+	for _, name := range strings.Split(str, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, ht := range []*utils.HashType{utils.MD5, utils.SHA1, utils.SHA256} {
+			if ht.Name == name {
+				res = append(res, ht)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unsupported hash: %s", name)
+		}
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no hash is configured")
+	}
+	return res, nil
+}
+
+// missing reports whether some of the configured hashes are absent
+func (d *Hasher) missing(hi utils.HashInfo) bool {
+	for _, ht := range d.hashTypes {
+		if hi.GetHash(ht) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeHash(dst map[*utils.HashType]string, hi utils.HashInfo) map[*utils.HashType]string {
+	if dst == nil {
+		dst = make(map[*utils.HashType]string)
+	}
+	for ht, v := range hi.All() {
+		if dst[ht] == "" && v != "" {
+			dst[ht] = v
+		}
+	}
+	return dst
+}
+
+func toObj(path string, obj model.Obj, hi utils.HashInfo) model.Obj {
+	objRes := model.Object{
+		Path:     path,
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		Ctime:    obj.CreateTime(),
+		IsFolder: obj.IsDir(),
+		HashInfo: hi,
+	}
+	thumb, ok := model.GetThumb(obj)
+	if !ok {
+		return &objRes
+	}
+	return &model.ObjThumb{
+		Object: objRes,
+		Thumbnail: model.Thumbnail{
+			Thumbnail: thumb,
+		},
+	}
+}
+
+// withHashes fills the missing hashes of the remote objs from the cache,
+// paths are the paths of the objs in this storage
+func (d *Hasher) withHashes(paths []string, objs []model.Obj) []model.Obj {
+	var query []string
+	for i, obj := range objs {
+		if !obj.IsDir() && d.missing(obj.GetHash()) {
+			query = append(query, paths[i])
+		}
+	}
+	cached := make(map[string]model.HashCache)
+	if len(query) > 0 {
+		caches, err := op.GetHashCaches(d.ID, query)
+		if err != nil {
+			log.Warnf("failed to get hash caches: %+v", err)
+		}
+		for _, c := range caches {
+			cached[c.Path] = c
+		}
+	}
+	res := make([]model.Obj, 0, len(objs))
+	for i, obj := range objs {
+		hi := obj.GetHash()
+		if c, ok := cached[paths[i]]; ok && c.Size == obj.GetSize() && c.Modified == obj.ModTime().UnixMilli() {
+			h := mergeHash(nil, hi)
+			hi = utils.NewHashInfoByMap(mergeHash(h, utils.FromString(c.Hash)))
+		}
+		res = append(res, toObj(paths[i], obj, hi))
+	}
+	return res
+}
+
+func (d *Hasher) saveHashes(path string, remoteObj model.Obj, hi utils.HashInfo) {
+	err := op.SaveHashCache(&model.HashCache{
+		StorageID: d.ID,
+		Path:      path,
+		Size:      remoteObj.GetSize(),
+		Modified:  remoteObj.ModTime().UnixMilli(),
+		Hash:      hi.String(),
+	})
+	if err != nil {
+		log.Warnf("failed to save hashes of [%s]: %+v", path, err)
+	}
+}
+
+// copyHashes copies the hash caches of src and its descendants to dst, they
+// are saved with the modified times of the copies which may be changed by
+// the remote
+func (d *Hasher) copyHashes(ctx context.Context, src, dst string) {
+	caches, err := op.GetHashCachesUnder(d.ID, src)
+	if err != nil {
+		log.Warnf("failed to get hash caches of [%s]: %+v", src, err)
+		return
+	}
+	for _, c := range caches {
+		path := stdpath.Join(dst, strings.TrimPrefix(c.Path, src))
+		remotePath, err := d.getActualPath(path)
+		if err != nil {
+			continue
+		}
+		remoteObj, err := op.Get(ctx, d.remoteStorage, remotePath)
+		if err != nil || remoteObj.GetSize() != c.Size {
+			continue
+		}
+		d.saveHashes(path, remoteObj, utils.FromString(c.Hash))
+	}
+}
+
+func toRangeReadCloser(link *model.Link, size int64) (model.RangeReadCloserIF, error) {
+	if link.RangeReadCloser != nil {
+		return link.RangeReadCloser, nil
+	}
+	if len(link.URL) > 0 {
+		return stream.GetRangeReadCloserFromLink(size, link)
+	}
+	if link.MFile != nil {
+		return &model.RangeReadCloser{
+			RangeReader: func(ctx context.Context, r http_range.Range) (io.ReadCloser, error) {
+				length := r.Length
+				if length < 0 || r.Start+length > size {
+					length = size - r.Start
+				}
+				return io.NopCloser(io.NewSectionReader(link.MFile, r.Start, length)), nil
+			},
+			Closers: utils.NewClosers(link.MFile),
+		}, nil
+	}
+	return nil, errs.NotSupport
+}
+
+// hashingReader computes the hashes of a full read of the file
+type hashingReader struct {
+	io.ReadCloser
+	hasher *utils.MultiHasher
+	size   int64
+	done   func(hi *utils.HashInfo)
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		_, _ = r.hasher.Write(p[:n])
+	}
+	if r.done != nil && r.hasher.Size() == r.size {
+		r.done(r.hasher.GetHashInfo())
+		r.done = nil
+	}
+	return n, err
+}
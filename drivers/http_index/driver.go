@@ -0,0 +1,169 @@
+package http_index
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strings"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/drivers/base"
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+)
+
+type HttpIndex struct {
+	model.Storage
+	Addition
+	base *url.URL
+}
+
+func (d *HttpIndex) Config() driver.Config {
+	return config
+}
+
+func (d *HttpIndex) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *HttpIndex) Init(ctx context.Context) error {
+	u, err := url.Parse(strings.TrimSpace(d.Address))
+	if err != nil {
+		return fmt.Errorf("invalid address: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid address: %s", d.Address)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+	d.base = u
+	return nil
+}
+
+func (d *HttpIndex) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *HttpIndex) getURL(path string, isDir bool) *url.URL {
+	u := *d.base
+	u.Path = stdpath.Join("/", d.base.Path, path)
+	if isDir && !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return &u
+}
+
+func (d *HttpIndex) authHeader() string {
+	if d.Username == "" && d.Password == "" {
+		return ""
+	}
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(d.Username+":"+d.Password))
+}
+
+func (d *HttpIndex) request(ctx context.Context, method string, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", base.UserAgent)
+	if auth := d.authHeader(); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	res, err := base.HttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 400 {
+		_ = res.Body.Close()
+		if res.StatusCode == http.StatusNotFound {
+			return nil, errs.ObjectNotFound
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, u.String(), res.Status)
+	}
+	return res, nil
+}
+
+func (d *HttpIndex) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	dirURL := d.getURL(dir.GetPath(), true)
+	res, err := d.request(ctx, http.MethodGet, dirURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	// the index page may be served after a redirect
+	entries, err := ParseIndex(body, res.Header.Get("Content-Type"), d.Format, res.Request.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the index of [%s]: %w", dir.GetPath(), err)
+	}
+	if d.HeadSize {
+		d.headSizes(ctx, dir.GetPath(), entries)
+	}
+	objs := make([]model.Obj, 0, len(entries))
+	for _, e := range entries {
+		objs = append(objs, &model.Object{
+			Path:     stdpath.Join(dir.GetPath(), e.Name),
+			Name:     e.Name,
+			Size:     e.Size,
+			Modified: e.Modified,
+			IsFolder: e.IsDir,
+		})
+	}
+	return objs, nil
+}
+
+// headSizes fills the unknown sizes and modified times of the files by HEAD requests
+func (d *HttpIndex) headSizes(ctx context.Context, dirPath string, entries []Entry) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 8)
+	for i := range entries {
+		e := &entries[i]
+		if e.IsDir || e.Size > 0 {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			res, err := d.request(ctx, http.MethodHead, d.getURL(stdpath.Join(dirPath, e.Name), false))
+			if err != nil {
+				return
+			}
+			_ = res.Body.Close()
+			if res.ContentLength > 0 {
+				e.Size = res.ContentLength
+			}
+			if e.Modified.IsZero() {
+				if t, err := http.ParseTime(res.Header.Get("Last-Modified")); err == nil {
+					e.Modified = t
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *HttpIndex) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	link := &model.Link{
+		URL: d.getURL(file.GetPath(), false).String(),
+	}
+	if auth := d.authHeader(); auth != "" {
+		link.Header = http.Header{"Authorization": []string{auth}}
+	}
+	return link, nil
+}
+
+var _ driver.Driver = (*HttpIndex)(nil)
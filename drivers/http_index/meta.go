@@ -0,0 +1,28 @@
+package http_index
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/op"
+)
+
+type Addition struct {
+	driver.RootPath
+	Address  string `json:"address" required:"true" help:"the base url of the http file server, e.g. https://mirror.example.com"`
+	Format   string `json:"format" type:"select" options:"auto,html,json,xml" default:"auto" help:"the format of the index pages, json and xml are the nginx autoindex_format"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	HeadSize bool   `json:"head_size" type:"bool" default:"false" help:"use head method to get the file size when the index page doesn't show it, e.g. python http.server"`
+}
+
+var config = driver.Config{
+	Name:        "HTTP Index",
+	LocalSort:   true,
+	NoUpload:    true,
+	DefaultRoot: "/",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &HttpIndex{}
+	})
+}
@@ -0,0 +1,111 @@
+package http_index_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/drivers/http_index"
+)
+
+type want struct {
+	name     string
+	size     int64
+	modified string
+	isDir    bool
+}
+
+func check(t *testing.T, body, contentType string, wants []want) {
+	t.Helper()
+	dirURL, _ := url.Parse("http://example.com/pub/")
+	entries, err := http_index.ParseIndex([]byte(body), contentType, "auto", dirURL)
+	if err != nil {
+		t.Fatalf("failed to parse: %+v", err)
+	}
+	if len(entries) != len(wants) {
+		t.Fatalf("expected %d entries, got %+v", len(wants), entries)
+	}
+	for i, w := range wants {
+		e := entries[i]
+		if e.Name != w.name || e.Size != w.size || e.IsDir != w.isDir {
+			t.Errorf("expected %+v, got %+v", w, e)
+		}
+		if w.modified != "" && e.Modified.Format(time.DateTime) != w.modified {
+			t.Errorf("expected modified %s of %s, got %s", w.modified, w.name, e.Modified)
+		}
+	}
+}
+
+func TestNginx(t *testing.T) {
+	check(t, `<html><head><title>Index of /pub/</title></head><body><h1>Index of /pub/</h1><hr><pre><a href="../">../</a>
+<a href="docs/">docs/</a>                                              08-Oct-2025 07:14                   -
+<a href="a%20b.iso">a b.iso</a>                                            09-Oct-2025 10:01          1073741824
+</pre><hr></body></html>`, "text/html", []want{
+		{"docs", 0, "2025-10-08 07:14:00", true},
+		{"a b.iso", 1073741824, "2025-10-09 10:01:00", false},
+	})
+}
+
+func TestApache(t *testing.T) {
+	check(t, `<html><body><h1>Index of /pub</h1><table>
+<tr><th><a href="?C=N;O=D">Name</a></th><th><a href="?C=M;O=A">Last modified</a></th><th><a href="?C=S;O=A">Size</a></th></tr>
+<tr><td><a href="/">Parent Directory</a></td><td>&nbsp;</td><td align="right">  - </td></tr>
+<tr><td><a href="sub/">sub/</a></td><td align="right">2025-10-08 07:14  </td><td align="right">  - </td></tr>
+<tr><td><a href="file.tar.gz">file.tar.gz</a></td><td align="right">2025-10-09 10:01  </td><td align="right">1.5M</td></tr>
+</table></body></html>`, "text/html", []want{
+		{"sub", 0, "2025-10-08 07:14:00", true},
+		{"file.tar.gz", 1572864, "2025-10-09 10:01:00", false},
+	})
+}
+
+func TestCaddy(t *testing.T) {
+	check(t, `<table><tbody>
+<tr><td><a href=".."><span>Go up</span></a></td></tr>
+<tr class="file"><td><a href="./2025-report.pdf"><span class="name">2025-report.pdf</span></a></td>
+<td class="size" data-size="12345"><div class="sizebar">12 KiB</div></td>
+<td class="timestamp"><time datetime="2025-10-09T10:01:02Z">10/09/2025 10:01:02 AM +00:00</time></td></tr>
+</tbody></table>`, "text/html", []want{
+		{"2025-report.pdf", 12345, "2025-10-09 10:01:02", false},
+	})
+}
+
+func TestLighttpd(t *testing.T) {
+	check(t, `<table><tbody>
+<tr class="d"><td class="n"><a href="../">Parent Directory</a>/</td><td class="m">&nbsp;</td><td class="s">- &nbsp;</td><td class="t">Directory</td></tr>
+<tr><td class="n"><a href="data.bin">data.bin</a></td><td class="m">2025-Oct-09 10:01:02</td><td class="s">4.0K</td><td class="t">application/octet-stream</td></tr>
+</tbody></table>`, "text/html", []want{
+		{"data.bin", 4096, "2025-10-09 10:01:02", false},
+	})
+}
+
+func TestPython(t *testing.T) {
+	check(t, `<ul>
+<li><a href="dir/">dir/</a></li>
+<li><a href="x.txt">x.txt</a></li>
+<li><a href="https://other.example.com/y.txt">y.txt</a></li>
+</ul>`, "text/html", []want{
+		{"dir", 0, "", true},
+		{"x.txt", 0, "", false},
+	})
+}
+
+func TestNginxJSON(t *testing.T) {
+	check(t, `[
+{ "name":"docs", "type":"directory", "mtime":"Wed, 08 Oct 2025 07:14:00 GMT" },
+{ "name":"a.iso", "type":"file", "mtime":"Thu, 09 Oct 2025 10:01:00 GMT", "size":1024 }
+]`, "application/json", []want{
+		{"docs", 0, "2025-10-08 07:14:00", true},
+		{"a.iso", 1024, "2025-10-09 10:01:00", false},
+	})
+}
+
+func TestNginxXML(t *testing.T) {
+	check(t, `<?xml version="1.0"?>
+<list>
+<directory mtime="2025-10-08T07:14:00Z">docs</directory>
+<file mtime="2025-10-09T10:01:00Z" size="1024">a.iso</file>
+</list>`, "text/xml", []want{
+		{"docs", 0, "2025-10-08 07:14:00", true},
+		{"a.iso", 1024, "2025-10-09 10:01:00", false},
+	})
+}
@@ -0,0 +1,35 @@
+package http_index
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Entry is an entry of an index page
+type Entry struct {
+	Name     string
+	Size     int64
+	Modified time.Time
+	IsDir    bool
+}
+
+// nginx autoindex_format json
+type jsonEntry struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Mtime string `json:"mtime"`
+	Size  int64  `json:"size"`
+}
+
+// nginx autoindex_format xml
+type xmlList struct {
+	XMLName xml.Name   `xml:"list"`
+	Entries []xmlEntry `xml:",any"`
+}
+
+type xmlEntry struct {
+	XMLName xml.Name
+	Mtime   string `xml:"mtime,attr"`
+	Size    int64  `xml:"size,attr"`
+	Name    string `xml:",chardata"`
+}
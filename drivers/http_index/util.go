@@ -0,0 +1,257 @@
+package http_index
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// ParseIndex parses the index page of the dir, format is one of auto, html,
+// json and xml
+func ParseIndex(body []byte, contentType, format string, dirURL *url.URL) ([]Entry, error) {
+	if format == "" || format == "auto" {
+		format = detectFormat(body, contentType)
+	}
+	switch format {
+	case "json":
+		return parseJSON(body)
+	case "xml":
+		return parseXML(body)
+	default:
+		return parseHTML(body, dirURL)
+	}
+}
+
+func detectFormat(body []byte, contentType string) string {
+	switch {
+	case strings.Contains(contentType, "json"):
+		return "json"
+	case strings.Contains(contentType, "xml") && !strings.Contains(contentType, "xhtml"):
+		return "xml"
+	}
+	trimmed := bytes.TrimSpace(body)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		return "json"
+	case bytes.HasPrefix(trimmed, []byte("<?xml")), bytes.HasPrefix(trimmed, []byte("<list")):
+		return "xml"
+	}
+	return "html"
+}
+
+func parseJSON(body []byte) ([]Entry, error) {
+	var items []jsonEntry
+	if err := json.Unmarshal(body, &items); err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(items))
+	for _, item := range items {
+		modified, _ := time.Parse(http.TimeFormat, item.Mtime)
+		entries = append(entries, Entry{
+			Name:     item.Name,
+			Size:     item.Size,
+			Modified: modified,
+			IsDir:    item.Type == "directory",
+		})
+	}
+	return entries, nil
+}
+
+func parseXML(body []byte) ([]Entry, error) {
+	var list xmlList
+	if err := xml.Unmarshal(body, &list); err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(list.Entries))
+	for _, item := range list.Entries {
+		modified, _ := time.Parse(time.RFC3339, item.Mtime)
+		entries = append(entries, Entry{
+			Name:     item.Name,
+			Size:     item.Size,
+			Modified: modified,
+			IsDir:    item.XMLName.Local == "directory",
+		})
+	}
+	return entries, nil
+}
+
+// parseHTML takes the links to the children of the dir as the entries, the
+// size and modified time are picked from the table row of the link, or the
+// text following the link on the same line
+func parseHTML(body []byte, dirURL *url.URL) ([]Entry, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	seen := make(map[string]bool)
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			if name, isDir, ok := childName(dirURL, attr(n, "href")); ok && !seen[name] {
+				seen[name] = true
+				e := Entry{Name: name, IsDir: isDir}
+				text, attrs := linkContext(n)
+				fillEntry(&e, text, attrs)
+				entries = append(entries, e)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return entries, nil
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// childName returns the name of the child of the dir the href points to
+func childName(dirURL *url.URL, href string) (string, bool, bool) {
+	if href == "" || strings.HasPrefix(href, "?") || strings.HasPrefix(href, "#") {
+		return "", false, false
+	}
+	u, err := dirURL.Parse(href)
+	if err != nil || u.Scheme != dirURL.Scheme || u.Host != dirURL.Host || u.RawQuery != "" {
+		return "", false, false
+	}
+	rel, ok := strings.CutPrefix(u.Path, dirURL.Path)
+	if !ok {
+		return "", false, false
+	}
+	isDir := strings.HasSuffix(rel, "/")
+	rel = strings.TrimSuffix(rel, "/")
+	if rel == "" || rel == "." || rel == ".." || strings.Contains(rel, "/") {
+		return "", false, false
+	}
+	return rel, isDir, true
+}
+
+// linkContext returns the text and the attributes around the link
+func linkContext(a *html.Node) (string, map[string]string) {
+	attrs := make(map[string]string)
+	for p := a.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == "tr" {
+			var buf strings.Builder
+			collect(p, a, &buf, attrs)
+			return buf.String(), attrs
+		}
+	}
+	var buf strings.Builder
+	for s := a.NextSibling; s != nil; s = s.NextSibling {
+		if s.Type == html.ElementNode && s.Data == "a" {
+			break
+		}
+		if s.Type == html.TextNode {
+			if i := strings.IndexByte(s.Data, '\n'); i >= 0 {
+				buf.WriteString(s.Data[:i])
+				break
+			}
+		}
+		collect(s, nil, &buf, attrs)
+	}
+	return buf.String(), attrs
+}
+
+func collect(n, skip *html.Node, buf *strings.Builder, attrs map[string]string) {
+	if n == skip {
+		return
+	}
+	switch n.Type {
+	case html.TextNode:
+		buf.WriteString(n.Data)
+		buf.WriteByte(' ')
+	case html.ElementNode:
+		for _, a := range n.Attr {
+			if a.Key == "data-size" || a.Key == "datetime" {
+				attrs[a.Key] = a.Val
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		collect(c, skip, buf, attrs)
+	}
+}
+
+var timeRegexp = regexp.MustCompile(`\d{1,2}-[A-Za-z]{3}-\d{4} \d{2}:\d{2}(?::\d{2})?|` +
+	`\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}(?::\d{2})?|` +
+	`\d{4}-[A-Za-z]{3}-\d{2} \d{2}:\d{2}(?::\d{2})?`)
+
+var timeLayouts = []string{
+	"02-Jan-2006 15:04", "02-Jan-2006 15:04:05", // nginx
+	"2006-01-02 15:04", "2006-01-02 15:04:05", // apache
+	"2006-01-02T15:04", "2006-01-02T15:04:05",
+	"2006-Jan-02 15:04", "2006-Jan-02 15:04:05", // lighttpd
+}
+
+var sizeRegexp = regexp.MustCompile(`(?i)(?:^|\s)(\d+(?:\.\d+)?)\s?([KMGTP]i?B?|B|bytes)?(?:\s|$)`)
+
+func fillEntry(e *Entry, text string, attrs map[string]string) {
+	if v, ok := attrs["datetime"]; ok {
+		e.Modified, _ = time.Parse(time.RFC3339, v)
+	}
+	if loc := timeRegexp.FindStringIndex(text); loc != nil {
+		if e.Modified.IsZero() {
+			for _, layout := range timeLayouts {
+				if t, err := time.Parse(layout, text[loc[0]:loc[1]]); err == nil {
+					e.Modified = t
+					break
+				}
+			}
+		}
+		text = text[:loc[0]] + " " + text[loc[1]:]
+	}
+	if e.IsDir {
+		return
+	}
+	if v, ok := attrs["data-size"]; ok {
+		if size, err := strconv.ParseInt(v, 10, 64); err == nil {
+			e.Size = size
+			return
+		}
+	}
+	matches := sizeRegexp.FindAllStringSubmatch(text, -1)
+	if len(matches) == 0 {
+		return
+	}
+	m := matches[len(matches)-1]
+	e.Size = parseSize(m[1], m[2])
+}
+
+func parseSize(num, unit string) int64 {
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	if unit == "" {
+		return int64(f)
+	}
+	switch strings.ToUpper(unit[:1]) {
+	case "K":
+		f *= 1 << 10
+	case "M":
+		f *= 1 << 20
+	case "G":
+		f *= 1 << 30
+	case "T":
+		f *= 1 << 40
+	case "P":
+		f *= 1 << 50
+	}
+	return int64(f)
+}
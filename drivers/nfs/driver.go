@@ -0,0 +1,124 @@
+package nfs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/OpenListTeam/OpenList/pkg/utils/random"
+	"github.com/willscott/go-nfs-client/nfs"
+)
+
+type NFS struct {
+	model.Storage
+	Addition
+	mount  *nfs.Mount
+	target *nfs.Target
+}
+
+func (d *NFS) Config() driver.Config {
+	return config
+}
+
+func (d *NFS) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *NFS) Init(ctx context.Context) error {
+	return d.initClient()
+}
+
+func (d *NFS) Drop(ctx context.Context) error {
+	if d.target != nil {
+		_ = d.mount.Unmount()
+		d.target.Close()
+		d.mount.Close()
+	}
+	return nil
+}
+
+func (d *NFS) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	entries, err := d.target.ReadDirPlus(dir.GetPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, errs.ObjectNotFound
+		}
+		return nil, err
+	}
+	return utils.SliceConvert(entries, func(src *nfs.EntryPlus) (model.Obj, error) {
+		return &model.Object{
+			Path:     path.Join(dir.GetPath(), src.Name()),
+			Name:     src.Name(),
+			Size:     src.Size(),
+			Modified: src.ModTime(),
+			IsFolder: src.IsDir(),
+		}, nil
+	})
+}
+
+func (d *NFS) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	f, err := d.target.Open(file.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	return &model.Link{
+		MFile: f,
+	}, nil
+}
+
+func (d *NFS) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	_, err := d.target.Mkdir(path.Join(parentDir.GetPath(), dirName), 0o755)
+	return err
+}
+
+func (d *NFS) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return d.target.Rename(srcObj.GetPath(), path.Join(dstDir.GetPath(), srcObj.GetName()))
+}
+
+func (d *NFS) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	return d.target.Rename(srcObj.GetPath(), path.Join(path.Dir(srcObj.GetPath()), newName))
+}
+
+func (d *NFS) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return errs.NotSupport
+}
+
+func (d *NFS) Remove(ctx context.Context, obj model.Obj) error {
+	if obj.IsDir() {
+		return d.target.RemoveAll(obj.GetPath())
+	}
+	return d.target.Remove(obj.GetPath())
+}
+
+func (d *NFS) Put(ctx context.Context, dstDir model.Obj, stream model.FileStreamer, up driver.UpdateProgress) error {
+	dstPath := path.Join(dstDir.GetPath(), stream.GetName())
+	// the file is written under a temporary name and renamed over the old one,
+	// so a failed upload keeps the old file
+	tmpPath := path.Join(dstDir.GetPath(), fmt.Sprintf(".%s.%s.tmp", stream.GetName(), random.String(8)))
+	tmpFile, err := d.target.OpenFile(tmpPath, 0o666)
+	if err != nil {
+		return err
+	}
+	err = utils.CopyWithCtx(ctx, tmpFile, driver.NewLimitedUploadStream(ctx, stream), stream.GetSize(), up)
+	if err == nil {
+		err = tmpFile.Close()
+	} else {
+		_ = tmpFile.Close()
+	}
+	if err == nil {
+		err = d.target.Rename(tmpPath, dstPath)
+	}
+	if err != nil {
+		_ = d.target.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+var _ driver.Driver = (*NFS)(nil)
@@ -0,0 +1,107 @@
+package nfs
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	gonfs "github.com/willscott/go-nfs"
+	"github.com/willscott/go-nfs/helpers"
+	"github.com/willscott/go-nfs/helpers/memfs"
+)
+
+func newTestNFS(t *testing.T) *NFS {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+	mem := memfs.New()
+	// memfs only acknowledges the root when it has a file
+	_, _ = mem.Create("/.keep")
+	handler := helpers.NewCachingHandler(helpers.NewNullAuthHandler(mem), 1024)
+	go func() {
+		_ = gonfs.Serve(listener, handler)
+	}()
+	d := &NFS{Addition: Addition{
+		Address: "127.0.0.1",
+		Port:    listener.Addr().(*net.TCPAddr).Port,
+		Export:  "/",
+	}}
+	if err := d.Init(context.Background()); err != nil {
+		t.Fatalf("failed to init: %+v", err)
+	}
+	t.Cleanup(func() { _ = d.Drop(context.Background()) })
+	return d
+}
+
+func list(t *testing.T, d *NFS, path string) map[string]model.Obj {
+	t.Helper()
+	objs, err := d.List(context.Background(), &model.Object{Path: path, IsFolder: true}, model.ListArgs{})
+	if err != nil {
+		t.Fatalf("failed to list %s: %+v", path, err)
+	}
+	res := make(map[string]model.Obj)
+	for _, obj := range objs {
+		res[obj.GetName()] = obj
+	}
+	return res
+}
+
+func put(t *testing.T, d *NFS, dir, name string, data []byte) {
+	t.Helper()
+	err := d.Put(context.Background(), &model.Object{Path: dir, IsFolder: true}, &stream.FileStream{
+		Obj:    &model.Object{Name: name, Size: int64(len(data))},
+		Reader: io.NopCloser(bytes.NewReader(data)),
+	}, func(float64) {})
+	if err != nil {
+		t.Fatalf("failed to put %s: %+v", name, err)
+	}
+}
+
+func TestNFS(t *testing.T) {
+	ctx := context.Background()
+	d := newTestNFS(t)
+	root := &model.Object{Path: "/", IsFolder: true}
+	if err := d.MakeDir(ctx, root, "dir"); err != nil {
+		t.Fatalf("failed to mkdir: %+v", err)
+	}
+	data := bytes.Repeat([]byte("0123456789"), 100000)
+	put(t, d, "/dir", "a.bin", []byte("a longer content to be overwritten"))
+	put(t, d, "/dir", "a.bin", data)
+
+	files := list(t, d, "/dir")
+	obj, ok := files["a.bin"]
+	if !ok || obj.GetSize() != int64(len(data)) || len(files) != 1 {
+		t.Fatalf("unexpected files: %+v", files)
+	}
+	link, err := d.Link(ctx, obj, model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed to link: %+v", err)
+	}
+	buf := make([]byte, 20)
+	if _, err := link.MFile.ReadAt(buf, 99995); err != nil || !bytes.Equal(buf, data[99995:100015]) {
+		t.Errorf("unexpected range %q: %+v", buf, err)
+	}
+	_ = link.MFile.Close()
+
+	if err := d.Rename(ctx, obj, "b.bin"); err != nil {
+		t.Fatalf("failed to rename: %+v", err)
+	}
+	if err := d.Move(ctx, &model.Object{Path: "/dir/b.bin", Name: "b.bin"}, root); err != nil {
+		t.Fatalf("failed to move: %+v", err)
+	}
+	if _, ok := list(t, d, "/")["b.bin"]; !ok {
+		t.Fatal("expected the moved file in the root")
+	}
+	if err := d.Remove(ctx, &model.Object{Path: "/dir", Name: "dir", IsFolder: true}); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	if _, ok := list(t, d, "/")["dir"]; ok {
+		t.Fatal("expected the dir removed")
+	}
+}
@@ -0,0 +1,29 @@
+package nfs
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/op"
+)
+
+type Addition struct {
+	Address string `json:"address" required:"true" help:"the host of the nfs server"`
+	Port    int    `json:"port" type:"number" default:"0" help:"the port serving both mount and nfs, 0 to ask the portmapper"`
+	Export  string `json:"export" required:"true" help:"the exported path, e.g. /volume1/share"`
+	UID     uint32 `json:"uid" type:"number" default:"0"`
+	GID     uint32 `json:"gid" type:"number" default:"0"`
+	driver.RootPath
+}
+
+var config = driver.Config{
+	Name:        "NFS",
+	LocalSort:   true,
+	OnlyLocal:   true,
+	DefaultRoot: "/",
+	CheckStatus: true,
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &NFS{}
+	})
+}
@@ -0,0 +1,39 @@
+package nfs
+
+import (
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/willscott/go-nfs-client/nfs"
+	"github.com/willscott/go-nfs-client/nfs/rpc"
+)
+
+// do others that not defined in Driver interface
+
+func (d *NFS) initClient() error {
+	var mount *nfs.Mount
+	if d.Port > 0 {
+		// servers like go-nfs serve mount and nfs on the same port without portmapper
+		client, err := rpc.DialTCP("tcp", net.JoinHostPort(d.Address, strconv.Itoa(d.Port)), os.Geteuid() == 0)
+		if err != nil {
+			return err
+		}
+		mount = &nfs.Mount{Client: client}
+	} else {
+		var err error
+		mount, err = nfs.DialMount(d.Address, 0)
+		if err != nil {
+			return err
+		}
+	}
+	hostname, _ := os.Hostname()
+	target, err := mount.Mount(d.Export, rpc.NewAuthUnix(hostname, d.UID, d.GID).Auth())
+	if err != nil {
+		mount.Close()
+		return err
+	}
+	d.mount = mount
+	d.target = target
+	return nil
+}
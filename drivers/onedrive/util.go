@@ -61,7 +61,11 @@ func (d *Onedrive) GetMetaUrl(auth bool, path string) string {
 }
 
 func (d *Onedrive) refreshToken() error {
-	var err error
+	unlock, err := op.LockDriverToken(d)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	for i := 0; i < 3; i++ {
 		err = d._refreshToken()
 		if err == nil {
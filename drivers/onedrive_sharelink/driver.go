@@ -8,6 +8,7 @@ import (
 	"github.com/OpenListTeam/OpenList/internal/driver"
 	"github.com/OpenListTeam/OpenList/internal/errs"
 	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
 	"github.com/OpenListTeam/OpenList/pkg/cron"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
 	log "github.com/sirupsen/logrus"
@@ -34,14 +35,17 @@ func (d *OnedriveSharelink) Init(ctx context.Context) error {
 	// If there is "-my" in the URL, it is NOT a SharePoint link
 	d.IsSharepoint = !strings.Contains(d.ShareLinkURL, "-my")
 
-	// Initialize cron job to run every hour
-	d.cron = cron.NewCron(time.Hour * 1)
+	// Initialize cron job to run every hour, the headers refreshed by the
+	// leader are saved for the other nodes
+	d.cron = cron.NewCron(time.Hour * 1).LeaderOnly()
 	d.cron.Do(func() {
 		var err error
 		d.Headers, err = d.getHeaders()
 		if err != nil {
 			log.Errorf("%+v", err)
+			return
 		}
+		op.MustSaveDriverStorage(d)
 	})
 
 	// Get initial headers
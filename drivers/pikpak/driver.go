@@ -90,7 +90,13 @@ func (d *PikPak) Init(ctx context.Context) (err error) {
 		}
 	} else {
 		// 如果没有填写RefreshToken，尝试登录 获取 refreshToken
-		if err = d.login(); err != nil {
+		unlock, err := op.LockDriverToken(d)
+		if err != nil {
+			return err
+		}
+		err = d.login()
+		unlock()
+		if err != nil {
 			return err
 		}
 	}
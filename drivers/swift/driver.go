@@ -0,0 +1,148 @@
+package swift
+
+import (
+	"context"
+	"fmt"
+	"io"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/OpenListTeam/OpenList/server/common"
+	"github.com/ncw/swift/v2"
+)
+
+type Swift struct {
+	model.Storage
+	Addition
+	conn *swift.Connection
+}
+
+func (d *Swift) Config() driver.Config {
+	return config
+}
+
+func (d *Swift) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Swift) Init(ctx context.Context) error {
+	if d.ChunkSize <= 0 {
+		d.ChunkSize = 1024
+	}
+	d.conn = d.newConnection()
+	return d.conn.Authenticate(ctx)
+}
+
+func (d *Swift) Drop(ctx context.Context) error {
+	return nil
+}
+
+func (d *Swift) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	if utils.PathEqual(dir.GetPath(), "/") {
+		return d.listContainers(ctx)
+	}
+	return d.listDir(ctx, dir.GetPath())
+}
+
+func (d *Swift) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	container, object := splitPath(file.GetPath())
+	if d.TempURLKey != "" && !common.ShouldProxy(d, file.GetName()) {
+		u, err := d.tempURL(container, object)
+		if err != nil {
+			return nil, err
+		}
+		return &model.Link{URL: u}, nil
+	}
+	// the connection takes care of the reauthentication
+	rangeReader := func(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+		h := swift.Headers{}
+		if r := http_range.ApplyRangeToHttpHeader(httpRange, nil).Get("Range"); r != "" {
+			h["Range"] = r
+		}
+		rc, _, err := d.conn.ObjectOpen(ctx, container, object, false, h)
+		return rc, err
+	}
+	return &model.Link{
+		RangeReadCloser: &model.RangeReadCloser{RangeReader: rangeReader},
+	}, nil
+}
+
+func (d *Swift) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	if utils.PathEqual(parentDir.GetPath(), "/") {
+		return d.conn.ContainerCreate(ctx, dirName, nil)
+	}
+	container, object := splitPath(stdpath.Join(parentDir.GetPath(), dirName))
+	return d.makeDir(ctx, container, object)
+}
+
+func (d *Swift) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	if err := d.Copy(ctx, srcObj, dstDir); err != nil {
+		return err
+	}
+	return d.Remove(ctx, srcObj)
+}
+
+func (d *Swift) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	if err := d.copy(ctx, srcObj, stdpath.Join(stdpath.Dir(srcObj.GetPath()), newName)); err != nil {
+		return err
+	}
+	return d.Remove(ctx, srcObj)
+}
+
+func (d *Swift) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	return d.copy(ctx, srcObj, stdpath.Join(dstDir.GetPath(), srcObj.GetName()))
+}
+
+func (d *Swift) copy(ctx context.Context, srcObj model.Obj, dstPath string) error {
+	srcContainer, srcObject := splitPath(srcObj.GetPath())
+	dstContainer, dstObject := splitPath(dstPath)
+	if srcObj.IsDir() {
+		return d.copyDir(ctx, srcContainer, srcObject, dstContainer, dstObject)
+	}
+	if dstObject == "" {
+		return errs.NotSupport
+	}
+	_, err := d.conn.ObjectCopy(ctx, srcContainer, srcObject, dstContainer, dstObject, nil)
+	return err
+}
+
+func (d *Swift) Remove(ctx context.Context, obj model.Obj) error {
+	container, object := splitPath(obj.GetPath())
+	if obj.IsDir() {
+		return d.removeDir(ctx, container, object)
+	}
+	return d.removeObject(ctx, container, object)
+}
+
+func (d *Swift) Put(ctx context.Context, dstDir model.Obj, s model.FileStreamer, up driver.UpdateProgress) error {
+	container, object := splitPath(stdpath.Join(dstDir.GetPath(), s.GetName()))
+	if object == "" {
+		return fmt.Errorf("files can't be uploaded to the root, create a container first")
+	}
+	reader := driver.NewLimitedUploadStream(ctx, &driver.ReaderUpdatingProgress{
+		Reader:         s,
+		UpdateProgress: up,
+	})
+	// the segments of the large object being replaced are removed after the
+	// new one is uploaded
+	segmentContainer, segments, err := d.largeObjectSegments(ctx, container, object)
+	if err != nil {
+		return err
+	}
+	chunkSize := d.ChunkSize * utils.MB
+	if s.GetSize() <= chunkSize {
+		_, err = d.conn.ObjectPut(ctx, container, object, reader, false, "", s.GetMimetype(), nil)
+	} else {
+		err = d.putLargeObject(ctx, container, object, reader, s.GetSize(), chunkSize, s.GetMimetype())
+	}
+	if err != nil {
+		return err
+	}
+	return d.removeSegments(ctx, segmentContainer, segments)
+}
+
+var _ driver.Driver = (*Swift)(nil)
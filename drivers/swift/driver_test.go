@@ -0,0 +1,148 @@
+package swift
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/ncw/swift/v2/swifttest"
+)
+
+func newTestSwift(t *testing.T, largeObject string) *Swift {
+	srv, err := swifttest.NewSwiftServer("localhost")
+	if err != nil {
+		t.Fatalf("failed to start the swift server: %+v", err)
+	}
+	t.Cleanup(srv.Close)
+	d := &Swift{Addition: Addition{
+		AuthURL:     srv.AuthURL,
+		AuthVersion: "1",
+		UserName:    swifttest.TEST_ACCOUNT,
+		ApiKey:      swifttest.TEST_ACCOUNT,
+		ChunkSize:   1,
+		LargeObject: largeObject,
+	}}
+	if err := d.Init(context.Background()); err != nil {
+		t.Fatalf("failed to init: %+v", err)
+	}
+	return d
+}
+
+func list(t *testing.T, d *Swift, path string) map[string]model.Obj {
+	t.Helper()
+	objs, err := d.List(context.Background(), &model.Object{Path: path, IsFolder: true}, model.ListArgs{})
+	if err != nil {
+		t.Fatalf("failed to list %s: %+v", path, err)
+	}
+	res := make(map[string]model.Obj)
+	for _, obj := range objs {
+		res[obj.GetName()] = obj
+	}
+	return res
+}
+
+func put(t *testing.T, d *Swift, dir, name string, data []byte) {
+	t.Helper()
+	err := d.Put(context.Background(), &model.Object{Path: dir, IsFolder: true}, &stream.FileStream{
+		Obj:      &model.Object{Name: name, Size: int64(len(data))},
+		Reader:   io.NopCloser(bytes.NewReader(data)),
+		Mimetype: "application/octet-stream",
+	}, func(float64) {})
+	if err != nil {
+		t.Fatalf("failed to put %s: %+v", name, err)
+	}
+}
+
+func read(t *testing.T, d *Swift, obj model.Obj, r http_range.Range) []byte {
+	t.Helper()
+	link, err := d.Link(context.Background(), obj, model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed to link: %+v", err)
+	}
+	rc, err := link.RangeReadCloser.RangeRead(context.Background(), r)
+	if err != nil {
+		t.Fatalf("failed to read: %+v", err)
+	}
+	defer rc.Close()
+	b, _ := io.ReadAll(rc)
+	return b
+}
+
+func TestSwift(t *testing.T) {
+	for _, largeObject := range []string{"slo", "dlo"} {
+		t.Run(largeObject, func(t *testing.T) {
+			ctx := context.Background()
+			d := newTestSwift(t, largeObject)
+			root := &model.Object{Path: "/", IsFolder: true}
+			if err := d.MakeDir(ctx, root, "bucket"); err != nil {
+				t.Fatalf("failed to create the container: %+v", err)
+			}
+			if err := d.MakeDir(ctx, &model.Object{Path: "/bucket", IsFolder: true}, "dir"); err != nil {
+				t.Fatalf("failed to create the dir: %+v", err)
+			}
+			small := []byte("hello swift")
+			large := bytes.Repeat([]byte("0123456789abcdef"), int(utils.MB*5/2/16))
+			put(t, d, "/bucket/dir", "small.txt", small)
+			put(t, d, "/bucket/dir", "large.bin", large)
+
+			if _, ok := list(t, d, "/")["bucket"]; !ok {
+				t.Fatal("expected the container in the root")
+			}
+			if obj, ok := list(t, d, "/bucket")["dir"]; !ok || !obj.IsDir() {
+				t.Fatal("expected the pseudo directory")
+			}
+			files := list(t, d, "/bucket/dir")
+			if len(files) != 2 {
+				t.Fatalf("unexpected files: %+v", files)
+			}
+			if b := read(t, d, files["small.txt"], http_range.Range{Start: 6, Length: -1}); string(b) != "swift" {
+				t.Errorf("unexpected range: %q", b)
+			}
+			if b := read(t, d, files["large.bin"], http_range.Range{Length: -1}); !bytes.Equal(b, large) {
+				t.Errorf("unexpected large object of %d bytes", len(b))
+			}
+			start := int64(utils.MB - 8)
+			if b := read(t, d, files["large.bin"], http_range.Range{Start: start, Length: 16}); !bytes.Equal(b, large[start:start+16]) {
+				t.Errorf("unexpected range across the segments: %q", b)
+			}
+
+			// replacing the large object keeps only the new segments
+			segments := func() int {
+				names, err := d.conn.ObjectNamesAll(ctx, "bucket_segments", nil)
+				if err != nil {
+					t.Fatalf("failed to list the segments: %+v", err)
+				}
+				return len(names)
+			}
+			n := segments()
+			put(t, d, "/bucket/dir", "large.bin", large)
+			if got := segments(); got != n {
+				t.Errorf("expected %d segments after replacing the large object, got %d", n, got)
+			}
+			// swifttest keeps the manifest headers of the replaced object, so
+			// only the segments are checked and the object is deleted directly
+			put(t, d, "/bucket/dir", "large.bin", small)
+			if got := segments(); got != 0 {
+				t.Errorf("expected no segments after replacing with a small object, got %d", got)
+			}
+			if err := d.conn.ObjectDelete(ctx, "bucket", "dir/large.bin"); err != nil {
+				t.Fatalf("failed to delete the replaced object: %+v", err)
+			}
+
+			if err := d.Rename(ctx, files["small.txt"], "renamed.txt"); err != nil {
+				t.Fatalf("failed to rename: %+v", err)
+			}
+			if err := d.Remove(ctx, list(t, d, "/bucket")["dir"]); err != nil {
+				t.Fatalf("failed to remove: %+v", err)
+			}
+			if files := list(t, d, "/bucket"); len(files) != 0 {
+				t.Fatalf("expected empty container, got %+v", files)
+			}
+		})
+	}
+}
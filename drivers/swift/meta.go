@@ -0,0 +1,36 @@
+package swift
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/op"
+)
+
+type Addition struct {
+	driver.RootPath
+	AuthURL       string `json:"auth_url" required:"true" help:"e.g. https://keystone.example.com/v3"`
+	AuthVersion   string `json:"auth_version" type:"select" options:"auto,1,2,3" default:"auto"`
+	UserName      string `json:"user_name" required:"true"`
+	ApiKey        string `json:"api_key" required:"true" help:"the password or the api key"`
+	Domain        string `json:"domain" help:"the user domain, v3 only"`
+	Tenant        string `json:"tenant" help:"the tenant or the project, v2 and v3 only"`
+	TenantDomain  string `json:"tenant_domain" help:"the project domain, v3 only"`
+	Region        string `json:"region"`
+	EndpointType  string `json:"endpoint_type" type:"select" options:"public,internal,admin" default:"public"`
+	TempURLKey    string `json:"temp_url_key" help:"the temp url key of the account, direct links are generated with it"`
+	TempURLExpire int    `json:"temp_url_expire" type:"number" default:"4" help:"the expiration of the temp urls in hours"`
+	ChunkSize     int64  `json:"chunk_size" type:"number" default:"1024" help:"files larger than it (in MB) are uploaded as large objects"`
+	LargeObject   string `json:"large_object" type:"select" options:"slo,dlo" default:"slo"`
+}
+
+var config = driver.Config{
+	Name:        "Swift",
+	DefaultRoot: "/",
+	LocalSort:   true,
+	CheckStatus: true,
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Swift{}
+	})
+}
@@ -0,0 +1,296 @@
+package swift
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/ncw/swift/v2"
+)
+
+// directoryType is the content type of the directory markers
+const directoryType = "application/directory"
+
+// splitPath splits the path into the container and the object name
+func splitPath(path string) (string, string) {
+	container, object, _ := strings.Cut(strings.TrimPrefix(utils.FixAndCleanPath(path), "/"), "/")
+	return container, object
+}
+
+func dirPrefix(object string) string {
+	if object == "" {
+		return ""
+	}
+	return object + "/"
+}
+
+func (d *Swift) newConnection() *swift.Connection {
+	conn := &swift.Connection{
+		UserName:     d.UserName,
+		ApiKey:       d.ApiKey,
+		AuthUrl:      d.AuthURL,
+		Domain:       d.Domain,
+		Tenant:       d.Tenant,
+		TenantDomain: d.TenantDomain,
+		Region:       d.Region,
+		EndpointType: swift.EndpointType(d.EndpointType),
+	}
+	switch d.AuthVersion {
+	case "1":
+		conn.AuthVersion = 1
+	case "2":
+		conn.AuthVersion = 2
+	case "3":
+		conn.AuthVersion = 3
+	}
+	return conn
+}
+
+func (d *Swift) listContainers(ctx context.Context) ([]model.Obj, error) {
+	containers, err := d.conn.ContainersAll(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return utils.SliceConvert(containers, func(src swift.Container) (model.Obj, error) {
+		return &model.Object{
+			Path:     "/" + src.Name,
+			Name:     src.Name,
+			Size:     src.Bytes,
+			IsFolder: true,
+		}, nil
+	})
+}
+
+// listDir lists a pseudo directory, the names are split by the delimiter
+func (d *Swift) listDir(ctx context.Context, path string) ([]model.Obj, error) {
+	container, object := splitPath(path)
+	prefix := dirPrefix(object)
+	objects, err := d.conn.ObjectsAll(ctx, container, &swift.ObjectsOpts{
+		Prefix:    prefix,
+		Delimiter: '/',
+	})
+	if err != nil {
+		if errors.Is(err, swift.ContainerNotFound) {
+			return nil, errs.ObjectNotFound
+		}
+		return nil, err
+	}
+	var objs []model.Obj
+	dirs := make(map[string]bool)
+	for _, o := range objects {
+		name := strings.TrimSuffix(strings.TrimPrefix(o.Name, prefix), "/")
+		if name == "" {
+			// the marker of the dir itself
+			continue
+		}
+		if o.PseudoDirectory || o.ContentType == directoryType {
+			if dirs[name] {
+				continue
+			}
+			dirs[name] = true
+			objs = append(objs, &model.Object{
+				Path:     "/" + container + "/" + prefix + name,
+				Name:     name,
+				Modified: o.LastModified,
+				IsFolder: true,
+			})
+			continue
+		}
+		size := o.Bytes
+		hash := o.Hash
+		if o.ObjectType != swift.RegularObjectType {
+			hash = ""
+		} else if size == 0 {
+			// the manifests of the dynamic large objects are listed as empty
+			if info, headers, err := d.conn.Object(ctx, container, o.Name); err == nil && headers.IsLargeObject() {
+				size = info.Bytes
+				hash = ""
+			}
+		}
+		objs = append(objs, &model.Object{
+			Path:     "/" + container + "/" + o.Name,
+			Name:     name,
+			Size:     size,
+			Modified: o.LastModified,
+			HashInfo: utils.NewHashInfo(utils.MD5, hash),
+		})
+	}
+	return objs, nil
+}
+
+// objectsUnder returns all the objects in the dir, including the dir markers
+func (d *Swift) objectsUnder(ctx context.Context, container, object string) ([]swift.Object, error) {
+	return d.conn.ObjectsAll(ctx, container, &swift.ObjectsOpts{Prefix: dirPrefix(object)})
+}
+
+func (d *Swift) removeObject(ctx context.Context, container, object string) error {
+	err := d.conn.LargeObjectDelete(ctx, container, object)
+	if errors.Is(err, swift.ObjectNotFound) {
+		return nil
+	}
+	return err
+}
+
+// largeObjectSegments returns the segments of the object, none if it isn't a
+// large object or doesn't exist
+func (d *Swift) largeObjectSegments(ctx context.Context, container, object string) (string, []swift.Object, error) {
+	segmentContainer, segments, err := d.conn.LargeObjectGetSegments(ctx, container, object)
+	if errors.Is(err, swift.ObjectNotFound) || errors.Is(err, swift.NotLargeObject) {
+		return "", nil, nil
+	}
+	return segmentContainer, segments, err
+}
+
+func (d *Swift) removeSegments(ctx context.Context, segmentContainer string, segments []swift.Object) error {
+	for _, o := range segments {
+		err := d.conn.ObjectDelete(ctx, segmentContainer, o.Name)
+		if err != nil && !errors.Is(err, swift.ObjectNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Swift) removeDir(ctx context.Context, container, object string) error {
+	objects, err := d.objectsUnder(ctx, container, object)
+	if err != nil {
+		return err
+	}
+	for _, o := range objects {
+		if err := d.removeObject(ctx, container, o.Name); err != nil {
+			return err
+		}
+	}
+	if object == "" {
+		return d.conn.ContainerDelete(ctx, container)
+	}
+	// the marker created by other clients may have no trailing slash
+	return d.removeObject(ctx, container, object)
+}
+
+func (d *Swift) copyDir(ctx context.Context, srcContainer, srcObject, dstContainer, dstObject string) error {
+	objects, err := d.objectsUnder(ctx, srcContainer, srcObject)
+	if err != nil {
+		return err
+	}
+	if dstObject == "" {
+		if err := d.conn.ContainerCreate(ctx, dstContainer, nil); err != nil {
+			return err
+		}
+	} else if err := d.makeDir(ctx, dstContainer, dstObject); err != nil {
+		return err
+	}
+	srcPrefix, dstPrefix := dirPrefix(srcObject), dirPrefix(dstObject)
+	for _, o := range objects {
+		name := dstPrefix + strings.TrimPrefix(o.Name, srcPrefix)
+		if _, err := d.conn.ObjectCopy(ctx, srcContainer, o.Name, dstContainer, name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Swift) makeDir(ctx context.Context, container, object string) error {
+	return d.conn.ObjectPutString(ctx, container, dirPrefix(object), "", directoryType)
+}
+
+// tempURL signs the url of the object with the temp url key
+func (d *Swift) tempURL(container, object string) (string, error) {
+	storageURL, err := url.Parse(d.conn.StorageUrl)
+	if err != nil {
+		return "", err
+	}
+	expires := time.Now().Add(time.Hour * time.Duration(d.TempURLExpire)).Unix()
+	path := storageURL.Path + "/" + container + "/" + object
+	mac := hmac.New(sha1.New, []byte(d.TempURLKey))
+	mac.Write([]byte(fmt.Sprintf("GET\n%d\n%s", expires, path)))
+	storageURL.Path = path
+	storageURL.RawPath = ""
+	storageURL.RawQuery = url.Values{
+		"temp_url_sig":     []string{hex.EncodeToString(mac.Sum(nil))},
+		"temp_url_expires": []string{fmt.Sprint(expires)},
+	}.Encode()
+	return storageURL.String(), nil
+}
+
+type sloSegment struct {
+	Path      string `json:"path"`
+	Etag      string `json:"etag"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+// putLargeObject uploads the segments to the segments container one by one,
+// then creates the static or dynamic manifest of them
+func (d *Swift) putLargeObject(ctx context.Context, container, object string, reader io.Reader, size, chunkSize int64, contentType string) error {
+	segmentContainer := container + "_segments"
+	if err := d.conn.ContainerCreate(ctx, segmentContainer, nil); err != nil {
+		return err
+	}
+	prefix := fmt.Sprintf("%s/%s/%d/%d", object, d.LargeObject, time.Now().UnixNano(), size)
+	var segments []sloSegment
+	var uploaded []string
+	cleanup := func() {
+		for _, name := range uploaded {
+			_ = d.conn.ObjectDelete(context.Background(), segmentContainer, name)
+		}
+	}
+	for offset := int64(0); offset < size; offset += chunkSize {
+		n := min(chunkSize, size-offset)
+		name := fmt.Sprintf("%s/%08d", prefix, len(segments))
+		headers, err := d.conn.ObjectPut(ctx, segmentContainer, name, io.LimitReader(reader, n), false, "", "application/octet-stream", nil)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		uploaded = append(uploaded, name)
+		segments = append(segments, sloSegment{
+			Path:      segmentContainer + "/" + name,
+			Etag:      strings.Trim(headers["Etag"], `"`),
+			SizeBytes: n,
+		})
+	}
+	var err error
+	if d.LargeObject == "dlo" {
+		manifest := url.PathEscape(segmentContainer) + "/" + escapePath(prefix) + "/"
+		_, err = d.conn.ObjectPut(ctx, container, object, strings.NewReader(""), false, "", contentType,
+			swift.Headers{"X-Object-Manifest": manifest})
+	} else {
+		var body []byte
+		body, err = utils.Json.Marshal(segments)
+		if err == nil {
+			_, _, err = d.conn.Call(ctx, d.conn.StorageUrl, swift.RequestOpts{
+				Container:  container,
+				ObjectName: object,
+				Operation:  http.MethodPut,
+				Parameters: url.Values{"multipart-manifest": []string{"put"}},
+				Headers:    swift.Headers{"Content-Type": contentType},
+				Body:       bytes.NewReader(body),
+				NoResponse: true,
+			})
+		}
+	}
+	if err != nil {
+		cleanup()
+	}
+	return err
+}
+
+func escapePath(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		segs[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segs, "/")
+}
@@ -0,0 +1,236 @@
+package union
+
+import (
+	"context"
+	stderrors "errors"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/fs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+type Union struct {
+	model.Storage
+	Addition
+	branches []string
+}
+
+func (d *Union) Config() driver.Config {
+	return config
+}
+
+func (d *Union) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Union) Init(ctx context.Context) error {
+	d.branches = nil
+	for _, path := range strings.Split(d.Paths, "\n") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		path = utils.FixAndCleanPath(path)
+		if utils.IsSubPath(d.MountPath, path) {
+			return errors.Errorf("branch %s is inside the union itself", path)
+		}
+		d.branches = append(d.branches, path)
+	}
+	if len(d.branches) == 0 {
+		return errors.New("paths is required")
+	}
+	return nil
+}
+
+func (d *Union) Drop(ctx context.Context) error {
+	d.branches = nil
+	return nil
+}
+
+func (d *Union) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			Name:     "Root",
+			IsFolder: true,
+			Path:     "/",
+		}, nil
+	}
+	_, obj, err := d.search(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Object{
+		Path:     path,
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		IsFolder: obj.IsDir(),
+		HashInfo: obj.GetHash(),
+	}, nil
+}
+
+func (d *Union) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	path := dir.GetPath()
+	fsArgs := &fs.ListArgs{NoLog: true, Refresh: args.Refresh}
+	var objs []model.Obj
+	index := make(map[string]int)
+	listed := false
+	for _, branch := range d.branches {
+		tmp, err := fs.List(ctx, stdpath.Join(branch, path), fsArgs)
+		if err != nil {
+			continue
+		}
+		listed = true
+		for _, obj := range tmp {
+			if i, ok := index[obj.GetName()]; ok {
+				// dirs are merged, a file is hidden by the one in an earlier branch
+				// unless the newer one is preferred
+				if d.SearchPolicy == PolicyNewest && !obj.IsDir() && !objs[i].IsDir() &&
+					obj.ModTime().After(objs[i].ModTime()) {
+					objs[i] = toObj(path, obj)
+				}
+				continue
+			}
+			index[obj.GetName()] = len(objs)
+			objs = append(objs, toObj(path, obj))
+		}
+	}
+	if !listed {
+		return nil, errs.ObjectNotFound
+	}
+	return objs, nil
+}
+
+func (d *Union) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	branch, _, err := d.search(ctx, file.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	return d.link(ctx, stdpath.Join(branch, file.GetPath()), args)
+}
+
+func (d *Union) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	if d.ReadOnly {
+		return errs.PermissionDenied
+	}
+	branch, err := d.createBranch(ctx, parentDir.GetPath())
+	if err != nil {
+		return err
+	}
+	return fs.MakeDir(ctx, stdpath.Join(branch, parentDir.GetPath(), dirName))
+}
+
+func (d *Union) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	if d.ReadOnly {
+		return errs.PermissionDenied
+	}
+	return d.action(ctx, srcObj.GetPath(), func(branch string) error {
+		dst := stdpath.Join(branch, dstDir.GetPath())
+		if err := fs.MakeDir(ctx, dst); err != nil {
+			return err
+		}
+		return fs.Move(ctx, stdpath.Join(branch, srcObj.GetPath()), dst)
+	})
+}
+
+func (d *Union) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	if d.ReadOnly {
+		return errs.PermissionDenied
+	}
+	return d.action(ctx, srcObj.GetPath(), func(branch string) error {
+		return fs.Rename(ctx, stdpath.Join(branch, srcObj.GetPath()), newName)
+	})
+}
+
+// Copy copies the file inside the branch it is read from
+func (d *Union) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	if d.ReadOnly {
+		return errs.PermissionDenied
+	}
+	branch, _, err := d.search(ctx, srcObj.GetPath())
+	if err != nil {
+		return err
+	}
+	dst := stdpath.Join(branch, dstDir.GetPath())
+	if err := fs.MakeDir(ctx, dst); err != nil {
+		return err
+	}
+	_, err = fs.Copy(ctx, stdpath.Join(branch, srcObj.GetPath()), dst)
+	return err
+}
+
+func (d *Union) Remove(ctx context.Context, obj model.Obj) error {
+	if d.ReadOnly {
+		return errs.PermissionDenied
+	}
+	return d.action(ctx, obj.GetPath(), func(branch string) error {
+		return fs.Remove(ctx, stdpath.Join(branch, obj.GetPath()))
+	})
+}
+
+// Put overwrites the file in place if it exists, otherwise it is created
+// in the branch chosen by the create policy
+func (d *Union) Put(ctx context.Context, dstDir model.Obj, s model.FileStreamer, up driver.UpdateProgress) error {
+	if d.ReadOnly {
+		return errs.PermissionDenied
+	}
+	var branch string
+	if existing := d.branchesWith(ctx, stdpath.Join(dstDir.GetPath(), s.GetName())); len(existing) > 0 {
+		branch = existing[0]
+	} else {
+		var err error
+		branch, err = d.createBranch(ctx, dstDir.GetPath())
+		if err != nil {
+			return err
+		}
+	}
+	dst := stdpath.Join(branch, dstDir.GetPath())
+	if err := fs.MakeDir(ctx, dst); err != nil {
+		return err
+	}
+	return fs.PutDirectly(ctx, dst, s)
+}
+
+// GetDetails sums the capacity of the branches that report it
+func (d *Union) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	var total model.StorageDetails
+	found := false
+	for _, branch := range d.branches {
+		details, err := branchDetails(ctx, branch)
+		if err != nil {
+			continue
+		}
+		found = true
+		total.TotalSpace += details.TotalSpace
+		total.FreeSpace += details.FreeSpace
+	}
+	if !found {
+		return nil, errs.NotImplement
+	}
+	return &total, nil
+}
+
+// action applies f to the branches chosen by the action policy
+func (d *Union) action(ctx context.Context, path string, f func(branch string) error) error {
+	branches := d.branchesWith(ctx, path)
+	if len(branches) == 0 {
+		return errs.ObjectNotFound
+	}
+	if d.ActionPolicy == PolicyFirstFound {
+		branches = branches[:1]
+	}
+	var errList []error
+	for _, branch := range branches {
+		if err := f(branch); err != nil {
+			errList = append(errList, errors.WithMessagef(err, "branch %s", branch))
+		}
+	}
+	return stderrors.Join(errList...)
+}
+
+var _ driver.Driver = (*Union)(nil)
@@ -0,0 +1,280 @@
+package union_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/drivers/local"
+	_ "github.com/OpenListTeam/OpenList/drivers/union"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+)
+
+// capacities are the details reported by the SizedLocal storages by mount path
+var capacities = map[string]*model.StorageDetails{}
+
+// sizedLocal is a local storage reporting the capacity set in capacities
+type sizedLocal struct {
+	local.Local
+}
+
+func (d *sizedLocal) Config() driver.Config {
+	config := d.Local.Config()
+	config.Name = "SizedLocal"
+	return config
+}
+
+func (d *sizedLocal) GetDetails(ctx context.Context) (*model.StorageDetails, error) {
+	return capacities[d.MountPath], nil
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &sizedLocal{}
+	})
+}
+
+// newUnion mounts a SizedLocal storage of a temporary dir for each branch and
+// a union storage of them with the addition on /union, the dirs are returned
+// in the order of the branches
+func newUnion(t *testing.T, addition string, branches ...string) (driver.Driver, []string) {
+	db.Init(dbtest.Open(t))
+	ctx := context.Background()
+	var roots []string
+	for _, branch := range branches {
+		root := t.TempDir()
+		id, err := op.CreateStorage(ctx, model.Storage{
+			Driver:    "SizedLocal",
+			MountPath: branch,
+			Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+		})
+		if err != nil {
+			t.Fatalf("failed to create the storage of %s: %+v", branch, err)
+		}
+		t.Cleanup(func() { _ = op.DeleteStorageById(ctx, id) })
+		roots = append(roots, root)
+	}
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Union",
+		MountPath: "/union",
+		Addition:  fmt.Sprintf(`{"paths":%q,%s}`, strings.Join(branches, "\n"), addition),
+	})
+	if err != nil {
+		t.Fatalf("failed to create union storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, id) })
+	d, err := op.GetStorageByMountPath("/union")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, roots
+}
+
+func writeFile(t *testing.T, path, data string, modified time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func listNames(t *testing.T, d driver.Driver, dir string) string {
+	t.Helper()
+	objs, err := op.List(context.Background(), d, dir, model.ListArgs{})
+	if err != nil {
+		t.Fatalf("failed to list %s: %+v", dir, err)
+	}
+	var names []string
+	for _, obj := range objs {
+		names = append(names, obj.GetName())
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func readFile(t *testing.T, d driver.Driver, path string) string {
+	t.Helper()
+	ctx := context.Background()
+	link, _, err := op.Link(ctx, d, path, model.LinkArgs{})
+	if err != nil {
+		t.Fatalf("failed to link %s: %+v", path, err)
+	}
+	if link.MFile == nil {
+		t.Fatalf("expected the local file of %s", path)
+	}
+	defer link.MFile.Close()
+	data, err := io.ReadAll(link.MFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func put(t *testing.T, d driver.Driver, dir, name, data string) {
+	t.Helper()
+	err := op.Put(context.Background(), d, dir, &stream.FileStream{
+		Obj:    &model.Object{Name: name, Size: int64(len(data)), Modified: time.Now()},
+		Reader: io.NopCloser(bytes.NewReader([]byte(data))),
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to put %s: %+v", name, err)
+	}
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func TestMergedList(t *testing.T) {
+	d, roots := newUnion(t, `"search_policy":"first_found"`, "/b1", "/b2")
+	old, now := time.Now().Add(-time.Hour), time.Now()
+	writeFile(t, filepath.Join(roots[0], "dir", "a.txt"), "a", now)
+	writeFile(t, filepath.Join(roots[1], "dir", "b.txt"), "b", now)
+	writeFile(t, filepath.Join(roots[0], "same.txt"), "first", old)
+	writeFile(t, filepath.Join(roots[1], "same.txt"), "second", now)
+	writeFile(t, filepath.Join(roots[1], "only.txt"), "only", now)
+
+	if got := listNames(t, d, "/"); got != "dir,only.txt,same.txt" {
+		t.Errorf("expected the branches merged, got %s", got)
+	}
+	if got := listNames(t, d, "/dir"); got != "a.txt,b.txt" {
+		t.Errorf("expected the dirs merged, got %s", got)
+	}
+	if got := readFile(t, d, "/same.txt"); got != "first" {
+		t.Errorf("expected the file of the first branch read, got %q", got)
+	}
+	if got := readFile(t, d, "/only.txt"); got != "only" {
+		t.Errorf("expected the file of the second branch read, got %q", got)
+	}
+}
+
+func TestSearchNewest(t *testing.T) {
+	d, roots := newUnion(t, `"search_policy":"newest"`, "/b1", "/b2")
+	old, now := time.Now().Add(-time.Hour), time.Now()
+	writeFile(t, filepath.Join(roots[0], "same.txt"), "first", old)
+	writeFile(t, filepath.Join(roots[1], "same.txt"), "second", now)
+
+	obj, err := op.Get(context.Background(), d, "/same.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.GetSize() != int64(len("second")) {
+		t.Errorf("expected the newer file listed, got size %d", obj.GetSize())
+	}
+	if got := readFile(t, d, "/same.txt"); got != "second" {
+		t.Errorf("expected the newer file read, got %q", got)
+	}
+}
+
+func TestCreatePolicy(t *testing.T) {
+	capacities["/b1"] = &model.StorageDetails{TotalSpace: 100, FreeSpace: 10}
+	capacities["/b2"] = &model.StorageDetails{TotalSpace: 1000, FreeSpace: 500}
+	t.Cleanup(func() { delete(capacities, "/b1"); delete(capacities, "/b2") })
+	for _, c := range []struct {
+		policy string
+		branch int
+	}{
+		{"first_found", 0},
+		{"most_free_space", 1},
+		{"least_used", 0},
+	} {
+		t.Run(c.policy, func(t *testing.T) {
+			d, roots := newUnion(t, fmt.Sprintf(`"create_policy":%q`, c.policy), "/b1", "/b2")
+			put(t, d, "/", "new.txt", "new")
+			if !exists(filepath.Join(roots[c.branch], "new.txt")) {
+				t.Errorf("expected new.txt created in branch %d", c.branch)
+			}
+			if exists(filepath.Join(roots[1-c.branch], "new.txt")) {
+				t.Errorf("expected new.txt not created in branch %d", 1-c.branch)
+			}
+		})
+	}
+}
+
+func TestPathPreserving(t *testing.T) {
+	d, roots := newUnion(t, `"path_preserving":true`, "/b1", "/b2")
+	if err := os.Mkdir(filepath.Join(roots[1], "dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	put(t, d, "/dir", "new.txt", "new")
+	if !exists(filepath.Join(roots[1], "dir", "new.txt")) || exists(filepath.Join(roots[0], "dir")) {
+		t.Errorf("expected new.txt created in the branch holding the dir")
+	}
+	parent := &model.Object{Path: "/missing", IsFolder: true}
+	if err := d.(driver.Mkdir).MakeDir(context.Background(), parent, "sub"); err == nil {
+		t.Errorf("expected no branch to create in without the parent dir")
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	d, roots := newUnion(t, `"action_policy":"all"`, "/b1", "/b2")
+	ctx := context.Background()
+	writeFile(t, filepath.Join(roots[1], "same.txt"), "second", time.Now())
+	// a put overwrites the existing file in place instead of shadowing it
+	put(t, d, "/", "same.txt", "updated")
+	if exists(filepath.Join(roots[0], "same.txt")) {
+		t.Errorf("expected the existing file overwritten in its own branch")
+	}
+	if got := readFile(t, d, "/same.txt"); got != "updated" {
+		t.Errorf("expected the overwritten file read, got %q", got)
+	}
+
+	writeFile(t, filepath.Join(roots[0], "same.txt"), "first", time.Now())
+	if err := op.Rename(ctx, d, "/same.txt", "renamed.txt"); err != nil {
+		t.Fatal(err)
+	}
+	for i, root := range roots {
+		if !exists(filepath.Join(root, "renamed.txt")) || exists(filepath.Join(root, "same.txt")) {
+			t.Errorf("expected the file renamed in branch %d", i)
+		}
+	}
+	if err := op.Remove(ctx, d, "/renamed.txt"); err != nil {
+		t.Fatal(err)
+	}
+	for i, root := range roots {
+		if exists(filepath.Join(root, "renamed.txt")) {
+			t.Errorf("expected the file removed from branch %d", i)
+		}
+	}
+}
+
+func TestActionFirstFound(t *testing.T) {
+	d, roots := newUnion(t, `"action_policy":"first_found"`, "/b1", "/b2")
+	writeFile(t, filepath.Join(roots[0], "same.txt"), "first", time.Now())
+	writeFile(t, filepath.Join(roots[1], "same.txt"), "second", time.Now())
+	if err := op.Remove(context.Background(), d, "/same.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if exists(filepath.Join(roots[0], "same.txt")) || !exists(filepath.Join(roots[1], "same.txt")) {
+		t.Errorf("expected the file removed from the first branch only")
+	}
+	if got := readFile(t, d, "/same.txt"); got != "second" {
+		t.Errorf("expected the file of the second branch revealed, got %q", got)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	d, _ := newUnion(t, `"read_only":true`, "/b1")
+	err := op.MakeDir(context.Background(), d, "/dir")
+	if err == nil {
+		t.Fatal("expected a read only union to refuse writes")
+	}
+}
@@ -0,0 +1,37 @@
+package union
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/op"
+)
+
+const (
+	PolicyFirstFound    = "first_found"
+	PolicyMostFreeSpace = "most_free_space"
+	PolicyLeastUsed     = "least_used"
+	PolicyNewest        = "newest"
+	PolicyAll           = "all"
+)
+
+type Addition struct {
+	Paths          string `json:"paths" required:"true" type:"text" help:"One OpenList path per line, the earlier ones take precedence"`
+	CreatePolicy   string `json:"create_policy" type:"select" options:"first_found,most_free_space,least_used" default:"first_found" help:"Which branch new files and dirs are created in, most_free_space and least_used only consider the storages that report their capacity"`
+	PathPreserving bool   `json:"path_preserving" type:"bool" default:"false" help:"Only create in the branches where the parent dir already exists"`
+	SearchPolicy   string `json:"search_policy" type:"select" options:"first_found,newest" default:"first_found" help:"Which branch is read when a file exists in several branches"`
+	ActionPolicy   string `json:"action_policy" type:"select" options:"all,first_found" default:"all" help:"Which branches rename, move and remove are applied to"`
+	ReadOnly       bool   `json:"read_only" type:"bool" default:"false"`
+}
+
+var config = driver.Config{
+	Name:             "Union",
+	LocalSort:        true,
+	NoCache:          true,
+	DefaultRoot:      "/",
+	ProxyRangeOption: true,
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Union{}
+	})
+}
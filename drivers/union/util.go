@@ -0,0 +1,141 @@
+package union
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/fs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/sign"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/OpenListTeam/OpenList/server/common"
+)
+
+func toObj(dir string, obj model.Obj) model.Obj {
+	objRes := model.Object{
+		Path:     stdpath.Join(dir, obj.GetName()),
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		IsFolder: obj.IsDir(),
+		HashInfo: obj.GetHash(),
+	}
+	thumb, ok := model.GetThumb(obj)
+	if !ok {
+		return &objRes
+	}
+	return &model.ObjThumb{
+		Object: objRes,
+		Thumbnail: model.Thumbnail{
+			Thumbnail: thumb,
+		},
+	}
+}
+
+// search finds the branch to read the path from by the search policy
+func (d *Union) search(ctx context.Context, path string) (string, model.Obj, error) {
+	var branch string
+	var found model.Obj
+	for _, b := range d.branches {
+		obj, err := fs.Get(ctx, stdpath.Join(b, path), &fs.GetArgs{NoLog: true})
+		if err != nil {
+			continue
+		}
+		if d.SearchPolicy != PolicyNewest || obj.IsDir() {
+			return b, obj, nil
+		}
+		if found == nil || obj.ModTime().After(found.ModTime()) {
+			branch, found = b, obj
+		}
+	}
+	if found == nil {
+		return "", nil, errs.ObjectNotFound
+	}
+	return branch, found, nil
+}
+
+// branchesWith returns the branches where the path exists, in order
+func (d *Union) branchesWith(ctx context.Context, path string) []string {
+	var res []string
+	for _, b := range d.branches {
+		if _, err := fs.Get(ctx, stdpath.Join(b, path), &fs.GetArgs{NoLog: true}); err == nil {
+			res = append(res, b)
+		}
+	}
+	return res
+}
+
+// createBranch chooses the branch to create a new object under the parent
+// dir by the create policy
+func (d *Union) createBranch(ctx context.Context, parent string) (string, error) {
+	candidates := d.branches
+	if d.PathPreserving {
+		candidates = d.branchesWith(ctx, parent)
+		if len(candidates) == 0 {
+			return "", errs.ObjectNotFound
+		}
+	}
+	if d.CreatePolicy != PolicyMostFreeSpace && d.CreatePolicy != PolicyLeastUsed {
+		return candidates[0], nil
+	}
+	best, bestValue := "", int64(0)
+	for _, b := range candidates {
+		details, err := branchDetails(ctx, b)
+		if err != nil {
+			continue
+		}
+		if d.CreatePolicy == PolicyMostFreeSpace {
+			if best == "" || details.FreeSpace > bestValue {
+				best, bestValue = b, details.FreeSpace
+			}
+		} else if best == "" || details.UsedSpace() < bestValue {
+			best, bestValue = b, details.UsedSpace()
+		}
+	}
+	if best == "" {
+		// none of the branches reports the capacity
+		return candidates[0], nil
+	}
+	return best, nil
+}
+
+func branchDetails(ctx context.Context, branch string) (*model.StorageDetails, error) {
+	storage, _, err := op.GetStorageAndActualPath(branch)
+	if err != nil {
+		return nil, err
+	}
+	wd, ok := storage.(driver.WithDetails)
+	if !ok {
+		return nil, errs.NotImplement
+	}
+	return wd.GetDetails(ctx)
+}
+
+func (d *Union) link(ctx context.Context, reqPath string, args model.LinkArgs) (*model.Link, error) {
+	storage, reqActualPath, err := op.GetStorageAndActualPath(reqPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := storage.(*Union); !ok && !args.Redirect {
+		link, _, err := op.Link(ctx, storage, reqActualPath, args)
+		return link, err
+	}
+	if common.ShouldProxy(storage, stdpath.Base(reqPath)) {
+		link := &model.Link{
+			URL: fmt.Sprintf("%s/p%s?sign=%s",
+				common.GetApiUrl(args.HttpReq),
+				utils.EncodePath(reqPath, true),
+				sign.Sign(reqPath)),
+		}
+		if args.HttpReq != nil && d.ProxyRange {
+			link.RangeReadCloser = common.NoProxyRange
+		}
+		return link, nil
+	}
+	link, _, err := op.Link(ctx, storage, reqActualPath, args)
+	return link, err
+}
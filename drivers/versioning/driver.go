@@ -0,0 +1,284 @@
+package versioning
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/fs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+type Versioning struct {
+	model.Storage
+	Addition
+	remoteStorage driver.Driver
+}
+
+func (d *Versioning) Config() driver.Config {
+	return config
+}
+
+func (d *Versioning) GetAddition() driver.Additional {
+	return &d.Addition
+}
+
+func (d *Versioning) Init(ctx context.Context) error {
+	d.RemotePath = utils.FixAndCleanPath(d.RemotePath)
+	//need remote storage exist
+	storage, err := fs.GetStorage(d.RemotePath, &fs.GetStoragesArgs{})
+	if err != nil {
+		return fmt.Errorf("can't find remote storage: %w", err)
+	}
+	d.remoteStorage = storage
+	return nil
+}
+
+func (d *Versioning) Drop(ctx context.Context) error {
+	return nil
+}
+
+// actual path is used for internal only
+func (d *Versioning) getActualPath(path string) (string, error) {
+	_, remoteActualPath, err := op.GetStorageAndActualPath(stdpath.Join(d.RemotePath, path))
+	if err != nil {
+		return "", fmt.Errorf("failed to convert path to remote path: %w", err)
+	}
+	return remoteActualPath, nil
+}
+
+func (d *Versioning) List(ctx context.Context, dir model.Obj, args model.ListArgs) ([]model.Obj, error) {
+	remoteDir, err := d.getActualPath(dir.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	objs, err := op.List(ctx, d.remoteStorage, remoteDir, model.ListArgs{})
+	if err != nil {
+		return nil, err
+	}
+	var result []model.Obj
+	for _, obj := range objs {
+		if obj.GetName() == versionsDir && obj.IsDir() {
+			continue
+		}
+		result = append(result, toObj(stdpath.Join(dir.GetPath(), obj.GetName()), obj))
+	}
+	return result, nil
+}
+
+func (d *Versioning) Get(ctx context.Context, path string) (model.Obj, error) {
+	if utils.PathEqual(path, "/") {
+		return &model.Object{
+			Name:     "Root",
+			IsFolder: true,
+			Path:     "/",
+		}, nil
+	}
+	remotePath, err := d.getActualPath(path)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := op.Get(ctx, d.remoteStorage, remotePath)
+	if err != nil {
+		return nil, err
+	}
+	return toObj(path, obj), nil
+}
+
+func (d *Versioning) Link(ctx context.Context, file model.Obj, args model.LinkArgs) (*model.Link, error) {
+	remotePath, err := d.getActualPath(file.GetPath())
+	if err != nil {
+		return nil, err
+	}
+	link, _, err := op.Link(ctx, d.remoteStorage, remotePath, args)
+	return link, err
+}
+
+func (d *Versioning) MakeDir(ctx context.Context, parentDir model.Obj, dirName string) error {
+	if dirName == versionsDir {
+		return fmt.Errorf("the name [%s] is reserved for versions", dirName)
+	}
+	remoteDir, err := d.getActualPath(parentDir.GetPath())
+	if err != nil {
+		return err
+	}
+	return op.MakeDir(ctx, d.remoteStorage, stdpath.Join(remoteDir, dirName))
+}
+
+func (d *Versioning) Move(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcDir, name := stdpath.Split(srcObj.GetPath())
+	srcRemoteDir, err := d.getActualPath(srcDir)
+	if err != nil {
+		return err
+	}
+	dstRemoteDir, err := d.getActualPath(dstDir.GetPath())
+	if err != nil {
+		return err
+	}
+	err = op.Move(ctx, d.remoteStorage, stdpath.Join(srcRemoteDir, name), dstRemoteDir)
+	if err != nil || srcObj.IsDir() {
+		return err
+	}
+	// the versions go with the file
+	vDir := d.versionDir(srcRemoteDir, name)
+	if !d.exists(ctx, vDir) {
+		return nil
+	}
+	dstVersionsDir := stdpath.Join(dstRemoteDir, versionsDir)
+	if err := op.MakeDir(ctx, d.remoteStorage, dstVersionsDir); err != nil {
+		log.Warnf("failed to move versions of [%s]: %+v", srcObj.GetPath(), err)
+		return nil
+	}
+	if err := op.Move(ctx, d.remoteStorage, vDir, dstVersionsDir); err != nil {
+		log.Warnf("failed to move versions of [%s]: %+v", srcObj.GetPath(), err)
+	}
+	return nil
+}
+
+func (d *Versioning) Rename(ctx context.Context, srcObj model.Obj, newName string) error {
+	if newName == versionsDir {
+		return fmt.Errorf("the name [%s] is reserved for versions", newName)
+	}
+	srcDir, name := stdpath.Split(srcObj.GetPath())
+	remoteDir, err := d.getActualPath(srcDir)
+	if err != nil {
+		return err
+	}
+	err = op.Rename(ctx, d.remoteStorage, stdpath.Join(remoteDir, name), newName)
+	if err != nil || srcObj.IsDir() {
+		return err
+	}
+	vDir := d.versionDir(remoteDir, name)
+	if !d.exists(ctx, vDir) {
+		return nil
+	}
+	if err := op.Rename(ctx, d.remoteStorage, vDir, newName); err != nil {
+		log.Warnf("failed to rename versions of [%s]: %+v", srcObj.GetPath(), err)
+	}
+	return nil
+}
+
+func (d *Versioning) Copy(ctx context.Context, srcObj, dstDir model.Obj) error {
+	srcRemotePath, err := d.getActualPath(srcObj.GetPath())
+	if err != nil {
+		return err
+	}
+	dstRemoteDir, err := d.getActualPath(dstDir.GetPath())
+	if err != nil {
+		return err
+	}
+	return op.Copy(ctx, d.remoteStorage, srcRemotePath, dstRemoteDir)
+}
+
+func (d *Versioning) Remove(ctx context.Context, obj model.Obj) error {
+	dir, name := stdpath.Split(obj.GetPath())
+	remoteDir, err := d.getActualPath(dir)
+	if err != nil {
+		return err
+	}
+	err = op.Remove(ctx, d.remoteStorage, stdpath.Join(remoteDir, name))
+	if err != nil || obj.IsDir() {
+		return err
+	}
+	vDir := d.versionDir(remoteDir, name)
+	if !d.exists(ctx, vDir) {
+		return nil
+	}
+	if err := op.Remove(ctx, d.remoteStorage, vDir); err != nil {
+		log.Warnf("failed to remove versions of [%s]: %+v", obj.GetPath(), err)
+	}
+	return nil
+}
+
+func (d *Versioning) Put(ctx context.Context, dstDir model.Obj, streamer model.FileStreamer, up driver.UpdateProgress) error {
+	name := streamer.GetName()
+	if name == versionsDir {
+		return fmt.Errorf("the name [%s] is reserved for versions", name)
+	}
+	remoteDir, err := d.getActualPath(dstDir.GetPath())
+	if err != nil {
+		return err
+	}
+	// keep the overwritten file as a version
+	var version string
+	old, err := op.Get(ctx, d.remoteStorage, stdpath.Join(remoteDir, name))
+	if err == nil && !old.IsDir() && old.GetSize() > 0 {
+		version, err = d.saveVersion(ctx, remoteDir, name)
+		if err != nil {
+			return fmt.Errorf("failed to keep the previous version: %w", err)
+		}
+	}
+	err = op.Put(ctx, d.remoteStorage, remoteDir, streamer, up, false)
+	if err != nil {
+		if version != "" {
+			if err := d.takeVersion(ctx, remoteDir, name, version); err != nil {
+				log.Errorf("failed to recover [%s] from version %s: %+v", stdpath.Join(remoteDir, name), version, err)
+			}
+		}
+		return err
+	}
+	if version != "" {
+		d.prune(ctx, remoteDir, name)
+	}
+	return nil
+}
+
+func (d *Versioning) ListVersions(ctx context.Context, file model.Obj) ([]model.Obj, error) {
+	dir, name := stdpath.Split(file.GetPath())
+	remoteDir, err := d.getActualPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	d.prune(ctx, remoteDir, name)
+	versions, err := d.listVersions(ctx, remoteDir, name)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.Obj, 0, len(versions))
+	for _, v := range versions {
+		res = append(res, toObj(stdpath.Join(dir, versionsDir, name, v.GetName()), v))
+	}
+	return res, nil
+}
+
+func (d *Versioning) RestoreVersion(ctx context.Context, file model.Obj, version string) error {
+	dir, name := stdpath.Split(file.GetPath())
+	remoteDir, err := d.getActualPath(dir)
+	if err != nil {
+		return err
+	}
+	versions, err := d.listVersions(ctx, remoteDir, name)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, v := range versions {
+		if v.GetName() == version {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errs.ObjectNotFound
+	}
+	current, err := d.saveVersion(ctx, remoteDir, name)
+	if err != nil {
+		return fmt.Errorf("failed to keep the current version: %w", err)
+	}
+	if err := d.takeVersion(ctx, remoteDir, name, version); err != nil {
+		if err := d.takeVersion(ctx, remoteDir, name, current); err != nil {
+			log.Errorf("failed to recover [%s] from version %s: %+v", stdpath.Join(remoteDir, name), current, err)
+		}
+		return err
+	}
+	d.prune(ctx, remoteDir, name)
+	return nil
+}
+
+var _ driver.Driver = (*Versioning)(nil)
+var _ driver.Versioner = (*Versioning)(nil)
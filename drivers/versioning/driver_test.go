@@ -0,0 +1,160 @@
+package versioning_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/drivers/local"
+	_ "github.com/OpenListTeam/OpenList/drivers/versioning"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+)
+
+// newVersioning mounts a local storage of a temporary dir on /remote<n>
+// and a versioning storage of it on /v<n>
+func newVersioning(t *testing.T, n, maxVersions int) (driver.Driver, string) {
+	db.Init(dbtest.Open(t))
+	root := t.TempDir()
+	ctx := context.Background()
+	remote := fmt.Sprintf("/remote%d", n)
+	remoteID, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: remote,
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed to create local storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, remoteID) })
+	mount := fmt.Sprintf("/v%d", n)
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Versioning",
+		MountPath: mount,
+		Addition:  fmt.Sprintf(`{"remote_path":%q,"max_versions":%d}`, remote, maxVersions),
+	})
+	if err != nil {
+		t.Fatalf("failed to create versioning storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, id) })
+	d, err := op.GetStorageByMountPath(mount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d, root
+}
+
+func put(t *testing.T, d driver.Driver, name, data string) {
+	t.Helper()
+	// the versions are named by the time in milliseconds
+	time.Sleep(2 * time.Millisecond)
+	err := op.Put(context.Background(), d, "/", &stream.FileStream{
+		Obj:    &model.Object{Name: name, Size: int64(len(data)), Modified: time.Now()},
+		Reader: io.NopCloser(bytes.NewReader([]byte(data))),
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to put %s: %+v", name, err)
+	}
+}
+
+func versions(t *testing.T, d driver.Driver, name string) []model.Obj {
+	t.Helper()
+	file, err := op.Get(context.Background(), d, "/"+name)
+	if err != nil {
+		t.Fatalf("failed to get %s: %+v", name, err)
+	}
+	res, err := d.(driver.Versioner).ListVersions(context.Background(), file)
+	if err != nil {
+		t.Fatalf("failed to list versions of %s: %+v", name, err)
+	}
+	return res
+}
+
+func content(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestVersioning(t *testing.T) {
+	d, root := newVersioning(t, 1, 10)
+	put(t, d, "a.txt", "v1")
+	if vs := versions(t, d, "a.txt"); len(vs) != 0 {
+		t.Fatalf("expected no versions of a new file, got %d", len(vs))
+	}
+	put(t, d, "a.txt", "v2")
+	put(t, d, "a.txt", "v3")
+	vs := versions(t, d, "a.txt")
+	if len(vs) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(vs))
+	}
+	// newest first
+	oldest := vs[1].GetName()
+	if got := content(t, filepath.Join(root, ".versions", "a.txt", oldest)); got != "v1" {
+		t.Fatalf("expected v1 as the oldest version, got %q", got)
+	}
+	objs, err := op.List(context.Background(), d, "/", model.ListArgs{Refresh: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 1 || objs[0].GetName() != "a.txt" {
+		t.Fatalf("expected the versions dir hidden, got %v", objs)
+	}
+
+	file, err := op.Get(context.Background(), d, "/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if err := d.(driver.Versioner).RestoreVersion(context.Background(), file, oldest); err != nil {
+		t.Fatalf("failed to restore: %+v", err)
+	}
+	if got := content(t, filepath.Join(root, "a.txt")); got != "v1" {
+		t.Fatalf("expected v1 restored, got %q", got)
+	}
+	// the replaced content is kept as a version
+	if vs := versions(t, d, "a.txt"); len(vs) != 2 {
+		t.Fatalf("expected 2 versions after restore, got %d", len(vs))
+	}
+	if err := d.(driver.Versioner).RestoreVersion(context.Background(), file, "missing"); err == nil {
+		t.Fatal("expected an error restoring a missing version")
+	}
+}
+
+func TestVersioningPrune(t *testing.T) {
+	d, root := newVersioning(t, 2, 2)
+	for i := 1; i <= 5; i++ {
+		put(t, d, "b.txt", fmt.Sprintf("v%d", i))
+	}
+	vs := versions(t, d, "b.txt")
+	if len(vs) != 2 {
+		t.Fatalf("expected 2 versions kept, got %d", len(vs))
+	}
+	if got := content(t, filepath.Join(root, ".versions", "b.txt", vs[1].GetName())); got != "v3" {
+		t.Fatalf("expected v3 as the oldest kept version, got %q", got)
+	}
+}
+
+func TestVersioningRemove(t *testing.T) {
+	d, root := newVersioning(t, 3, 10)
+	put(t, d, "c.txt", "v1")
+	put(t, d, "c.txt", "v2")
+	if err := op.Remove(context.Background(), d, "/c.txt"); err != nil {
+		t.Fatalf("failed to remove: %+v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".versions", "c.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected the versions removed with the file, got %v", err)
+	}
+}
@@ -0,0 +1,25 @@
+package versioning
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/op"
+)
+
+type Addition struct {
+	RemotePath  string `json:"remote_path" required:"true" help:"This is where the files and their versions stores"`
+	MaxVersions int    `json:"max_versions" type:"number" default:"10" help:"the number of previous versions kept for each file, 0 means unlimited"`
+	MaxAge      int    `json:"max_age" type:"number" default:"0" help:"versions replaced more than these days ago are removed, 0 means unlimited"`
+}
+
+var config = driver.Config{
+	Name:        "Versioning",
+	LocalSort:   true,
+	NoCache:     true,
+	DefaultRoot: "/",
+}
+
+func init() {
+	op.RegisterDriver(func() driver.Driver {
+		return &Versioning{}
+	})
+}
@@ -0,0 +1,143 @@
+package versioning
+
+import (
+	"context"
+	stdpath "path"
+	"sort"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	log "github.com/sirupsen/logrus"
+)
+
+// the previous versions of dir/name are kept as dir/.versions/name/<time><ext>,
+// the extension is kept so that the versions can be previewed
+const (
+	versionsDir   = ".versions"
+	versionLayout = "20060102T150405.000Z"
+)
+
+func versionName(t time.Time, name string) string {
+	return t.UTC().Format(versionLayout) + stdpath.Ext(name)
+}
+
+// versionTime returns the time when the version was replaced
+func versionTime(version string) (time.Time, bool) {
+	if len(version) < len(versionLayout) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(versionLayout, version[:len(versionLayout)])
+	return t, err == nil
+}
+
+func toObj(path string, obj model.Obj) model.Obj {
+	objRes := model.Object{
+		Path:     path,
+		Name:     obj.GetName(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime(),
+		Ctime:    obj.CreateTime(),
+		IsFolder: obj.IsDir(),
+		HashInfo: obj.GetHash(),
+	}
+	thumb, ok := model.GetThumb(obj)
+	if !ok {
+		return &objRes
+	}
+	return &model.ObjThumb{
+		Object: objRes,
+		Thumbnail: model.Thumbnail{
+			Thumbnail: thumb,
+		},
+	}
+}
+
+func (d *Versioning) versionDir(remoteDir, name string) string {
+	return stdpath.Join(remoteDir, versionsDir, name)
+}
+
+func (d *Versioning) exists(ctx context.Context, remotePath string) bool {
+	_, err := op.Get(ctx, d.remoteStorage, remotePath)
+	return err == nil
+}
+
+// listVersions returns the versions of the file, newest first
+func (d *Versioning) listVersions(ctx context.Context, remoteDir, name string) ([]model.Obj, error) {
+	vDir := d.versionDir(remoteDir, name)
+	if !d.exists(ctx, vDir) {
+		return nil, nil
+	}
+	objs, err := op.List(ctx, d.remoteStorage, vDir, model.ListArgs{})
+	if err != nil {
+		return nil, err
+	}
+	var res []model.Obj
+	for _, obj := range objs {
+		if _, ok := versionTime(obj.GetName()); ok && !obj.IsDir() {
+			res = append(res, obj)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].GetName() > res[j].GetName()
+	})
+	return res, nil
+}
+
+// saveVersion moves the current file into the versions dir
+func (d *Versioning) saveVersion(ctx context.Context, remoteDir, name string) (string, error) {
+	vDir := d.versionDir(remoteDir, name)
+	if err := op.MakeDir(ctx, d.remoteStorage, vDir); err != nil {
+		return "", err
+	}
+	if err := op.Move(ctx, d.remoteStorage, stdpath.Join(remoteDir, name), vDir); err != nil {
+		return "", err
+	}
+	version := versionName(time.Now(), name)
+	if err := op.Rename(ctx, d.remoteStorage, stdpath.Join(vDir, name), version); err != nil {
+		if err := op.Move(ctx, d.remoteStorage, stdpath.Join(vDir, name), remoteDir); err != nil {
+			log.Errorf("failed to move back [%s]: %+v", stdpath.Join(remoteDir, name), err)
+		}
+		return "", err
+	}
+	return version, nil
+}
+
+// takeVersion moves the version back to be the current file
+func (d *Versioning) takeVersion(ctx context.Context, remoteDir, name, version string) error {
+	if err := op.Move(ctx, d.remoteStorage, stdpath.Join(d.versionDir(remoteDir, name), version), remoteDir); err != nil {
+		return err
+	}
+	return op.Rename(ctx, d.remoteStorage, stdpath.Join(remoteDir, version), name)
+}
+
+// prune removes the versions beyond the retention rules
+func (d *Versioning) prune(ctx context.Context, remoteDir, name string) {
+	versions, err := d.listVersions(ctx, remoteDir, name)
+	if err != nil {
+		log.Warnf("failed to list versions of [%s]: %+v", stdpath.Join(remoteDir, name), err)
+		return
+	}
+	if len(versions) == 0 {
+		return
+	}
+	kept := 0
+	for i, v := range versions {
+		t, _ := versionTime(v.GetName())
+		if (d.MaxVersions <= 0 || i < d.MaxVersions) &&
+			(d.MaxAge <= 0 || time.Since(t) <= time.Duration(d.MaxAge)*24*time.Hour) {
+			kept++
+			continue
+		}
+		if err := op.Remove(ctx, d.remoteStorage, stdpath.Join(d.versionDir(remoteDir, name), v.GetName())); err != nil {
+			log.Warnf("failed to remove version [%s] of [%s]: %+v", v.GetName(), stdpath.Join(remoteDir, name), err)
+			kept++
+		}
+	}
+	if kept == 0 {
+		if err := op.Remove(ctx, d.remoteStorage, d.versionDir(remoteDir, name)); err != nil && !errs.IsObjectNotFound(err) {
+			log.Warnf("failed to remove versions dir of [%s]: %+v", stdpath.Join(remoteDir, name), err)
+		}
+	}
+}
@@ -68,7 +68,7 @@ func (d *WeiYun) Init(ctx context.Context) error {
 
 	// qqCookie保活
 	if d.client.LoginType() == 1 {
-		d.cron = cron.NewCron(time.Minute * 5)
+		d.cron = cron.NewCron(time.Minute * 5).LeaderOnly()
 		d.cron.Do(func() {
 			_ = d.client.KeepAlive()
 		})
@@ -14,10 +14,15 @@ import (
 // do others that not defined in Driver interface
 
 func (d *YandexDisk) refreshToken() error {
+	unlock, err := op.LockDriverToken(d)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 	u := "https://oauth.yandex.com/token"
 	var resp base.TokenResp
 	var e TokenErrResp
-	_, err := base.RestyClient.R().SetResult(&resp).SetError(&e).SetFormData(map[string]string{
+	_, err = base.RestyClient.R().SetResult(&resp).SetError(&e).SetFormData(map[string]string{
 		"grant_type":    "refresh_token",
 		"refresh_token": d.RefreshToken,
 		"client_id":     d.ClientID,
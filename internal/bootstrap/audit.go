@@ -0,0 +1,22 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/setting"
+	"github.com/OpenListTeam/OpenList/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+func InitAuditLog() {
+	cleanAuditLogs()
+	cron.NewCron(time.Hour).LeaderOnly().Do(cleanAuditLogs)
+}
+
+func cleanAuditLogs() {
+	if err := op.CleanAuditLogs(setting.GetInt(conf.AuditLogRetentionDays, 90)); err != nil {
+		log.Errorf("failed to clean the audit logs: %+v", err)
+	}
+}
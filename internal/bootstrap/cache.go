@@ -0,0 +1,13 @@
+package bootstrap
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/cache"
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	log "github.com/sirupsen/logrus"
+)
+
+func InitCache() {
+	if err := cache.Init(); err != nil {
+		log.Fatalf("failed init %s cache: %+v", conf.Conf.Cache.Type, err)
+	}
+}
@@ -0,0 +1,12 @@
+package bootstrap
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/cluster"
+	log "github.com/sirupsen/logrus"
+)
+
+func InitCluster() {
+	if err := cluster.Init(); err != nil {
+		log.Fatalf("failed init cluster: %+v", err)
+	}
+}
@@ -5,7 +5,6 @@ import "github.com/OpenListTeam/OpenList/cmd/flags"
 func InitData() {
 	initUser()
 	initSettings()
-	initTasks()
 	if flags.Dev {
 		initDevData()
 		initDevDo()
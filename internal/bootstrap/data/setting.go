@@ -160,6 +160,11 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.ForwardDirectLinkParams, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL},
 		{Key: conf.IgnoreDirectLinkParams, Value: "sign,alist_ts", Type: conf.TypeString, Group: model.GLOBAL},
 		{Key: conf.WebauthnLoginEnabled, Value: "false", Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.PUBLIC},
+		{Key: conf.LoginMaxFailures, Value: "5", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `lock the account or the IP after the number of failed logins, 0 means never`},
+		{Key: conf.LoginLockDuration, Value: "5", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `minutes of the first lock, it doubles on every following lock`},
+		{Key: conf.LoginMaxLockDuration, Value: "1440", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `max minutes of a lock`},
+		{Key: conf.LoginCaptchaFailures, Value: "0", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `require a captcha for web login after the number of failed logins, 0 means never`},
+		{Key: conf.AuditLogRetentionDays, Value: "90", Type: conf.TypeNumber, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `days to keep the audit logs, 0 means forever`},
 
 		// single settings
 		{Key: conf.Token, Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
@@ -210,6 +215,11 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.FTPImplicitTLS, Value: "false", Type: conf.TypeBool, Group: model.FTP, Flag: model.PRIVATE},
 		{Key: conf.FTPTLSPrivateKeyPath, Value: "", Type: conf.TypeString, Group: model.FTP, Flag: model.PRIVATE},
 		{Key: conf.FTPTLSPublicCertPath, Value: "", Type: conf.TypeString, Group: model.FTP, Flag: model.PRIVATE},
+		{Key: conf.FTPMaxSessionsPerUser, Value: "0", Type: conf.TypeNumber, Group: model.FTP, Flag: model.PRIVATE, Help: `max concurrent FTP and SFTP sessions of a user, 0 means unlimited`},
+		{Key: conf.FTPMaxSessionsPerIP, Value: "0", Type: conf.TypeNumber, Group: model.FTP, Flag: model.PRIVATE, Help: `max concurrent FTP and SFTP sessions from an IP, 0 means unlimited`},
+		{Key: conf.FTPUserDownloadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.FTP, Flag: model.PRIVATE, Help: `download speed of a user in KB/s shared by all the sessions, -1 means unlimited`},
+		{Key: conf.FTPUserUploadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.FTP, Flag: model.PRIVATE, Help: `upload speed of a user in KB/s shared by all the sessions, -1 means unlimited`},
+		{Key: conf.FTPTransferLog, Value: "", Type: conf.TypeString, Group: model.FTP, Flag: model.PRIVATE, Help: `path of the xferlog format transfer log, empty means disabled`},
 
 		// traffic settings
 		{Key: conf.TaskOfflineDownloadThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Download.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
@@ -218,6 +228,10 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.TaskCopyThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Copy.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.TaskDecompressDownloadThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.Decompress.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.TaskDecompressUploadThreadsNum, Value: strconv.Itoa(conf.Conf.Tasks.DecompressUpload.Workers), Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
+		{Key: conf.TaskHistoryRetentionDays, Value: "30", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
+		{Key: conf.TaskHistoryMaxCount, Value: "10000", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
+		{Key: conf.TaskMaxRunningPerUser, Value: "0", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
+		{Key: conf.TaskMaxRunningPerStorage, Value: "0", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxClientDownloadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxClientUploadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxServerDownloadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
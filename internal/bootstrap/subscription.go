@@ -0,0 +1,7 @@
+package bootstrap
+
+import "github.com/OpenListTeam/OpenList/internal/subscription"
+
+func InitSubscriptions() {
+	subscription.Init()
+}
@@ -1,12 +1,18 @@
 package bootstrap
 
 import (
+	"time"
+
 	"github.com/OpenListTeam/OpenList/internal/conf"
-	"github.com/OpenListTeam/OpenList/internal/db"
 	"github.com/OpenListTeam/OpenList/internal/fs"
 	"github.com/OpenListTeam/OpenList/internal/offline_download/tool"
 	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/pipeline"
 	"github.com/OpenListTeam/OpenList/internal/setting"
+	"github.com/OpenListTeam/OpenList/internal/task"
+	"github.com/OpenListTeam/OpenList/pkg/cron"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	log "github.com/sirupsen/logrus"
 	"github.com/xhofe/tache"
 )
 
@@ -18,31 +24,57 @@ func taskFilterNegative(num int) int64 {
 }
 
 func InitTaskManager() {
-	fs.UploadTaskManager = tache.NewManager[*fs.UploadTask](tache.WithWorks(setting.GetInt(conf.TaskUploadThreadsNum, conf.Conf.Tasks.Upload.Workers)), tache.WithMaxRetry(conf.Conf.Tasks.Upload.MaxRetry)) //upload will not support persist
+	setConcurrencyLimits()
+	op.RegisterSettingChangingCallback(setConcurrencyLimits)
+	fs.UploadTaskManager = task.NewScheduler[*fs.UploadTask]("upload", conf.Conf.Tasks.Upload.TaskPersistant, tache.WithWorks(setting.GetInt(conf.TaskUploadThreadsNum, conf.Conf.Tasks.Upload.Workers)), tache.WithMaxRetry(conf.Conf.Tasks.Upload.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		fs.UploadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskUploadThreadsNum, conf.Conf.Tasks.Upload.Workers)))
 	})
-	fs.CopyTaskManager = tache.NewManager[*fs.CopyTask](tache.WithWorks(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("copy", conf.Conf.Tasks.Copy.TaskPersistant), db.UpdateTaskDataFunc("copy", conf.Conf.Tasks.Copy.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Copy.MaxRetry))
+	fs.CopyTaskManager = task.NewScheduler[*fs.CopyTask]("copy", conf.Conf.Tasks.Copy.TaskPersistant, tache.WithWorks(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers)), tache.WithMaxRetry(conf.Conf.Tasks.Copy.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		fs.CopyTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskCopyThreadsNum, conf.Conf.Tasks.Copy.Workers)))
 	})
-	tool.DownloadTaskManager = tache.NewManager[*tool.DownloadTask](tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant), db.UpdateTaskDataFunc("download", conf.Conf.Tasks.Download.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Download.MaxRetry))
+	tool.DownloadTaskManager = task.NewScheduler[*tool.DownloadTask]("download", conf.Conf.Tasks.Download.TaskPersistant, tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)), tache.WithMaxRetry(conf.Conf.Tasks.Download.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		tool.DownloadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadThreadsNum, conf.Conf.Tasks.Download.Workers)))
 	})
-	tool.TransferTaskManager = tache.NewManager[*tool.TransferTask](tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant), db.UpdateTaskDataFunc("transfer", conf.Conf.Tasks.Transfer.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Transfer.MaxRetry))
+	tool.TransferTaskManager = task.NewScheduler[*tool.TransferTask]("transfer", conf.Conf.Tasks.Transfer.TaskPersistant, tache.WithWorks(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)), tache.WithMaxRetry(conf.Conf.Tasks.Transfer.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		tool.TransferTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskOfflineDownloadTransferThreadsNum, conf.Conf.Tasks.Transfer.Workers)))
 	})
-	if len(tool.TransferTaskManager.GetAll()) == 0 { //prevent offline downloaded files from being deleted
-		CleanTempDir()
-	}
-	fs.ArchiveDownloadTaskManager = tache.NewManager[*fs.ArchiveDownloadTask](tache.WithWorks(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)), tache.WithPersistFunction(db.GetTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant), db.UpdateTaskDataFunc("decompress", conf.Conf.Tasks.Decompress.TaskPersistant)), tache.WithMaxRetry(conf.Conf.Tasks.Decompress.MaxRetry))
+	fs.ArchiveDownloadTaskManager = task.NewScheduler[*fs.ArchiveDownloadTask]("decompress", conf.Conf.Tasks.Decompress.TaskPersistant, tache.WithWorks(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)), tache.WithMaxRetry(conf.Conf.Tasks.Decompress.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveDownloadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressDownloadThreadsNum, conf.Conf.Tasks.Decompress.Workers)))
 	})
-	fs.ArchiveContentUploadTaskManager.Manager = tache.NewManager[*fs.ArchiveContentUploadTask](tache.WithWorks(setting.GetInt(conf.TaskDecompressUploadThreadsNum, conf.Conf.Tasks.DecompressUpload.Workers)), tache.WithMaxRetry(conf.Conf.Tasks.DecompressUpload.MaxRetry)) //decompress upload will not support persist
+	fs.ArchiveContentUploadTaskManager.Scheduler = task.NewScheduler[*fs.ArchiveContentUploadTask]("decompress_upload", conf.Conf.Tasks.DecompressUpload.TaskPersistant, tache.WithWorks(setting.GetInt(conf.TaskDecompressUploadThreadsNum, conf.Conf.Tasks.DecompressUpload.Workers)), tache.WithMaxRetry(conf.Conf.Tasks.DecompressUpload.MaxRetry))
 	op.RegisterSettingChangingCallback(func() {
 		fs.ArchiveContentUploadTaskManager.SetWorkersNumActive(taskFilterNegative(setting.GetInt(conf.TaskDecompressUploadThreadsNum, conf.Conf.Tasks.DecompressUpload.Workers)))
 	})
+	pipeline.PipelineTaskManager = task.NewScheduler[*pipeline.PipelineTask]("pipeline", conf.Conf.Tasks.Pipeline.TaskPersistant, tache.WithWorks(conf.Conf.Tasks.Pipeline.Workers), tache.WithMaxRetry(conf.Conf.Tasks.Pipeline.MaxRetry))
+	// prevent the offline downloaded files, including the partial ones to
+	// resume, and the cached uploads from being deleted
+	if !hasUnfinished(tool.DownloadTaskManager) && !hasUnfinished(tool.TransferTaskManager) && !hasUnfinished(fs.UploadTaskManager) &&
+		!hasUnfinished(fs.ArchiveContentUploadTaskManager.Scheduler) {
+		CleanTempDir()
+	}
+	cleanTaskHistory()
+	cron.NewCron(time.Hour).LeaderOnly().Do(cleanTaskHistory)
+	op.RegisterSettingChangingCallback(cleanTaskHistory)
+}
+
+func setConcurrencyLimits() {
+	task.SetConcurrencyLimits(setting.GetInt(conf.TaskMaxRunningPerUser, 0), setting.GetInt(conf.TaskMaxRunningPerStorage, 0))
+}
+
+func hasUnfinished[T task.TaskExtensionInfo](m *task.Scheduler[T]) bool {
+	return len(m.GetByCondition(func(t T) bool {
+		return !utils.SliceContains(task.FinishedStates, int(t.GetState()))
+	})) > 0
+}
+
+func cleanTaskHistory() {
+	err := task.CleanHistory(setting.GetInt(conf.TaskHistoryRetentionDays, 30), setting.GetInt(conf.TaskHistoryMaxCount, 10000))
+	if err != nil {
+		log.Errorf("failed to clean the task history: %+v", err)
+	}
 }
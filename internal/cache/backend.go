@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var errNotFound = errors.New("cache not found")
+
+// Backend stores the shared caches and propagates the messages, such as the
+// invalidations of the local caches, between the nodes
+type Backend interface {
+	// Get returns errNotFound if the key doesn't exist
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set with zero ttl means no expiration
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// GetDel gets and deletes the key atomically, errNotFound if it doesn't exist
+	GetDel(ctx context.Context, key string) ([]byte, error)
+	Del(ctx context.Context, keys ...string) error
+	// HIncrBy adds n to the field of the hash and resets the ttl of the
+	// hash at once, the new value is returned
+	HIncrBy(ctx context.Context, key, field string, n int64, ttl time.Duration) (int64, error)
+	// HSet sets the fields of the hash and resets its ttl at once
+	HSet(ctx context.Context, key string, fields map[string]int64, ttl time.Duration) error
+	// HGetAll returns the fields of the hash, empty if it doesn't exist
+	HGetAll(ctx context.Context, key string) (map[string]int64, error)
+	// Keys returns the keys with the prefix
+	Keys(ctx context.Context, prefix string) ([]string, error)
+	DelPrefix(ctx context.Context, prefix string) error
+	Publish(ctx context.Context, msg []byte) error
+	// Subscribe calls f with the messages published by all the nodes
+	Subscribe(ctx context.Context, f func(msg []byte)) error
+	Close() error
+}
+
+// backend is nil if the caches are only kept in memory
+var backend Backend
+
+// node identifies the messages published by this node
+var node = random.String(16)
+
+const opTimeout = 3 * time.Second
+
+// Init connects the backend of the config
+func Init() error {
+	var b Backend
+	switch conf.Conf.Cache.Type {
+	case "", "memory":
+		return nil
+	case "redis":
+		r, err := newRedis(conf.Conf.Cache.Redis)
+		if err != nil {
+			return err
+		}
+		b = r
+	default:
+		return fmt.Errorf("unknown cache type [%s]", conf.Conf.Cache.Type)
+	}
+	if err := b.Subscribe(context.Background(), dispatch); err != nil {
+		_ = b.Close()
+		return err
+	}
+	backend = b
+	return nil
+}
+
+// Close closes the backend, the caches fall back to memory
+func Close() {
+	if backend != nil {
+		_ = backend.Close()
+		backend = nil
+	}
+}
+
+type message struct {
+	Node string `json:"node"`
+	// Cache and Keys are the local cache to invalidate, all the keys are
+	// invalidated if Keys is empty
+	Cache string   `json:"cache,omitempty"`
+	Keys  []string `json:"keys,omitempty"`
+	// Topic and Payload are for the subscribers
+	Topic   string `json:"topic,omitempty"`
+	Payload string `json:"payload,omitempty"`
+}
+
+var (
+	locals   sync.Map // cache name -> invalidator
+	handlers sync.Map // topic -> func(payload string)
+)
+
+type invalidator interface {
+	invalidate(keys []string)
+}
+
+func publish(m message) {
+	if backend == nil {
+		return
+	}
+	m.Node = node
+	b, err := json.Marshal(m)
+	if err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	if err = backend.Publish(ctx, b); err != nil {
+		log.Warnf("failed publish cache message: %+v", err)
+	}
+}
+
+func dispatch(b []byte) {
+	var m message
+	if err := json.Unmarshal(b, &m); err != nil || m.Node == node {
+		return
+	}
+	if m.Topic != "" {
+		if f, ok := handlers.Load(m.Topic); ok {
+			f.(func(string))(m.Payload)
+		}
+		return
+	}
+	if c, ok := locals.Load(m.Cache); ok {
+		c.(invalidator).invalidate(m.Keys)
+	}
+}
+
+// Publish sends the payload to the subscribers of the topic on the other
+// nodes
+func Publish(topic, payload string) {
+	publish(message{Topic: topic, Payload: payload})
+}
+
+// Subscribe handles the payloads of the topic published by the other nodes
+func Subscribe(topic string, f func(payload string)) {
+	handlers.Store(topic, f)
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"time"
+
+	gocache "github.com/Xhofe/go-cache"
+)
+
+// Cache is the cache of op, the values are kept in memory unless a shared
+// backend is configured
+type Cache[V any] interface {
+	Get(key string) (V, bool)
+	Set(key string, v V, opts ...SetOption[V]) bool
+	Del(keys ...string)
+	Clear()
+}
+
+type SetOption[V any] = gocache.SetIOption[V]
+
+func WithEx[V any](d time.Duration) SetOption[V] {
+	return gocache.WithEx[V](d)
+}
+
+// ttlItem records the expiration set by the options
+type ttlItem struct {
+	expire time.Time
+}
+
+func (i *ttlItem) Expired() bool {
+	return i.CanExpire() && !time.Now().Before(i.expire)
+}
+
+func (i *ttlItem) CanExpire() bool {
+	return !i.expire.IsZero()
+}
+
+func (i *ttlItem) SetExpireAt(t time.Time) {
+	i.expire = t
+}
+
+// ttl returns the time to live set by the options, zero means no expiration
+// and false means the value is already expired
+func ttl[V any](opts []SetOption[V]) (time.Duration, bool) {
+	item := &ttlItem{}
+	for _, opt := range opts {
+		if !opt(nil, "", item) {
+			return 0, false
+		}
+	}
+	if !item.CanExpire() {
+		return 0, true
+	}
+	d := time.Until(item.expire)
+	return d, d > 0
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/alicebob/miniredis/v2"
+)
+
+type value struct {
+	Name string
+	Hash string `json:"-"`
+}
+
+func initRedis(t *testing.T) *miniredis.Miniredis {
+	s := miniredis.RunT(t)
+	conf.Conf = conf.DefaultConfig()
+	conf.Conf.Cache.Type = "redis"
+	conf.Conf.Cache.Redis.Address = s.Addr()
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(Close)
+	return s
+}
+
+func TestShared(t *testing.T) {
+	s := initRedis(t)
+	c := NewShared[*value]("test", 1)
+	c.Set("a", &value{Name: "a", Hash: "x"}, WithEx[*value](time.Minute))
+	c.Set("b", nil)
+	v, ok := c.Get("a")
+	if !ok || v.Name != "a" || v.Hash != "x" {
+		t.Fatalf("unexpected value: %+v", v)
+	}
+	if v, ok = c.Get("b"); !ok || v != nil {
+		t.Fatalf("expected cached nil, got %+v", v)
+	}
+	if !s.Exists("openlist:cache:test:a") {
+		t.Fatal("expected the value in redis")
+	}
+	// read the value from redis rather than memory
+	c.invalidate([]string{"a"})
+	s.FastForward(2 * time.Minute)
+	if _, ok = c.Get("a"); ok {
+		t.Fatal("expected a expired")
+	}
+	c.Clear()
+	if _, ok = c.Get("b"); ok {
+		t.Fatal("expected b cleared")
+	}
+}
+
+func TestSharedNear(t *testing.T) {
+	s := initRedis(t)
+	c := NewShared[*value]("test_near", 1)
+	c.Set("a", &value{Name: "a"})
+	// the value is read from memory
+	s.Del("openlist:cache:test_near:a")
+	if v, ok := c.Get("a"); !ok || v.Name != "a" {
+		t.Fatalf("expected a in memory, got %+v", v)
+	}
+	got := make(chan string, 1)
+	Subscribe("test_near_topic", func(payload string) {
+		got <- payload
+	})
+	// another node changes the value
+	publishFrom(t, message{Cache: "test_near", Keys: []string{"a"}})
+	publishFrom(t, message{Topic: "test_near_topic"})
+	select {
+	case <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the message")
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected a invalidated")
+	}
+}
+
+// publishFrom publishes the message as another node
+func publishFrom(t *testing.T, m message) {
+	m.Node = "other"
+	b, _ := json.Marshal(m)
+	if err := backend.Publish(context.Background(), b); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocalInvalidate(t *testing.T) {
+	initRedis(t)
+	c := NewLocal[[]string]("test_local", 1)
+	c.Set("a", []string{"a"})
+	c.Set("b", []string{"b"})
+	got := make(chan string, 1)
+	Subscribe("test_topic", func(payload string) {
+		got <- payload
+	})
+	// the messages of this node are ignored
+	c.Invalidate("b")
+	Publish("test_topic", "self")
+	publishFrom(t, message{Cache: "test_local", Keys: []string{"a"}})
+	publishFrom(t, message{Topic: "test_topic", Payload: "other"})
+	select {
+	case p := <-got:
+		if p != "other" {
+			t.Fatalf("unexpected payload: %s", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the message")
+	}
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected a invalidated")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Fatal("expected b kept")
+	}
+	publishFrom(t, message{Cache: "test_local"})
+	publishFrom(t, message{Topic: "test_topic", Payload: "other"})
+	<-got
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("expected b cleared")
+	}
+}
+
+func TestSharedGetDel(t *testing.T) {
+	s := initRedis(t)
+	c := NewShared[string]("test_getdel", 1)
+	c.Set("a", "code")
+	if v, ok := c.GetDel("a"); !ok || v != "code" {
+		t.Fatalf("expected code, got %q", v)
+	}
+	if s.Exists("openlist:cache:test_getdel:a") {
+		t.Fatal("expected a deleted from redis")
+	}
+	if _, ok := c.GetDel("a"); ok {
+		t.Fatal("expected a used only once")
+	}
+}
+
+func testCounters(t *testing.T, c *Counters, expire func()) {
+	t.Helper()
+	for i := 1; i <= 3; i++ {
+		if v := c.Incr("a", "n", 1, time.Minute); v != int64(i) {
+			t.Fatalf("expected %d, got %d", i, v)
+		}
+	}
+	c.Set("a", map[string]int64{"t": 7}, time.Minute)
+	c.Incr("b", "n", 1, time.Second)
+	if f := c.Get("a"); f["n"] != 3 || f["t"] != 7 {
+		t.Fatalf("unexpected fields: %v", f)
+	}
+	if all := c.All(); len(all) != 2 || all["b"]["n"] != 1 {
+		t.Fatalf("unexpected counters: %v", all)
+	}
+	expire()
+	if f := c.Get("b"); f != nil {
+		t.Fatalf("expected b expired, got %v", f)
+	}
+	c.Del("a")
+	if all := c.All(); len(all) != 0 {
+		t.Fatalf("expected no counters, got %v", all)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	s := initRedis(t)
+	testCounters(t, NewCounters("test"), func() { s.FastForward(2 * time.Second) })
+	if s.Exists("openlist:cache:counter:test:a") {
+		t.Fatal("expected a deleted from redis")
+	}
+}
+
+func TestCountersMemory(t *testing.T) {
+	c := NewCounters("test_memory")
+	testCounters(t, c, func() { c.mem["b"].expireAt = time.Now() })
+	for i := 0; i < maxCounters; i++ {
+		c.Incr(strconv.Itoa(i), "n", 1, time.Minute)
+	}
+	if len(c.mem) > maxCounters {
+		t.Fatalf("expected at most %d counters, got %d", maxCounters, len(c.mem))
+	}
+}
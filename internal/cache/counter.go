@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxCounters caps the keys of a Counters kept in memory
+const maxCounters = 100000
+
+// counterSweep is how often the expired keys in memory are dropped
+const counterSweep = time.Minute
+
+// Counters keeps the integer fields of the keys, such as the failed logins
+// from an ip. The fields are changed atomically in the backend, so that the
+// nodes don't lose the changes of each other, and a key expires after the
+// ttl of its last change. Without the backend, the keys are kept in memory,
+// no more than maxCounters of them.
+type Counters struct {
+	name  string
+	mu    sync.Mutex
+	mem   map[string]*counter
+	swept time.Time
+}
+
+type counter struct {
+	fields   map[string]int64
+	expireAt time.Time
+}
+
+func NewCounters(name string) *Counters {
+	return &Counters{
+		name: name,
+		mem:  make(map[string]*counter),
+	}
+}
+
+func (c *Counters) key(k string) string {
+	return "counter:" + c.name + ":" + k
+}
+
+// Incr adds n to the field of the key and returns the new value
+func (c *Counters) Incr(key, field string, n int64, ttl time.Duration) int64 {
+	if backend == nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		e := c.entry(key, ttl)
+		e.fields[field] += n
+		return e.fields[field]
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	v, err := backend.HIncrBy(ctx, c.key(key), field, n, ttl)
+	if err != nil {
+		log.Warnf("failed incr counter [%s]: %+v", c.key(key), err)
+	}
+	return v
+}
+
+// Set sets the fields of the key
+func (c *Counters) Set(key string, fields map[string]int64, ttl time.Duration) {
+	if backend == nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		e := c.entry(key, ttl)
+		for f, v := range fields {
+			e.fields[f] = v
+		}
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	if err := backend.HSet(ctx, c.key(key), fields, ttl); err != nil {
+		log.Warnf("failed set counter [%s]: %+v", c.key(key), err)
+	}
+}
+
+// Get returns the fields of the key, nil if it doesn't exist
+func (c *Counters) Get(key string) map[string]int64 {
+	if backend == nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if e, ok := c.mem[key]; ok && time.Now().Before(e.expireAt) {
+			res := make(map[string]int64, len(e.fields))
+			for f, v := range e.fields {
+				res[f] = v
+			}
+			return res
+		}
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	fields, err := backend.HGetAll(ctx, c.key(key))
+	if err != nil {
+		log.Warnf("failed get counter [%s]: %+v", c.key(key), err)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+func (c *Counters) Del(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	if backend == nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for _, k := range keys {
+			delete(c.mem, k)
+		}
+		return
+	}
+	ks := make([]string, len(keys))
+	for i, k := range keys {
+		ks[i] = c.key(k)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	if err := backend.Del(ctx, ks...); err != nil {
+		log.Warnf("failed del counters %v: %+v", ks, err)
+	}
+}
+
+// All returns the fields of all the keys
+func (c *Counters) All() map[string]map[string]int64 {
+	res := make(map[string]map[string]int64)
+	if backend == nil {
+		c.mu.Lock()
+		keys := make([]string, 0, len(c.mem))
+		for k := range c.mem {
+			keys = append(keys, k)
+		}
+		c.mu.Unlock()
+		for _, k := range keys {
+			if fields := c.Get(k); fields != nil {
+				res[k] = fields
+			}
+		}
+		return res
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	keys, err := backend.Keys(ctx, c.key(""))
+	if err != nil {
+		log.Warnf("failed list counters [%s]: %+v", c.name, err)
+	}
+	for _, k := range keys {
+		k = strings.TrimPrefix(k, c.key(""))
+		if fields := c.Get(k); fields != nil {
+			res[k] = fields
+		}
+	}
+	return res
+}
+
+// entry returns the key in memory with the ttl reset, a new one is created
+// if it doesn't exist, must be called with the lock held
+func (c *Counters) entry(key string, ttl time.Duration) *counter {
+	now := time.Now()
+	e, ok := c.mem[key]
+	if !ok || !now.Before(e.expireAt) {
+		c.sweep(now)
+		e = &counter{fields: make(map[string]int64)}
+		c.mem[key] = e
+	}
+	e.expireAt = now.Add(ttl)
+	return e
+}
+
+// sweep drops the expired keys once in counterSweep or when the keys reach
+// maxCounters, then the keys closest to expiring are dropped until a tenth
+// of the room is free, must be called with the lock held
+func (c *Counters) sweep(now time.Time) {
+	if len(c.mem) < maxCounters && now.Sub(c.swept) < counterSweep {
+		return
+	}
+	c.swept = now
+	for k, e := range c.mem {
+		if !now.Before(e.expireAt) {
+			delete(c.mem, k)
+		}
+	}
+	if len(c.mem) < maxCounters {
+		return
+	}
+	keys := make([]string, 0, len(c.mem))
+	for k := range c.mem {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.mem[keys[i]].expireAt.Before(c.mem[keys[j]].expireAt)
+	})
+	for _, k := range keys[:len(keys)-maxCounters*9/10] {
+		delete(c.mem, k)
+	}
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	gocache "github.com/Xhofe/go-cache"
+)
+
+// LocalCache keeps the values in memory as they can't be encoded, such as
+// the objs and the links, or mustn't leave the node, such as the passwords,
+// the deletions are propagated to the other nodes
+type LocalCache[V any] struct {
+	name string
+	gocache.ICache[V]
+}
+
+func NewLocal[V any](name string, shards int) *LocalCache[V] {
+	c := &LocalCache[V]{
+		name:   name,
+		ICache: gocache.NewMemCache(gocache.WithShards[V](shards)),
+	}
+	locals.Store(name, c)
+	return c
+}
+
+func (c *LocalCache[V]) Del(keys ...string) {
+	c.ICache.Del(keys...)
+	c.Invalidate(keys...)
+}
+
+func (c *LocalCache[V]) Clear() {
+	c.ICache.Clear()
+	publish(message{Cache: c.name})
+}
+
+// Invalidate deletes the keys on the other nodes only, it's used when the
+// value is updated on this node
+func (c *LocalCache[V]) Invalidate(keys ...string) {
+	if len(keys) > 0 {
+		publish(message{Cache: c.name, Keys: keys})
+	}
+}
+
+func (c *LocalCache[V]) invalidate(keys []string) {
+	if len(keys) == 0 {
+		c.ICache.Clear()
+		return
+	}
+	c.ICache.Del(keys...)
+}
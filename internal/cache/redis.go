@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+type redisBackend struct {
+	client *redis.Client
+	prefix string
+	pubsub *redis.PubSub
+}
+
+func newRedis(c conf.Redis) (*redisBackend, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     c.Address,
+		Username: c.Username,
+		Password: c.Password,
+		DB:       c.DB,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, errors.Wrapf(err, "failed connect redis [%s]", c.Address)
+	}
+	return &redisBackend{client: client, prefix: c.Prefix}, nil
+}
+
+func (r *redisBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	b, err := r.client.Get(ctx, r.prefix+"cache:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errNotFound
+	}
+	return b, err
+}
+
+func (r *redisBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return r.client.Set(ctx, r.prefix+"cache:"+key, value, ttl).Err()
+}
+
+func (r *redisBackend) GetDel(ctx context.Context, key string) ([]byte, error) {
+	b, err := r.client.GetDel(ctx, r.prefix+"cache:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errNotFound
+	}
+	return b, err
+}
+
+func (r *redisBackend) Del(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	ks := make([]string, len(keys))
+	for i, k := range keys {
+		ks[i] = r.prefix + "cache:" + k
+	}
+	return r.client.Del(ctx, ks...).Err()
+}
+
+func (r *redisBackend) HIncrBy(ctx context.Context, key, field string, n int64, ttl time.Duration) (int64, error) {
+	k := r.prefix + "cache:" + key
+	var incr *redis.IntCmd
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.HIncrBy(ctx, k, field, n)
+		pipe.Expire(ctx, k, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (r *redisBackend) HSet(ctx context.Context, key string, fields map[string]int64, ttl time.Duration) error {
+	k := r.prefix + "cache:" + key
+	values := make([]any, 0, len(fields)*2)
+	for f, v := range fields {
+		values = append(values, f, v)
+	}
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, k, values...)
+		pipe.Expire(ctx, k, ttl)
+		return nil
+	})
+	return err
+}
+
+func (r *redisBackend) HGetAll(ctx context.Context, key string) (map[string]int64, error) {
+	m, err := r.client.HGetAll(ctx, r.prefix+"cache:"+key).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]int64, len(m))
+	for f, v := range m {
+		res[f], _ = strconv.ParseInt(v, 10, 64)
+	}
+	return res, nil
+}
+
+func (r *redisBackend) Keys(ctx context.Context, prefix string) ([]string, error) {
+	p := r.prefix + "cache:"
+	iter := r.client.Scan(ctx, 0, p+prefix+"*", 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, strings.TrimPrefix(iter.Val(), p))
+	}
+	return keys, iter.Err()
+}
+
+func (r *redisBackend) DelPrefix(ctx context.Context, prefix string) error {
+	iter := r.client.Scan(ctx, 0, r.prefix+"cache:"+prefix+"*", 100).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) >= 100 {
+			if err := r.client.Del(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return r.client.Del(ctx, keys...).Err()
+	}
+	return nil
+}
+
+func (r *redisBackend) channel() string {
+	return r.prefix + "cache"
+}
+
+func (r *redisBackend) Publish(ctx context.Context, msg []byte) error {
+	return r.client.Publish(ctx, r.channel(), msg).Err()
+}
+
+func (r *redisBackend) Subscribe(ctx context.Context, f func(msg []byte)) error {
+	r.pubsub = r.client.Subscribe(ctx, r.channel())
+	// wait for the confirmation so that no message is missed after Init
+	if _, err := r.pubsub.Receive(ctx); err != nil {
+		_ = r.pubsub.Close()
+		return errors.Wrap(err, "failed subscribe redis")
+	}
+	go func() {
+		for msg := range r.pubsub.Channel() {
+			f([]byte(msg.Payload))
+		}
+		log.Debug("redis cache subscription closed")
+	}()
+	return nil
+}
+
+func (r *redisBackend) Close() error {
+	if r.pubsub != nil {
+		_ = r.pubsub.Close()
+	}
+	return r.client.Close()
+}
+
+// RedisClient returns the client and the key prefix of the redis backend,
+// nil if the cache isn't stored in redis
+func RedisClient() (*redis.Client, string) {
+	if r, ok := backend.(*redisBackend); ok {
+		return r.client, r.prefix
+	}
+	return nil, ""
+}
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"time"
+
+	gocache "github.com/Xhofe/go-cache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// entry wraps the value so that nil pointers can be cached too
+type entry[V any] struct {
+	Value V
+}
+
+// nearTTL bounds how long a value of the backend is kept in memory, in case
+// an invalidation from another node is missed
+const nearTTL = time.Minute
+
+// SharedCache stores the values in the backend so that all the nodes see
+// the same values, the values must be encodable by gob and mustn't contain
+// secrets such as passwords. The values read from the backend are kept in
+// memory too, the other nodes invalidate them when they change the values.
+type SharedCache[V any] struct {
+	name string
+	mem  gocache.ICache[V]
+}
+
+func NewShared[V any](name string, shards int) *SharedCache[V] {
+	c := &SharedCache[V]{
+		name: name,
+		mem:  gocache.NewMemCache(gocache.WithShards[V](shards)),
+	}
+	locals.Store(name, c)
+	return c
+}
+
+// near keeps the value in memory, no longer than it's kept in the backend
+func (c *SharedCache[V]) near(key string, v V, ttl time.Duration) {
+	if ttl <= 0 || ttl > nearTTL {
+		ttl = nearTTL
+	}
+	c.mem.Set(key, v, gocache.WithEx[V](ttl))
+}
+
+func (c *SharedCache[V]) invalidate(keys []string) {
+	if len(keys) == 0 {
+		c.mem.Clear()
+		return
+	}
+	c.mem.Del(keys...)
+}
+
+func (c *SharedCache[V]) key(k string) string {
+	return c.name + ":" + k
+}
+
+func (c *SharedCache[V]) Get(key string) (V, bool) {
+	if v, ok := c.mem.Get(key); ok || backend == nil {
+		return v, ok
+	}
+	v, ok := c.load(key, backend.Get)
+	if ok {
+		c.near(key, v, 0)
+	}
+	return v, ok
+}
+
+// GetDel gets and deletes the value at once, so that only one of the nodes
+// gets it, e.g. a one-time code
+func (c *SharedCache[V]) GetDel(key string) (V, bool) {
+	if backend == nil {
+		return c.mem.GetDel(key)
+	}
+	c.mem.Del(key)
+	v, ok := c.load(key, backend.GetDel)
+	if ok {
+		publish(message{Cache: c.name, Keys: []string{key}})
+	}
+	return v, ok
+}
+
+// load reads the value from the backend by get
+func (c *SharedCache[V]) load(key string, get func(ctx context.Context, key string) ([]byte, error)) (V, bool) {
+	var v V
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	b, err := get(ctx, c.key(key))
+	if err != nil {
+		if !errors.Is(err, errNotFound) {
+			log.Warnf("failed get cache [%s]: %+v", c.key(key), err)
+		}
+		return v, false
+	}
+	var e entry[V]
+	if err = gob.NewDecoder(bytes.NewReader(b)).Decode(&e); err != nil {
+		log.Warnf("failed decode cache [%s]: %+v", c.key(key), err)
+		return v, false
+	}
+	return e.Value, true
+}
+
+func (c *SharedCache[V]) Set(key string, v V, opts ...SetOption[V]) bool {
+	if backend == nil {
+		return c.mem.Set(key, v, opts...)
+	}
+	ttl, ok := ttl(opts)
+	if !ok {
+		return false
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(entry[V]{Value: v}); err != nil {
+		log.Warnf("failed encode cache [%s]: %+v", c.key(key), err)
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	if err := backend.Set(ctx, c.key(key), buf.Bytes(), ttl); err != nil {
+		log.Warnf("failed set cache [%s]: %+v", c.key(key), err)
+		return false
+	}
+	c.near(key, v, ttl)
+	publish(message{Cache: c.name, Keys: []string{key}})
+	return true
+}
+
+func (c *SharedCache[V]) Del(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	if backend == nil {
+		c.mem.Del(keys...)
+		return
+	}
+	ks := make([]string, len(keys))
+	for i, k := range keys {
+		ks[i] = c.key(k)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	if err := backend.Del(ctx, ks...); err != nil {
+		log.Warnf("failed del cache %v: %+v", ks, err)
+	}
+	c.mem.Del(keys...)
+	publish(message{Cache: c.name, Keys: keys})
+}
+
+func (c *SharedCache[V]) Clear() {
+	if backend == nil {
+		c.mem.Clear()
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	if err := backend.DelPrefix(ctx, c.key("")); err != nil {
+		log.Warnf("failed clear cache [%s]: %+v", c.name, err)
+	}
+	c.mem.Clear()
+	publish(message{Cache: c.name})
+}
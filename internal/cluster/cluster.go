@@ -0,0 +1,227 @@
+package cluster
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/cache"
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/pkg/cron"
+	"github.com/OpenListTeam/OpenList/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const leaderLease = "leader"
+
+var (
+	enabled atomic.Bool
+	nodeID  string
+	// instance tells this process from a former one of the same node
+	instance = random.String(16)
+	ttl      time.Duration
+	store    leaser
+	leader   atomic.Bool
+	loop     *cron.Cron
+)
+
+func nodeLease(id string) string {
+	return "node:" + id
+}
+
+// Init joins the cluster, the leases are stored in redis if the cache is,
+// otherwise in the database
+func Init() error {
+	c := conf.Conf.Cluster
+	if !c.Enable {
+		return nil
+	}
+	nodeID = c.NodeID
+	if nodeID == "" {
+		h, err := os.Hostname()
+		if err != nil {
+			return errors.Wrap(err, "failed get the hostname as the node id")
+		}
+		nodeID = h
+	}
+	ttl = time.Duration(c.LeaseTTL) * time.Second
+	if ttl <= 0 {
+		ttl = 15 * time.Second
+	}
+	if client, prefix := cache.RedisClient(); client != nil {
+		store = &redisLeaser{client: client, prefix: prefix}
+	} else {
+		store = dbLeaser{}
+		log.Warn("the caches aren't shared by the cluster nodes, the redis cache is recommended")
+	}
+	// the lease of the former process of this node expires in ttl if it
+	// wasn't closed normally
+	deadline := time.Now().Add(ttl + time.Second)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), ttl)
+		ok, err := store.acquire(ctx, nodeLease(nodeID), instance, ttl)
+		cancel()
+		if err != nil {
+			return errors.WithMessage(err, "failed join the cluster")
+		}
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("the node id [%s] is used by another running node", nodeID)
+		}
+		time.Sleep(time.Second)
+	}
+	enabled.Store(true)
+	cron.IsLeader = IsLeader
+	heartbeat()
+	loop = cron.NewCron(ttl / 3)
+	loop.Do(heartbeat)
+	log.Infof("node [%s] joined the cluster", nodeID)
+	return nil
+}
+
+// heartbeat renews the lease of the node and takes part in the election
+func heartbeat() {
+	ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+	defer cancel()
+	if ok, err := store.acquire(ctx, nodeLease(nodeID), instance, ttl); err != nil || !ok {
+		log.Errorf("failed renew the lease of node [%s]: %v", nodeID, err)
+	}
+	ok, err := store.acquire(ctx, leaderLease, nodeID, ttl)
+	if err != nil {
+		// step down as the others can't tell whether it's still alive
+		log.Errorf("failed renew the leader lease: %+v", err)
+		ok = false
+	}
+	if leader.Swap(ok) != ok {
+		if ok {
+			log.Infof("node [%s] becomes the leader", nodeID)
+		} else {
+			log.Warnf("node [%s] is no longer the leader", nodeID)
+		}
+	}
+}
+
+// Close leaves the cluster, the leader lease is released for the others
+func Close() {
+	if !enabled.Load() {
+		return
+	}
+	loop.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+	defer cancel()
+	if leader.Swap(false) {
+		if err := store.release(ctx, leaderLease, nodeID); err != nil {
+			log.Warnf("failed release the leader lease: %+v", err)
+		}
+	}
+	if err := store.release(ctx, nodeLease(nodeID), instance); err != nil {
+		log.Warnf("failed release the lease of node [%s]: %+v", nodeID, err)
+	}
+	enabled.Store(false)
+}
+
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// NodeID returns the id of this node, empty if the cluster is disabled
+func NodeID() string {
+	if !enabled.Load() {
+		return ""
+	}
+	return nodeID
+}
+
+// IsLeader reports whether this node runs the singleton jobs, it's always
+// true if the cluster is disabled
+func IsLeader() bool {
+	return !enabled.Load() || leader.Load()
+}
+
+// Leader returns the id of the leader node, empty if there is none
+func Leader() string {
+	if !enabled.Load() {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+	defer cancel()
+	h, err := store.holder(ctx, leaderLease)
+	if err != nil {
+		log.Warnf("failed get the leader: %+v", err)
+	}
+	return h
+}
+
+// Alive reports whether the node keeps its lease, the node is considered
+// alive if it can't be told
+func Alive(node string) bool {
+	if !enabled.Load() || node == nodeID {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+	defer cancel()
+	h, err := store.holder(ctx, nodeLease(node))
+	if err != nil {
+		log.Warnf("failed check node [%s]: %+v", node, err)
+		return true
+	}
+	return h != ""
+}
+
+func lockLease(name string) string {
+	return "lock:" + name
+}
+
+// TryLock takes the lock of a singleton job across the cluster until unlock
+// is called, ok is false if the lock is held by a job on any node
+func TryLock(name string) (unlock func(), ok bool) {
+	if !enabled.Load() {
+		return func() {}, true
+	}
+	holder := nodeID + "/" + random.String(8)
+	ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+	defer cancel()
+	ok, err := store.acquire(ctx, lockLease(name), holder, ttl)
+	if err != nil {
+		log.Errorf("failed lock [%s]: %+v", name, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	renew := cron.NewCron(ttl / 3)
+	renew.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+		defer cancel()
+		if ok, err := store.acquire(ctx, lockLease(name), holder, ttl); err != nil || !ok {
+			log.Warnf("failed renew the lock [%s]: %v", name, err)
+		}
+	})
+	return func() {
+		renew.Stop()
+		ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+		defer cancel()
+		if err := store.release(ctx, lockLease(name), holder); err != nil {
+			log.Warnf("failed unlock [%s]: %+v", name, err)
+		}
+	}, true
+}
+
+// Locked reports whether the lock is held by a job on any node
+func Locked(name string) bool {
+	if !enabled.Load() {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ttl/3)
+	defer cancel()
+	h, err := store.holder(ctx, lockLease(name))
+	if err != nil {
+		log.Warnf("failed check the lock [%s]: %+v", name, err)
+		return false
+	}
+	return h != ""
+}
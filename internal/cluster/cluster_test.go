@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func testLeaser(t *testing.T, l leaser) {
+	ctx := context.Background()
+	ttl := time.Second
+	if ok, err := l.acquire(ctx, "leader", "a", ttl); err != nil || !ok {
+		t.Fatalf("expected a to acquire the lease: %v", err)
+	}
+	if ok, err := l.acquire(ctx, "leader", "b", ttl); err != nil || ok {
+		t.Fatalf("expected b not to acquire the lease: %v", err)
+	}
+	if ok, err := l.acquire(ctx, "leader", "a", ttl); err != nil || !ok {
+		t.Fatalf("expected a to renew the lease: %v", err)
+	}
+	if h, err := l.holder(ctx, "leader"); err != nil || h != "a" {
+		t.Fatalf("expected a to hold the lease, got %s: %v", h, err)
+	}
+	// b can't release the lease of a
+	if err := l.release(ctx, "leader", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.release(ctx, "leader", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := l.acquire(ctx, "leader", "b", ttl); err != nil || !ok {
+		t.Fatalf("expected b to acquire the released lease: %v", err)
+	}
+}
+
+func TestDBLeaser(t *testing.T) {
+	db.Init(dbtest.Open(t))
+	testLeaser(t, dbLeaser{})
+	// the lease expires if it isn't renewed
+	if ok, _ := (dbLeaser{}).acquire(context.Background(), "expire", "a", time.Millisecond); !ok {
+		t.Fatal("expected a to acquire the lease")
+	}
+	time.Sleep(time.Millisecond * 10)
+	if ok, err := (dbLeaser{}).acquire(context.Background(), "expire", "b", time.Second); err != nil || !ok {
+		t.Fatalf("expected b to acquire the expired lease: %v", err)
+	}
+}
+
+func TestRedisLeaser(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	defer client.Close()
+	l := &redisLeaser{client: client, prefix: "openlist:"}
+	testLeaser(t, l)
+	s.FastForward(2 * time.Second)
+	if h, err := l.holder(context.Background(), "leader"); err != nil || h != "" {
+		t.Fatalf("expected the lease expired, got %s: %v", h, err)
+	}
+}
@@ -0,0 +1,75 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// leaser stores the leases, a lease is held by one node until it expires
+type leaser interface {
+	// acquire takes the lease or renews it if the holder has it, false
+	// means another node holds the lease
+	acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	release(ctx context.Context, name, holder string) error
+	// holder returns empty if the lease is free
+	holder(ctx context.Context, name string) (string, error)
+}
+
+type dbLeaser struct{}
+
+func (dbLeaser) acquire(_ context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	return db.AcquireLease(name, holder, ttl)
+}
+
+func (dbLeaser) release(_ context.Context, name, holder string) error {
+	return db.ReleaseLease(name, holder)
+}
+
+func (dbLeaser) holder(_ context.Context, name string) (string, error) {
+	return db.GetLeaseHolder(name)
+}
+
+type redisLeaser struct {
+	client *redis.Client
+	prefix string
+}
+
+var (
+	renewScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	releaseScript = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+func (r *redisLeaser) key(name string) string {
+	return r.prefix + "lease:" + name
+}
+
+func (r *redisLeaser) acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	ok, err := r.client.SetNX(ctx, r.key(name), holder, ttl).Result()
+	if err != nil || ok {
+		return ok, errors.WithStack(err)
+	}
+	n, err := renewScript.Run(ctx, r.client, []string{r.key(name)}, holder, ttl.Milliseconds()).Int()
+	return n == 1, errors.WithStack(err)
+}
+
+func (r *redisLeaser) release(ctx context.Context, name, holder string) error {
+	return errors.WithStack(releaseScript.Run(ctx, r.client, []string{r.key(name)}, holder).Err())
+}
+
+func (r *redisLeaser) holder(ctx context.Context, name string) (string, error) {
+	h, err := r.client.Get(ctx, r.key(name)).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return h, errors.WithStack(err)
+}
@@ -60,6 +60,7 @@ type TasksConfig struct {
 	Copy               TaskConfig `json:"copy" envPrefix:"COPY_"`
 	Decompress         TaskConfig `json:"decompress" envPrefix:"DECOMPRESS_"`
 	DecompressUpload   TaskConfig `json:"decompress_upload" envPrefix:"DECOMPRESS_UPLOAD_"`
+	Pipeline           TaskConfig `json:"pipeline" envPrefix:"PIPELINE_"`
 	AllowRetryCanceled bool       `json:"allow_retry_canceled" env:"ALLOW_RETRY_CANCELED"`
 }
 
@@ -93,6 +94,30 @@ type SFTP struct {
 	Listen string `json:"listen" env:"LISTEN"`
 }
 
+type Redis struct {
+	Address  string `json:"address" env:"ADDRESS"`
+	Username string `json:"username" env:"USERNAME"`
+	Password string `json:"password" env:"PASSWORD"`
+	DB       int    `json:"db" env:"DB"`
+	Prefix   string `json:"prefix" env:"PREFIX"`
+}
+
+type Cache struct {
+	// Type is memory or redis, the caches are shared by the nodes with redis
+	Type  string `json:"type" env:"TYPE"`
+	Redis Redis  `json:"redis" envPrefix:"REDIS_"`
+}
+
+type Cluster struct {
+	// Enable runs the singleton jobs only on the leader and shares the tasks
+	// able to run on any node, all the nodes must use the same database
+	Enable bool `json:"enable" env:"ENABLE"`
+	// NodeID identifies the node, the hostname is used if it's empty
+	NodeID string `json:"node_id" env:"NODE_ID"`
+	// LeaseTTL is the seconds before the leases of a dead node expire
+	LeaseTTL int `json:"lease_ttl" env:"LEASE_TTL"`
+}
+
 type Config struct {
 	Force                 bool        `json:"force" env:"FORCE"`
 	SiteURL               string      `json:"site_url" env:"SITE_URL"`
@@ -115,6 +140,8 @@ type Config struct {
 	S3                    S3          `json:"s3" envPrefix:"S3_"`
 	FTP                   FTP         `json:"ftp" envPrefix:"FTP_"`
 	SFTP                  SFTP        `json:"sftp" envPrefix:"SFTP_"`
+	Cache                 Cache       `json:"cache" envPrefix:"CACHE_"`
+	Cluster               Cluster     `json:"cluster" envPrefix:"CLUSTER_"`
 	LastLaunchedVersion   string      `json:"last_launched_version"`
 }
 
@@ -184,6 +211,10 @@ func DefaultConfig() *Config {
 				Workers:  5,
 				MaxRetry: 2,
 			},
+			Pipeline: TaskConfig{
+				Workers: 5,
+				// TaskPersistant: true,
+			},
 			AllowRetryCanceled: false,
 		},
 		Cors: Cors{
@@ -212,6 +243,16 @@ func DefaultConfig() *Config {
 			Enable: false,
 			Listen: ":5222",
 		},
+		Cache: Cache{
+			Type: "memory",
+			Redis: Redis{
+				Address: "localhost:6379",
+				Prefix:  "openlist:",
+			},
+		},
+		Cluster: Cluster{
+			LeaseTTL: 15,
+		},
 		LastLaunchedVersion: "",
 	}
 }
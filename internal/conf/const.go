@@ -45,6 +45,11 @@ const (
 	ForwardDirectLinkParams = "forward_direct_link_params"
 	IgnoreDirectLinkParams  = "ignore_direct_link_params"
 	WebauthnLoginEnabled    = "webauthn_login_enabled"
+	LoginMaxFailures        = "login_max_failures"
+	LoginLockDuration       = "login_lock_duration"
+	LoginMaxLockDuration    = "login_max_lock_duration"
+	LoginCaptchaFailures    = "login_captcha_failures"
+	AuditLogRetentionDays   = "audit_log_retention_days"
 
 	// index
 	SearchIndex     = "search_index"
@@ -52,6 +57,10 @@ const (
 	IgnorePaths     = "ignore_paths"
 	MaxIndexDepth   = "max_index_depth"
 
+	// simple http
+	SimpleHttpConcurrency = "simple_http_concurrency"
+	SimpleHttpDirect      = "simple_http_direct"
+
 	// aria2
 	Aria2Uri    = "aria2_uri"
 	Aria2Secret = "aria2_secret"
@@ -60,6 +69,12 @@ const (
 	TransmissionUri      = "transmission_uri"
 	TransmissionSeedtime = "transmission_seedtime"
 
+	// bittorrent
+	BitTorrentPort      = "bittorrent_port"
+	BitTorrentDHT       = "bittorrent_dht"
+	BitTorrentSeedtime  = "bittorrent_seedtime"
+	BitTorrentSeedRatio = "bittorrent_seed_ratio"
+
 	// 115
 	Pan115TempDir = "115_temp_dir"
 
@@ -110,13 +125,18 @@ const (
 	QbittorrentSeedtime = "qbittorrent_seedtime"
 
 	// ftp
-	FTPPublicHost        = "ftp_public_host"
-	FTPPasvPortMap       = "ftp_pasv_port_map"
-	FTPProxyUserAgent    = "ftp_proxy_user_agent"
-	FTPMandatoryTLS      = "ftp_mandatory_tls"
-	FTPImplicitTLS       = "ftp_implicit_tls"
-	FTPTLSPrivateKeyPath = "ftp_tls_private_key_path"
-	FTPTLSPublicCertPath = "ftp_tls_public_cert_path"
+	FTPPublicHost         = "ftp_public_host"
+	FTPPasvPortMap        = "ftp_pasv_port_map"
+	FTPProxyUserAgent     = "ftp_proxy_user_agent"
+	FTPMandatoryTLS       = "ftp_mandatory_tls"
+	FTPImplicitTLS        = "ftp_implicit_tls"
+	FTPTLSPrivateKeyPath  = "ftp_tls_private_key_path"
+	FTPTLSPublicCertPath  = "ftp_tls_public_cert_path"
+	FTPMaxSessionsPerUser = "ftp_max_sessions_per_user"
+	FTPMaxSessionsPerIP   = "ftp_max_sessions_per_ip"
+	FTPUserDownloadSpeed  = "ftp_user_download_speed"
+	FTPUserUploadSpeed    = "ftp_user_upload_speed"
+	FTPTransferLog        = "ftp_transfer_log"
 
 	// traffic
 	TaskOfflineDownloadThreadsNum         = "offline_download_task_threads_num"
@@ -125,6 +145,10 @@ const (
 	TaskCopyThreadsNum                    = "copy_task_threads_num"
 	TaskDecompressDownloadThreadsNum      = "decompress_download_task_threads_num"
 	TaskDecompressUploadThreadsNum        = "decompress_upload_task_threads_num"
+	TaskHistoryRetentionDays              = "task_history_retention_days"
+	TaskHistoryMaxCount                   = "task_history_max_count"
+	TaskMaxRunningPerUser                 = "task_max_running_per_user"
+	TaskMaxRunningPerStorage              = "task_max_running_per_storage"
 	StreamMaxClientDownloadSpeed          = "max_client_download_speed"
 	StreamMaxClientUploadSpeed            = "max_client_upload_speed"
 	StreamMaxServerDownloadSpeed          = "max_server_download_speed"
@@ -143,5 +167,7 @@ const (
 
 // ContextKey is the type of context keys.
 const (
-	NoTaskKey = "no_task"
+	NoTaskKey       = "no_task"
+	TaskPriorityKey = "task_priority"
+	TaskGroupKey    = "task_group"
 )
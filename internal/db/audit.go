@@ -0,0 +1,31 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateAuditLog(l *model.AuditLog) error {
+	return errors.WithStack(db.Create(l).Error)
+}
+
+// GetAuditLogs returns the audit logs from the newest, empty action
+// or username matches all
+func GetAuditLogs(pageIndex, pageSize int, action, username string) (logs []model.AuditLog, count int64, err error) {
+	logDB := db.Model(&model.AuditLog{})
+	query := model.AuditLog{Action: action, Username: username}
+	if err := logDB.Where(query).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get audit logs count")
+	}
+	if err := logDB.Where(query).Order(columnName("id") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find audit logs")
+	}
+	return logs, count, nil
+}
+
+// DeleteAuditLogsBefore deletes the audit logs older than the time
+func DeleteAuditLogsBefore(before time.Time) error {
+	return errors.WithStack(db.Where(columnName("time")+" < ?", before).Delete(&model.AuditLog{}).Error)
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/model"
+)
+
+func TestDeleteAuditLogsBefore(t *testing.T) {
+	Init(dbtest.Open(t))
+	now := time.Now()
+	for _, d := range []int{-100, -10, 0} {
+		if err := CreateAuditLog(&model.AuditLog{Time: now.AddDate(0, 0, d), Action: model.AuditLoginFailed}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := DeleteAuditLogsBefore(now.AddDate(0, 0, -30)); err != nil {
+		t.Fatal(err)
+	}
+	logs, count, err := GetAuditLogs(1, 10, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 || len(logs) != 2 {
+		t.Fatalf("expected the 2 logs in the retention kept, got %+v", logs)
+	}
+}
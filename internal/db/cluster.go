@@ -0,0 +1,61 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// unixNow is the current unix time of the database in milliseconds
+func unixNow() string {
+	switch conf.Conf.Database.Type {
+	case "mysql":
+		return "CAST(UNIX_TIMESTAMP(NOW(3)) * 1000 AS SIGNED)"
+	case "postgres":
+		return "CAST(EXTRACT(EPOCH FROM CLOCK_TIMESTAMP()) * 1000 AS BIGINT)"
+	default:
+		return "CAST((julianday('now') - 2440587.5) * 86400000 AS INTEGER)"
+	}
+}
+
+// AcquireLease takes the lease if it's free or expired, or renews it if it's
+// held by the holder, false means another holder has the lease
+func AcquireLease(name, holder string, ttl time.Duration) (bool, error) {
+	expiresAt := gorm.Expr(unixNow()+" + ?", ttl.Milliseconds())
+	res := db.Model(&model.ClusterLease{}).
+		Where("name = ? AND (holder = ? OR expires_at < "+unixNow()+")", name, holder).
+		Updates(map[string]any{"holder": holder, "expires_at": expiresAt})
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	if res.RowsAffected > 0 {
+		return true, nil
+	}
+	res = db.Model(&model.ClusterLease{}).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(map[string]any{"name": name, "holder": holder, "expires_at": expiresAt})
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// ReleaseLease deletes the lease if it's held by the holder
+func ReleaseLease(name, holder string) error {
+	return errors.WithStack(db.Where("name = ? AND holder = ?", name, holder).Delete(&model.ClusterLease{}).Error)
+}
+
+// GetLeaseHolder returns the holder of the lease, empty if it's free or expired
+func GetLeaseHolder(name string) (string, error) {
+	var leases []model.ClusterLease
+	if err := db.Where("name = ? AND expires_at >= "+unixNow(), name).Limit(1).Find(&leases).Error; err != nil {
+		return "", errors.WithStack(err)
+	}
+	if len(leases) == 0 {
+		return "", nil
+	}
+	return leases[0].Holder, nil
+}
@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.Task), new(model.SSHPublicKey), new(model.WebdavLock), new(model.WebdavProp), new(model.AuditLog), new(model.Session), new(model.HashCache), new(model.Subscription), new(model.SubscriptionItem), new(model.ClusterLease))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
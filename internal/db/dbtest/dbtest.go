@@ -0,0 +1,28 @@
+// Package dbtest opens the in-memory database of the tests.
+package dbtest
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Open opens a new empty in-memory database with the default config, it's
+// passed to db.Init by the test
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	dB, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %v", err)
+	}
+	// every connection to file::memory: opens a new empty database
+	sqlDB, err := dB.DB()
+	if err != nil {
+		t.Fatalf("failed to get the connection pool: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	conf.Conf = conf.DefaultConfig()
+	return dB
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"fmt"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// GetHashCaches returns the hash caches of the paths in the storage
+func GetHashCaches(storageID uint, paths []string) ([]model.HashCache, error) {
+	var caches []model.HashCache
+	for i := 0; i < len(paths); i += 500 {
+		var batch []model.HashCache
+		err := db.Where(fmt.Sprintf("%s = ? AND %s IN ?", columnName("storage_id"), columnName("path")),
+			storageID, paths[i:min(i+500, len(paths))]).Find(&batch).Error
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed get hash caches")
+		}
+		caches = append(caches, batch...)
+	}
+	return caches, nil
+}
+
+// SaveHashCache replaces the hash cache of the path
+func SaveHashCache(h *model.HashCache) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where(fmt.Sprintf("%s = ? AND %s = ?", columnName("storage_id"), columnName("path")),
+			h.StorageID, h.Path).Delete(&model.HashCache{}).Error
+		if err != nil {
+			return err
+		}
+		h.ID = 0
+		return tx.Create(h).Error
+	}))
+}
+
+// GetHashCachesUnder returns the hash caches of path and its descendants
+func GetHashCachesUnder(storageID uint, path string) ([]model.HashCache, error) {
+	var caches []model.HashCache
+	err := db.Where(fmt.Sprintf("%s = ?", columnName("storage_id")), storageID).
+		Where(whereSelfOrUnder("path", path)).Find(&caches).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed get hash caches")
+	}
+	return caches, nil
+}
+
+// MoveHashCaches moves the hash caches of src and its descendants to dst
+func MoveHashCaches(storageID uint, src, dst string) error {
+	caches, err := GetHashCachesUnder(storageID, src)
+	if err != nil {
+		return err
+	}
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where(fmt.Sprintf("%s = ?", columnName("storage_id")), storageID).
+			Where(whereSelfOrUnder("path", dst)).Delete(&model.HashCache{}).Error
+		if err != nil {
+			return err
+		}
+		for _, h := range caches {
+			newPath := stdpath.Join(dst, strings.TrimPrefix(h.Path, src))
+			if err := tx.Model(&model.HashCache{}).Where(fmt.Sprintf("%s = ?", columnName("id")), h.ID).Update("path", newPath).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// DeleteHashCaches deletes the hash caches of path and its descendants
+func DeleteHashCaches(storageID uint, path string) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ?", columnName("storage_id")), storageID).
+		Where(whereSelfOrUnder("path", path)).Delete(&model.HashCache{}).Error)
+}
+
+func DeleteHashCachesByStorageID(storageID uint) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ?", columnName("storage_id")), storageID).
+		Delete(&model.HashCache{}).Error)
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/model"
+)
+
+func TestHashCachesWildcards(t *testing.T) {
+	Init(dbtest.Open(t))
+	for _, path := range []string{"/a_b/f", "/aXb/f", "/c%d/f", "/cXd/f"} {
+		if err := SaveHashCache(&model.HashCache{StorageID: 1, Path: path, Hash: path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := MoveHashCaches(1, "/a_b", "/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteHashCaches(1, "/c%d"); err != nil {
+		t.Fatal(err)
+	}
+	var caches []model.HashCache
+	if err := db.Order("path").Find(&caches).Error; err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, h := range caches {
+		paths = append(paths, h.Path)
+	}
+	expected := []string{"/aXb/f", "/cXd/f", "/moved/f"}
+	if len(paths) != len(expected) {
+		t.Fatalf("unexpected hash caches: %v", paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Fatalf("unexpected hash caches: %v", paths)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateSession(s *model.Session) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func GetSessionByID(id string) (*model.Session, error) {
+	var s model.Session
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("id")), id).First(&s).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get session")
+	}
+	return &s, nil
+}
+
+func GetSessionsByUserID(userID uint) ([]model.Session, error) {
+	var sessions []model.Session
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("user_id")), userID).Order(columnName("last_active") + " desc").Find(&sessions).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find sessions of user")
+	}
+	return sessions, nil
+}
+
+func UpdateSessionActivity(id, ip string, lastActive time.Time) error {
+	return errors.WithStack(db.Model(&model.Session{}).Where(fmt.Sprintf("%s = ?", columnName("id")), id).
+		Updates(map[string]any{"ip": ip, "last_active": lastActive}).Error)
+}
+
+func DeleteSessionByID(id string) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ?", columnName("id")), id).Delete(&model.Session{}).Error)
+}
+
+func DeleteSessionsByUserID(userID uint) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ?", columnName("user_id")), userID).Delete(&model.Session{}).Error)
+}
+
+func DeleteExpiredSessions(now time.Time) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s < ?", columnName("expires_at")), now).Delete(&model.Session{}).Error)
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetSubscriptionById(id uint) (*model.Subscription, error) {
+	var s model.Subscription
+	if err := db.First(&s, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get subscription")
+	}
+	return &s, nil
+}
+
+// GetSubscriptions returns the subscriptions of the user, zero user id
+// returns the ones of all the users
+func GetSubscriptions(userId uint, pageIndex, pageSize int) (subs []model.Subscription, count int64, err error) {
+	subDB := db.Model(&model.Subscription{})
+	query := model.Subscription{UserID: userId}
+	if err := subDB.Where(query).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get subscriptions count")
+	}
+	if err := subDB.Where(query).Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&subs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find subscriptions")
+	}
+	return subs, count, nil
+}
+
+func GetEnabledSubscriptions() (subs []model.Subscription, err error) {
+	if err := db.Where(columnName("disabled")+" = ?", false).Find(&subs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find enabled subscriptions")
+	}
+	return subs, nil
+}
+
+func CreateSubscription(s *model.Subscription) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+func UpdateSubscription(s *model.Subscription) error {
+	return errors.WithStack(db.Save(s).Error)
+}
+
+// UpdateSubscriptionFetched only updates the fetch result so that the
+// changes of the other fields during the fetch are kept
+func UpdateSubscriptionFetched(s *model.Subscription) error {
+	return errors.WithStack(db.Model(s).Select("Initialized", "LastFetched", "LastError").Updates(s).Error)
+}
+
+// DeleteSubscriptionById deletes the subscription with its fetch history
+func DeleteSubscriptionById(id uint) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(columnName("subscription_id")+" = ?", id).Delete(&model.SubscriptionItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Subscription{}, id).Error
+	}))
+}
+
+// GetSubscriptionItemsByGUIDs returns the fetched items of the subscription
+// by their guids
+func GetSubscriptionItemsByGUIDs(subscriptionId uint, guids []string) (items []model.SubscriptionItem, err error) {
+	if len(guids) == 0 {
+		return nil, nil
+	}
+	if err := db.Where(columnName("subscription_id")+" = ? AND "+columnName("guid")+" IN ?", subscriptionId, guids).
+		Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find subscription items")
+	}
+	return items, nil
+}
+
+func SaveSubscriptionItem(item *model.SubscriptionItem) error {
+	return errors.WithStack(db.Save(item).Error)
+}
+
+// GetSubscriptionItems returns the fetch history of the subscription from
+// the newest
+func GetSubscriptionItems(subscriptionId uint, pageIndex, pageSize int) (items []model.SubscriptionItem, count int64, err error) {
+	itemDB := db.Model(&model.SubscriptionItem{})
+	query := model.SubscriptionItem{SubscriptionID: subscriptionId}
+	if err := itemDB.Where(query).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get subscription items count")
+	}
+	if err := itemDB.Where(query).Order(columnName("id") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find subscription items")
+	}
+	return items, count, nil
+}
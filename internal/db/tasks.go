@@ -1,8 +1,12 @@
 package db
 
 import (
+	"time"
+
 	"github.com/OpenListTeam/OpenList/internal/model"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func GetTaskDataByType(type_s string) (*model.TaskItem, error) {
@@ -17,32 +21,131 @@ func UpdateTaskData(t *model.TaskItem) error {
 	return errors.WithStack(db.Model(&model.TaskItem{}).Where("key = ?", t.Key).Update("persist_data", t.PersistData).Error)
 }
 
-func CreateTaskData(t *model.TaskItem) error {
-	return errors.WithStack(db.Create(t).Error)
+// SaveTask creates or updates the row of the task
+func SaveTask(t *model.Task) error {
+	return errors.WithStack(db.Save(t).Error)
+}
+
+// GetTasksByType returns the tasks of the type that haven't been removed
+func GetTasksByType(taskType string) ([]model.Task, error) {
+	var tasks []model.Task
+	if err := db.Where("type = ? AND removed = ?", taskType, false).Order(columnName("created_at")).Find(&tasks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find tasks")
+	}
+	return tasks, nil
 }
 
-func GetTaskDataFunc(type_s string, enabled bool) func() ([]byte, error) {
-	if !enabled {
+// RemoveTasks marks the tasks as removed, they are only kept as history
+func RemoveTasks(ids []string) error {
+	if len(ids) == 0 {
 		return nil
 	}
-	task, err := GetTaskDataByType(type_s)
+	return errors.WithStack(db.Model(&model.Task{}).Where("id IN ?", ids).Update("removed", true).Error)
+}
+
+// InterruptTasks marks the tasks of the type as removed, and the unfinished
+// ones as failed state with the error message, only the tasks claimed by
+// the node are interrupted if the node isn't empty
+func InterruptTasks(taskType, node string, finishedStates []int, failedState int, errMsg string) error {
+	taskDB := func() *gorm.DB {
+		q := db.Model(&model.Task{}).Where("type = ?", taskType)
+		if node != "" {
+			q = q.Where("node = ?", node)
+		}
+		return q
+	}
+	err := taskDB().Where("removed = ? AND state NOT IN ?", false, finishedStates).
+		Updates(map[string]any{"state": failedState, "error": errMsg}).Error
 	if err != nil {
-		return nil
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(taskDB().Update("removed", true).Error)
+}
+
+// SaveClaimedTask saves the task only if its row is still claimed by the node,
+// or creates the row if it doesn't exist, false means another node has
+// claimed the task
+func SaveClaimedTask(t *model.Task, node string) (bool, error) {
+	res := db.Model(&model.Task{}).Where("id = ? AND node = ?", t.ID, node).Select("*").Updates(t)
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
 	}
-	return func() ([]byte, error) {
-		return []byte(task.PersistData), nil
+	if res.RowsAffected > 0 {
+		return true, nil
 	}
+	res = db.Clauses(clause.OnConflict{DoNothing: true}).Create(t)
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected > 0, nil
 }
 
-func UpdateTaskDataFunc(type_s string, enabled bool) func([]byte) error {
-	if !enabled {
-		return nil
+// GetOtherNodesTasks returns the unfinished tasks of the type which aren't
+// claimed by the node
+func GetOtherNodesTasks(taskType, node string, finishedStates []int) ([]model.Task, error) {
+	var tasks []model.Task
+	err := db.Where("type = ? AND removed = ? AND node <> ? AND state NOT IN ?",
+		taskType, false, node, finishedStates).
+		Order(columnName("created_at")).Find(&tasks).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed find tasks")
+	}
+	return tasks, nil
+}
+
+// GetTasks returns the tasks matching the query from the newest
+func GetTasks(q model.TaskQuery) (tasks []model.Task, count int64, err error) {
+	taskDB := db.Model(&model.Task{})
+	if q.Type != "" {
+		taskDB = taskDB.Where("type = ?", q.Type)
+	}
+	if q.CreatorID != 0 {
+		taskDB = taskDB.Where("creator_id = ?", q.CreatorID)
+	}
+	if q.Creator != "" {
+		taskDB = taskDB.Where("creator = ?", q.Creator)
+	}
+	if len(q.States) > 0 {
+		taskDB = taskDB.Where("state IN ?", q.States)
+	}
+	if q.Keyword != "" {
+		taskDB = taskDB.Where(columnName("name")+" LIKE ? ESCAPE '!'", "%"+escapeLike(q.Keyword)+"%")
+	}
+	if q.Since > 0 {
+		taskDB = taskDB.Where(columnName("created_at")+" >= ?", time.Unix(q.Since, 0))
+	}
+	if q.Until > 0 {
+		taskDB = taskDB.Where(columnName("created_at")+" < ?", time.Unix(q.Until, 0))
+	}
+	if err := taskDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get tasks count")
+	}
+	if err := taskDB.Order(columnName("created_at") + " desc").Offset((q.Page - 1) * q.PerPage).Limit(q.PerPage).Find(&tasks).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find tasks")
+	}
+	return tasks, count, nil
+}
+
+// DeleteTasksBefore deletes the tasks in the states which are updated before the time
+func DeleteTasksBefore(states []int, before time.Time) error {
+	return errors.WithStack(db.Where("state IN ? AND "+columnName("updated_at")+" < ?", states, before).Delete(&model.Task{}).Error)
+}
+
+// DeleteTasksExceeding deletes the oldest tasks in the states except the newest keep ones
+func DeleteTasksExceeding(states []int, keep int) error {
+	var ids []string
+	err := db.Model(&model.Task{}).Where("state IN ?", states).Order(columnName("updated_at")+" desc").
+		Offset(keep).Limit(model.MaxInt).Pluck("id", &ids).Error
+	if err != nil {
+		return errors.WithStack(err)
 	}
-	return func(data []byte) error {
-		s := string(data)
-		if s == "null" || s == "" {
-			s = "[]"
+	for len(ids) > 0 {
+		// keep the number of the sql variables small
+		n := min(len(ids), 500)
+		if err := db.Where("id IN ?", ids[:n]).Delete(&model.Task{}).Error; err != nil {
+			return errors.WithStack(err)
 		}
-		return UpdateTaskData(&model.TaskItem{Key: type_s, PersistData: s})
+		ids = ids[n:]
 	}
+	return nil
 }
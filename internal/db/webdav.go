@@ -0,0 +1,154 @@
+package db
+
+import (
+	"fmt"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func whereSelfOrUnder(column, path string) *gorm.DB {
+	if path == "/" {
+		return db.Where("1 = 1")
+	}
+	return db.Where(fmt.Sprintf("%s = ?", columnName(column)), path).
+		Or(whereUnder(column, path+"/"))
+}
+
+// whereUnder matches the values starting with the prefix, the wildcards in
+// the prefix are escaped by "!" which means the same in all the databases
+func whereUnder(column, prefix string) *gorm.DB {
+	return db.Where(fmt.Sprintf("%s LIKE ? ESCAPE '!'", columnName(column)), escapeLike(prefix)+"%")
+}
+
+var likeReplacer = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func escapeLike(s string) string {
+	return likeReplacer.Replace(s)
+}
+
+func GetWebdavLockByToken(token string) (*model.WebdavLock, error) {
+	var l model.WebdavLock
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("token")), token).First(&l).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed get webdav lock")
+	}
+	return &l, nil
+}
+
+// GetWebdavLocksByRoots returns the locks whose root is one of roots
+func GetWebdavLocksByRoots(roots []string) ([]model.WebdavLock, error) {
+	var locks []model.WebdavLock
+	if err := db.Where(fmt.Sprintf("%s IN ?", columnName("root")), roots).Find(&locks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webdav locks")
+	}
+	return locks, nil
+}
+
+// GetWebdavLocksUnder returns the locks on the descendants of root
+func GetWebdavLocksUnder(root string) ([]model.WebdavLock, error) {
+	var locks []model.WebdavLock
+	prefix := strings.TrimSuffix(root, "/") + "/"
+	if err := db.Where(whereUnder("root", prefix)).Find(&locks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webdav locks")
+	}
+	return locks, nil
+}
+
+func CreateWebdavLock(l *model.WebdavLock) error {
+	return errors.WithStack(db.Create(l).Error)
+}
+
+func UpdateWebdavLock(l *model.WebdavLock) error {
+	return errors.WithStack(db.Save(l).Error)
+}
+
+func DeleteWebdavLock(token string) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ?", columnName("token")), token).Delete(&model.WebdavLock{}).Error)
+}
+
+func DeleteExpiredWebdavLocks(now int64) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s > 0 AND %s <= ?", columnName("expiry"), columnName("expiry")), now).
+		Delete(&model.WebdavLock{}).Error)
+}
+
+func GetWebdavProps(path string) ([]model.WebdavProp, error) {
+	var props []model.WebdavProp
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("path")), path).Find(&props).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webdav props")
+	}
+	return props, nil
+}
+
+// PatchWebdavProps sets and removes the dead props of path in one transaction
+func PatchWebdavProps(path string, set []model.WebdavProp, remove []model.WebdavProp) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		for _, p := range append(set, remove...) {
+			err := tx.Where(fmt.Sprintf("%s = ? AND %s = ? AND %s = ?",
+				columnName("path"), columnName("space"), columnName("local")),
+				path, p.Space, p.Local).Delete(&model.WebdavProp{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		for _, p := range set {
+			p.ID = 0
+			p.Path = path
+			if err := tx.Create(&p).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// CopyWebdavProps copies the dead props of src and its descendants to dst
+func CopyWebdavProps(src, dst string) error {
+	var props []model.WebdavProp
+	if err := db.Where(whereSelfOrUnder("path", src)).Find(&props).Error; err != nil {
+		return errors.Wrapf(err, "failed get webdav props")
+	}
+	if len(props) == 0 {
+		return nil
+	}
+	for i := range props {
+		props[i].ID = 0
+		props[i].Path = stdpath.Join(dst, strings.TrimPrefix(props[i].Path, src))
+	}
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(whereSelfOrUnder("path", dst)).Delete(&model.WebdavProp{}).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(&props, 100).Error
+	}))
+}
+
+// MoveWebdavProps moves the dead props of src and its descendants to dst
+func MoveWebdavProps(src, dst string) error {
+	var props []model.WebdavProp
+	if err := db.Where(whereSelfOrUnder("path", src)).Find(&props).Error; err != nil {
+		return errors.Wrapf(err, "failed get webdav props")
+	}
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(whereSelfOrUnder("path", dst)).Delete(&model.WebdavProp{}).Error; err != nil {
+			return err
+		}
+		for _, p := range props {
+			newPath := stdpath.Join(dst, strings.TrimPrefix(p.Path, src))
+			if err := tx.Model(&model.WebdavProp{}).Where("id = ?", p.ID).Update("path", newPath).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// DeleteWebdavProps deletes the dead props of path and its descendants
+func DeleteWebdavProps(path string) error {
+	return errors.WithStack(db.Where(whereSelfOrUnder("path", path)).Delete(&model.WebdavProp{}).Error)
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/model"
+)
+
+func propPaths(t *testing.T) map[string]bool {
+	t.Helper()
+	var props []model.WebdavProp
+	if err := db.Find(&props).Error; err != nil {
+		t.Fatal(err)
+	}
+	paths := make(map[string]bool)
+	for _, p := range props {
+		paths[p.Path] = true
+	}
+	return paths
+}
+
+func TestWebdavPropsWildcards(t *testing.T) {
+	Init(dbtest.Open(t))
+	for _, path := range []string{"/a_b", "/a_b/c", "/aXb/c", "/a%b/c", "/a%bc/d"} {
+		if err := PatchWebdavProps(path, []model.WebdavProp{{Space: "ns", Local: "p", InnerXML: path}}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := MoveWebdavProps("/a_b", "/moved"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CopyWebdavProps("/a%b", "/copied"); err != nil {
+		t.Fatal(err)
+	}
+	if err := DeleteWebdavProps("/a%bc"); err != nil {
+		t.Fatal(err)
+	}
+	paths := propPaths(t)
+	for _, path := range []string{"/moved", "/moved/c", "/aXb/c", "/a%b/c", "/copied/c"} {
+		if !paths[path] {
+			t.Errorf("expected the props of %s, got %v", path, paths)
+		}
+	}
+	for _, path := range []string{"/a_b", "/a_b/c", "/moved/Xb/c", "/copied/c/d", "/a%bc/d"} {
+		if paths[path] {
+			t.Errorf("unexpected the props of %s", path)
+		}
+	}
+
+	for _, root := range []string{"/l_k/x", "/lXk/x"} {
+		if err := CreateWebdavLock(&model.WebdavLock{Token: root, Root: root}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	locks, err := GetWebdavLocksUnder("/l_k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locks) != 1 || locks[0].Root != "/l_k/x" {
+		t.Fatalf("unexpected locks: %+v", locks)
+	}
+}
@@ -29,6 +29,20 @@ type Other interface {
 	Other(ctx context.Context, args model.OtherArgs) (interface{}, error)
 }
 
+// WithDetails is implemented by the storages that can report their disk usage
+type WithDetails interface {
+	GetDetails(ctx context.Context) (*model.StorageDetails, error)
+}
+
+// Versioner is implemented by the storages that keep the previous revisions of overwritten files
+type Versioner interface {
+	// ListVersions returns the previous revisions of the file, newest first
+	ListVersions(ctx context.Context, file model.Obj) ([]model.Obj, error)
+	// RestoreVersion makes the revision the current content of the file,
+	// the current content is kept as a new revision
+	RestoreVersion(ctx context.Context, file model.Obj, version string) error
+}
+
 type Reader interface {
 	// List files in the path
 	// if identify files by path, need to set ID with path,like path.Join(dir.GetID(), obj.GetName())
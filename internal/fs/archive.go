@@ -48,6 +48,10 @@ func (t *ArchiveDownloadTask) GetStatus() string {
 	return t.status
 }
 
+func (t *ArchiveDownloadTask) GetStorages() []string {
+	return []string{t.SrcStorageMp, t.DstStorageMp}
+}
+
 func (t *ArchiveDownloadTask) Run() error {
 	t.ReinitCtx()
 	t.ClearEndTime()
@@ -117,7 +121,9 @@ func (t *ArchiveDownloadTask) RunWithoutPushUploadTask() (*ArchiveContentUploadT
 	baseName := strings.TrimSuffix(srcObj.GetName(), stdpath.Ext(srcObj.GetName()))
 	uploadTask := &ArchiveContentUploadTask{
 		TaskExtension: task.TaskExtension{
-			Creator: t.GetCreator(),
+			Creator:  t.GetCreator(),
+			Priority: t.GetPriority(),
+			Group:    t.GetGroup(),
 		},
 		ObjName:      baseName,
 		InPlace:      !t.PutIntoNewDir,
@@ -129,7 +135,7 @@ func (t *ArchiveDownloadTask) RunWithoutPushUploadTask() (*ArchiveContentUploadT
 	return uploadTask, nil
 }
 
-var ArchiveDownloadTaskManager *tache.Manager[*ArchiveDownloadTask]
+var ArchiveDownloadTaskManager *task.Scheduler[*ArchiveDownloadTask]
 
 type ArchiveContentUploadTask struct {
 	task.TaskExtension
@@ -151,6 +157,10 @@ func (t *ArchiveContentUploadTask) GetStatus() string {
 	return t.status
 }
 
+func (t *ArchiveContentUploadTask) GetStorages() []string {
+	return []string{t.DstStorageMp}
+}
+
 func (t *ArchiveContentUploadTask) Run() error {
 	t.ReinitCtx()
 	t.ClearEndTime()
@@ -199,7 +209,9 @@ func (t *ArchiveContentUploadTask) RunWithNextTaskCallback(f func(nextTsk *Archi
 			}
 			err = f(&ArchiveContentUploadTask{
 				TaskExtension: task.TaskExtension{
-					Creator: t.GetCreator(),
+					Creator:  t.GetCreator(),
+					Priority: t.GetPriority(),
+					Group:    t.GetGroup(),
 				},
 				ObjName:      entry.Name(),
 				InPlace:      false,
@@ -285,13 +297,13 @@ func genTempFileName(prefix string) (string, error) {
 }
 
 type archiveContentUploadTaskManagerType struct {
-	*tache.Manager[*ArchiveContentUploadTask]
+	*task.Scheduler[*ArchiveContentUploadTask]
 }
 
 func (m *archiveContentUploadTaskManagerType) Remove(id string) {
 	if t, ok := m.GetByID(id); ok {
 		t.deleteSrcFile()
-		m.Manager.Remove(id)
+		m.Scheduler.Remove(id)
 	}
 }
 
@@ -317,7 +329,7 @@ func (m *archiveContentUploadTaskManagerType) RemoveByCondition(condition func(t
 }
 
 var ArchiveContentUploadTaskManager = &archiveContentUploadTaskManagerType{
-	Manager: nil,
+	Scheduler: nil,
 }
 
 func archiveMeta(ctx context.Context, path string, args model.ArchiveMetaArgs) (*model.ArchiveMetaProvider, error) {
@@ -354,7 +366,9 @@ func archiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 	taskCreator, _ := ctx.Value("user").(*model.User)
 	tsk := &ArchiveDownloadTask{
 		TaskExtension: task.TaskExtension{
-			Creator: taskCreator,
+			Creator:  taskCreator,
+			Priority: task.PriorityFromCtx(ctx),
+			Group:    task.GroupFromCtx(ctx),
 		},
 		ArchiveDecompressArgs: args,
 		srcStorage:            srcStorage,
@@ -17,7 +17,6 @@ import (
 	"github.com/OpenListTeam/OpenList/internal/task"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
 	"github.com/pkg/errors"
-	"github.com/xhofe/tache"
 )
 
 type CopyTask struct {
@@ -39,6 +38,16 @@ func (t *CopyTask) GetStatus() string {
 	return t.Status
 }
 
+func (t *CopyTask) GetStorages() []string {
+	return []string{t.SrcStorageMp, t.DstStorageMp}
+}
+
+// Distributable allows the task to run on any node of the cluster unless it
+// belongs to a group, which is waited on the node that creates it
+func (t *CopyTask) Distributable() bool {
+	return t.Group == ""
+}
+
 func (t *CopyTask) Run() error {
 	t.ReinitCtx()
 	t.ClearEndTime()
@@ -57,7 +66,7 @@ func (t *CopyTask) Run() error {
 	return copyBetween2Storages(t, t.srcStorage, t.dstStorage, t.SrcObjPath, t.DstDirPath)
 }
 
-var CopyTaskManager *tache.Manager[*CopyTask]
+var CopyTaskManager *task.Scheduler[*CopyTask]
 
 // Copy if in the same storage, call move method
 // if not, add copy task
@@ -106,7 +115,9 @@ func _copy(ctx context.Context, srcObjPath, dstDirPath string, lazyCache ...bool
 	taskCreator, _ := ctx.Value("user").(*model.User)
 	t := &CopyTask{
 		TaskExtension: task.TaskExtension{
-			Creator: taskCreator,
+			Creator:  taskCreator,
+			Priority: task.PriorityFromCtx(ctx),
+			Group:    task.GroupFromCtx(ctx),
 		},
 		srcStorage:   srcStorage,
 		dstStorage:   dstStorage,
@@ -139,7 +150,9 @@ func copyBetween2Storages(t *CopyTask, srcStorage, dstStorage driver.Driver, src
 			dstObjPath := stdpath.Join(dstDirPath, srcObj.GetName())
 			CopyTaskManager.Add(&CopyTask{
 				TaskExtension: task.TaskExtension{
-					Creator: t.GetCreator(),
+					Creator:  t.GetCreator(),
+					Priority: t.GetPriority(),
+					Group:    t.GetGroup(),
 				},
 				srcStorage:   srcStorage,
 				dstStorage:   dstStorage,
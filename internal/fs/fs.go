@@ -188,3 +188,21 @@ func PutURL(ctx context.Context, path, dstName, urlStr string) error {
 	}
 	return op.PutURL(ctx, storage, dstDirActualPath, dstName, urlStr)
 }
+
+// ListVersions lists the previous revisions of the file, the paths of the
+// returned objs are full paths
+func ListVersions(ctx context.Context, path string) ([]model.Obj, error) {
+	res, err := listVersions(ctx, path)
+	if err != nil {
+		log.Errorf("failed list versions %s: %+v", path, err)
+	}
+	return res, err
+}
+
+func RestoreVersion(ctx context.Context, path, version string) error {
+	err := restoreVersion(ctx, path, version)
+	if err != nil {
+		log.Errorf("failed restore version %s of %s: %+v", version, path, err)
+	}
+	return err
+}
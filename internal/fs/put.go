@@ -3,40 +3,84 @@ package fs
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/internal/driver"
 	"github.com/OpenListTeam/OpenList/internal/errs"
 	"github.com/OpenListTeam/OpenList/internal/model"
 	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/stream"
 	"github.com/OpenListTeam/OpenList/internal/task"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
 	"github.com/pkg/errors"
-	"github.com/xhofe/tache"
 )
 
 type UploadTask struct {
 	task.TaskExtension
-	storage          driver.Driver
-	dstDirActualPath string
-	file             model.FileStreamer
+	storage          driver.Driver      `json:"-"`
+	file             model.FileStreamer `json:"-"`
+	DstStorageMp     string             `json:"dst_storage_mp"`
+	DstDirActualPath string             `json:"dst_path"`
+	FileName         string             `json:"file_name"`
+	FileSize         int64              `json:"file_size"`
+	Mimetype         string             `json:"mimetype"`
+	Modified         time.Time          `json:"modified"`
+	// TempFilePath is the file caching the content, the task is recovered from it
+	TempFilePath string `json:"temp_file_path"`
 }
 
 func (t *UploadTask) GetName() string {
-	return fmt.Sprintf("upload %s to [%s](%s)", t.file.GetName(), t.storage.GetStorage().MountPath, t.dstDirActualPath)
+	return fmt.Sprintf("upload %s to [%s](%s)", t.FileName, t.DstStorageMp, t.DstDirActualPath)
 }
 
 func (t *UploadTask) GetStatus() string {
 	return "uploading"
 }
 
+func (t *UploadTask) GetStorages() []string {
+	return []string{t.DstStorageMp}
+}
+
+// Recoverable reports whether the content of the unfinished task is still cached
+func (t *UploadTask) Recoverable() bool {
+	return utils.SliceContains(task.FinishedStates, int(t.GetState())) ||
+		(t.TempFilePath != "" && utils.Exists(t.TempFilePath))
+}
+
 func (t *UploadTask) Run() error {
 	t.ClearEndTime()
 	t.SetStartTime(time.Now())
 	defer func() { t.SetEndTime(time.Now()) }()
-	return op.Put(t.Ctx(), t.storage, t.dstDirActualPath, t.file, t.SetProgress, true)
+	var err error
+	if t.storage == nil {
+		t.storage, err = op.GetStorageByMountPath(t.DstStorageMp)
+		if err != nil {
+			return errors.WithMessage(err, "failed get storage")
+		}
+	}
+	if t.file == nil {
+		// the task is recovered, reopen the cached content
+		file, err := os.Open(t.TempFilePath)
+		if err != nil {
+			return err
+		}
+		fs := &stream.FileStream{
+			Obj: &model.Object{
+				Name:     t.FileName,
+				Size:     t.FileSize,
+				Modified: t.Modified,
+			},
+			Mimetype:     t.Mimetype,
+			WebPutAsTask: true,
+		}
+		fs.SetTmpFile(file)
+		t.file = fs
+	}
+	return op.Put(t.Ctx(), t.storage, t.DstDirActualPath, t.file, t.SetProgress, true)
 }
 
-var UploadTaskManager *tache.Manager[*UploadTask]
+var UploadTaskManager *task.Scheduler[*UploadTask]
 
 // putAsTask add as a put task and return immediately
 func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) (task.TaskExtensionInfo, error) {
@@ -47,22 +91,34 @@ func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 	if storage.Config().NoUpload {
 		return nil, errors.WithStack(errs.UploadNotSupported)
 	}
+	tempFilePath := ""
 	if file.NeedStore() {
-		_, err := file.CacheFullInTempFile()
+		tempFile, err := file.CacheFullInTempFile()
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to create temp file")
 		}
 		//file.SetReader(tempFile)
 		//file.SetTmpFile(tempFile)
+		if f, ok := tempFile.(*os.File); ok {
+			tempFilePath = f.Name()
+		}
 	}
 	taskCreator, _ := ctx.Value("user").(*model.User) // taskCreator is nil when convert failed
 	t := &UploadTask{
 		TaskExtension: task.TaskExtension{
-			Creator: taskCreator,
+			Creator:  taskCreator,
+			Priority: task.PriorityFromCtx(ctx),
+			Group:    task.GroupFromCtx(ctx),
 		},
 		storage:          storage,
-		dstDirActualPath: dstDirActualPath,
 		file:             file,
+		DstStorageMp:     storage.GetStorage().MountPath,
+		DstDirActualPath: dstDirActualPath,
+		FileName:         file.GetName(),
+		FileSize:         file.GetSize(),
+		Mimetype:         file.GetMimetype(),
+		Modified:         file.ModTime(),
+		TempFilePath:     tempFilePath,
 	}
 	t.SetTotalBytes(file.GetSize())
 	UploadTaskManager.Add(t)
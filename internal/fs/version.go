@@ -0,0 +1,41 @@
+package fs
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/pkg/errors"
+)
+
+func listVersions(ctx context.Context, path string) ([]model.Obj, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	versions, err := op.ListVersions(ctx, storage, actualPath)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.Obj, 0, len(versions))
+	for _, v := range versions {
+		res = append(res, &model.Object{
+			Path:     stdpath.Join(storage.GetStorage().MountPath, v.GetPath()),
+			Name:     v.GetName(),
+			Size:     v.GetSize(),
+			Modified: v.ModTime(),
+			Ctime:    v.CreateTime(),
+			HashInfo: v.GetHash(),
+		})
+	}
+	return res, nil
+}
+
+func restoreVersion(ctx context.Context, path, version string) error {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	return op.RestoreVersion(ctx, storage, actualPath, version)
+}
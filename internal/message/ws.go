@@ -1,3 +1,169 @@
 package message
 
-// TODO websocket implementation
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	wsWriteWait  = 10 * time.Second
+	wsPongWait   = 60 * time.Second
+	wsPingPeriod = wsPongWait * 9 / 10
+	wsSendBuffer = 64
+	wsQueue      = 256
+	// WsProtocol is the subprotocol of the websocket, the browsers pass the
+	// token as the second subprotocol since they can't set the headers
+	WsProtocol = "openlist"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{WsProtocol},
+	CheckOrigin: func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" || utils.SliceContains(conf.Conf.Cors.AllowOrigins, "*") {
+			return true
+		}
+		return utils.SliceContains(conf.Conf.Cors.AllowOrigins, origin)
+	},
+}
+
+// WsFilter decides whether a message should be delivered to a client,
+// it may also rewrite the message (e.g. strip the user's base path)
+type WsFilter func(msg Message) (Message, bool)
+
+type wsClient struct {
+	conn   *websocket.Conn
+	send   chan Message
+	filter WsFilter
+}
+
+// Ws broadcasts server side events to the connected websocket clients
+type Ws struct {
+	mu      sync.RWMutex
+	clients map[*wsClient]struct{}
+	queue   chan Message
+	once    sync.Once
+}
+
+func (w *Ws) Len() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return len(w.clients)
+}
+
+// Broadcast queues the message for the clients without blocking the caller,
+// as the filters may be slow, e.g. looking up the metas. The message is
+// dropped if the queue is full.
+func (w *Ws) Broadcast(msg Message) {
+	w.once.Do(func() {
+		go w.dispatch()
+	})
+	select {
+	case w.queue <- msg:
+	default:
+		log.Debugf("websocket queue is full, drop message: %s", msg.Type)
+	}
+}
+
+func (w *Ws) dispatch() {
+	for msg := range w.queue {
+		w.send(msg)
+	}
+}
+
+// send sends the message to every client whose filter accepts it,
+// slow clients whose buffer is full will miss the message instead of blocking
+func (w *Ws) send(msg Message) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for client := range w.clients {
+		m, ok := msg, true
+		if client.filter != nil {
+			m, ok = client.filter(msg)
+		}
+		if !ok {
+			continue
+		}
+		select {
+		case client.send <- m:
+		default:
+			log.Debugf("websocket client buffer is full, drop message: %s", m.Type)
+		}
+	}
+}
+
+// Handle upgrades the request and blocks until the connection is closed
+func (w *Ws) Handle(c *gin.Context, filter WsFilter) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Warnf("failed upgrade websocket: %+v", err)
+		return
+	}
+	client := &wsClient{
+		conn:   conn,
+		send:   make(chan Message, wsSendBuffer),
+		filter: filter,
+	}
+	w.mu.Lock()
+	w.clients[client] = struct{}{}
+	w.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.readLoop()
+	}()
+	client.writeLoop(done)
+	w.mu.Lock()
+	delete(w.clients, client)
+	w.mu.Unlock()
+	_ = conn.Close()
+}
+
+// readLoop only handles control frames, messages from the client are ignored
+func (c *wsClient) readLoop() {
+	c.conn.SetReadLimit(512)
+	_ = c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+	for {
+		if _, _, err := c.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func (c *wsClient) writeLoop(done <-chan struct{}) {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case msg := <-c.send:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+var WsInstance = &Ws{
+	clients: make(map[*wsClient]struct{}),
+	queue:   make(chan Message, wsQueue),
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+const (
+	AuditLoginFailed   = "login_failed"
+	AuditLoginLocked   = "login_locked"
+	AuditLoginUnlocked = "login_unlocked"
+)
+
+type AuditLog struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	Time     time.Time `json:"time" gorm:"index"`
+	Action   string    `json:"action" gorm:"index"`
+	Username string    `json:"username" gorm:"index"`
+	IP       string    `json:"ip"`
+	Protocol string    `json:"protocol"`
+	Detail   string    `json:"detail"`
+}
@@ -0,0 +1,11 @@
+package model
+
+// ClusterLease is held by a node of the cluster until it expires, it's used
+// to elect the leader and to lock the singleton jobs
+type ClusterLease struct {
+	Name   string `json:"name" gorm:"primaryKey;size:128"`
+	Holder string `json:"holder" gorm:"size:64"`
+	// ExpiresAt is in unix milliseconds of the database clock, so the clocks
+	// of the nodes don't need to be synced
+	ExpiresAt int64 `json:"expires_at" gorm:"index"`
+}
@@ -0,0 +1,12 @@
+package model
+
+// HashCache is the hashes computed for a file of the Hasher storage, it's
+// only valid while the size and modified time of the file are unchanged
+type HashCache struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	StorageID uint   `json:"storage_id" gorm:"index:idx_hash_cache_path"`
+	Path      string `json:"path" gorm:"index:idx_hash_cache_path"`
+	Size      int64  `json:"size"`
+	Modified  int64  `json:"modified"` // unix milli
+	Hash      string `json:"hash" gorm:"type:text"`
+}
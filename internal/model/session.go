@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+const (
+	LoginMethodPassword = "password"
+	LoginMethodLdap     = "ldap"
+	LoginMethodSSO      = "sso"
+	LoginMethodWebAuthn = "webauthn"
+)
+
+// Session is a login of a user, the id is carried by the token
+type Session struct {
+	ID         string    `json:"id" gorm:"primaryKey;size:64"`
+	UserID     uint      `json:"user_id" gorm:"index"`
+	Method     string    `json:"method"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastActive time.Time `json:"last_active"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"index"`
+}
@@ -21,6 +21,16 @@ type Storage struct {
 	Proxy
 }
 
+// StorageDetails is the disk usage of a storage in bytes
+type StorageDetails struct {
+	TotalSpace int64 `json:"total_space"`
+	FreeSpace  int64 `json:"free_space"`
+}
+
+func (d StorageDetails) UsedSpace() int64 {
+	return d.TotalSpace - d.FreeSpace
+}
+
 type Sort struct {
 	OrderBy        string `json:"order_by"`
 	OrderDirection string `json:"order_direction"`
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// Subscription polls a RSS/Atom feed and adds the matched items as offline
+// download tasks of the user
+type Subscription struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	UserID       uint   `json:"user_id" gorm:"index"`
+	Name         string `json:"name"`
+	URL          string `json:"url" gorm:"type:text"`
+	Tool         string `json:"tool"`
+	DstDir       string `json:"dst_dir"`
+	DeletePolicy string `json:"delete_policy"`
+	// Include and Exclude are the regexps matching the titles of the items
+	Include string `json:"include"`
+	Exclude string `json:"exclude"`
+	// Categories are separated by comma, the items in any of them are matched
+	Categories string `json:"categories"`
+	// MinSize and MaxSize are in bytes, zero means no limit
+	MinSize int64 `json:"min_size"`
+	MaxSize int64 `json:"max_size"`
+	// Interval is in minutes
+	Interval int  `json:"interval"`
+	Disabled bool `json:"disabled"`
+	// SkipExisting skips the items already in the feed at the first
+	// successful fetch
+	SkipExisting bool `json:"skip_existing"`
+	// Initialized is set by the first successful fetch
+	Initialized bool       `json:"initialized"`
+	LastFetched *time.Time `json:"last_fetched"`
+	LastError   string     `json:"last_error" gorm:"type:text"`
+}
+
+const (
+	SubscriptionItemAdded   = "added"
+	SubscriptionItemFailed  = "failed"
+	SubscriptionItemSkipped = "skipped"
+)
+
+// SubscriptionItem is the fetch history of a subscription
+type SubscriptionItem struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	SubscriptionID uint      `json:"subscription_id" gorm:"uniqueIndex:idx_subscription_guid"`
+	GUID           string    `json:"guid" gorm:"uniqueIndex:idx_subscription_guid;size:512"`
+	Title          string    `json:"title" gorm:"type:text"`
+	URL            string    `json:"url" gorm:"type:text"`
+	Size           int64     `json:"size"`
+	Status         string    `json:"status"`
+	Attempts       int       `json:"attempts"`
+	Error          string    `json:"error" gorm:"type:text"`
+	TaskID         string    `json:"task_id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
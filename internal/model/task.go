@@ -1,6 +1,49 @@
 package model
 
+import "time"
+
+// TaskItem is the legacy storage of the tasks, all the tasks of a type are
+// kept in a single json, it's only read to migrate to Task
 type TaskItem struct {
 	Key         string `json:"key"`
 	PersistData string `gorm:"type:text" json:"persist_data"`
 }
+
+// Task is the persisted row of a task, the payload is the serialized task
+// used to recover it, the other fields are kept for the history
+type Task struct {
+	ID         string     `json:"id" gorm:"primaryKey;size:64"`
+	Type       string     `json:"type" gorm:"index"`
+	Name       string     `json:"name" gorm:"type:text"`
+	CreatorID  uint       `json:"creator_id" gorm:"index"`
+	Creator    string     `json:"creator"`
+	State      int        `json:"state" gorm:"index"`
+	Status     string     `json:"status"`
+	Priority   int        `json:"priority"`
+	Progress   float64    `json:"progress"`
+	TotalBytes int64      `json:"total_bytes"`
+	Error      string     `json:"error" gorm:"type:text"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	StartTime  *time.Time `json:"start_time"`
+	EndTime    *time.Time `json:"end_time"`
+	// Removed means the task has been removed from its manager,
+	// the row is only kept as history and won't be recovered
+	Removed bool `json:"removed" gorm:"index"`
+	// Node is the cluster node running the task, empty if the task isn't
+	// claimed yet or the cluster is disabled
+	Node    string `json:"node" gorm:"size:64;index"`
+	Payload string `json:"-" gorm:"type:text"`
+}
+
+type TaskQuery struct {
+	PageReq
+	Type      string `json:"type" form:"type"`
+	CreatorID uint   `json:"-" form:"-"`
+	Creator   string `json:"creator" form:"creator"`
+	States    []int  `json:"states" form:"state"`
+	Keyword   string `json:"keyword" form:"keyword"`
+	// Since and Until limit the creation time, in unix seconds
+	Since int64 `json:"since" form:"since"`
+	Until int64 `json:"until" form:"until"`
+}
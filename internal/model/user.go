@@ -29,6 +29,7 @@ type User struct {
 	Salt     string `json:"-"`                                         // unique salt
 	Password string `json:"password"`                                  // password
 	BasePath string `json:"base_path"`                                 // base path
+	FtpRoot  string `json:"ftp_root"`                                  // root of ftp/sftp below the base path
 	Role     int    `json:"role"`                                      // user's role
 	Disabled bool   `json:"disabled"`
 	// Determine permissions by bit
@@ -141,6 +142,18 @@ func (u *User) JoinPath(reqPath string) (string, error) {
 	return utils.JoinBasePath(u.BasePath, reqPath)
 }
 
+// FtpUser returns a copy of the user whose base path is the ftp root,
+// so that the ftp and sftp sessions can't leave it
+func (u *User) FtpUser() (*User, error) {
+	basePath, err := u.JoinPath(u.FtpRoot)
+	if err != nil {
+		return nil, err
+	}
+	ftpUser := *u
+	ftpUser.BasePath = basePath
+	return &ftpUser, nil
+}
+
 func StaticHash(password string) string {
 	return utils.HashData(utils.SHA256, []byte(fmt.Sprintf("%s-%s", password, StaticHashSalt)))
 }
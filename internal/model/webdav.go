@@ -0,0 +1,21 @@
+package model
+
+// WebdavLock is a persisted WebDAV lock, Root is the full path of the locked resource
+type WebdavLock struct {
+	Token     string `json:"token" gorm:"primaryKey"`
+	Root      string `json:"root" gorm:"index"`
+	Duration  int64  `json:"duration"` // seconds, negative means infinite
+	OwnerXML  string `json:"owner_xml" gorm:"type:text"`
+	ZeroDepth bool   `json:"zero_depth"`
+	Expiry    int64  `json:"expiry" gorm:"index"` // unix time, 0 means never
+}
+
+// WebdavProp is a dead property set by PROPPATCH, Path is the full path of the resource
+type WebdavProp struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Path     string `json:"path" gorm:"index"`
+	Space    string `json:"space"`
+	Local    string `json:"local"`
+	Lang     string `json:"lang"`
+	InnerXML string `json:"inner_xml" gorm:"type:text"`
+}
@@ -0,0 +1,8 @@
+//go:build !nobittorrent
+// +build !nobittorrent
+
+package offline_download
+
+import (
+	_ "github.com/OpenListTeam/OpenList/internal/offline_download/bittorrent"
+)
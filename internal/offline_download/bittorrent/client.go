@@ -0,0 +1,436 @@
+package bittorrent
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/net"
+	"github.com/OpenListTeam/OpenList/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/internal/setting"
+	alog "github.com/anacrolix/log"
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/anacrolix/torrent/storage"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// BitTorrent downloads the torrents by the embedded client, so no daemon is
+// needed to deploy
+type BitTorrent struct {
+	mu     sync.Mutex
+	client *torrent.Client
+	config clientConfig
+	// added is the info hash -> the *addedTorrent of the tasks, which are
+	// moved to the new client when the settings of the client are changed
+	added sync.Map
+	// selected is the info hash -> the error of applying the file selection,
+	// the torrents are only reported after their files are selected
+	selected sync.Map
+}
+
+type addedTorrent struct {
+	tempDir   string
+	selection selection
+	// uploaded is the bytes seeded by the previous clients
+	uploaded int64
+}
+
+type clientConfig struct {
+	DataDir string
+	Port    int
+	DHT     bool
+}
+
+func newClient(c clientConfig) (*torrent.Client, error) {
+	cfg := torrent.NewDefaultClientConfig()
+	cfg.DataDir = c.DataDir
+	// the pieces are verified when the torrent is added again, so no
+	// completion db is needed
+	cfg.DefaultStorage = storage.NewFileOpts(storage.NewFileClientOpts{
+		ClientBaseDir:   c.DataDir,
+		PieceCompletion: storage.NewMapPieceCompletion(),
+	})
+	cfg.ListenPort = c.Port
+	cfg.NoDHT = !c.DHT
+	cfg.DisableWebtorrent = true
+	cfg.Seed = true
+	cfg.Logger = alog.Default.WithFilterLevel(alog.Warning)
+	return torrent.NewClient(cfg)
+}
+
+func (b *BitTorrent) Name() string {
+	return "BitTorrent"
+}
+
+func (b *BitTorrent) Items() []model.SettingItem {
+	return []model.SettingItem{
+		{Key: conf.BitTorrentPort, Value: "0", Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+		{Key: conf.BitTorrentDHT, Value: "true", Type: conf.TypeBool, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+		{Key: conf.BitTorrentSeedtime, Value: "0", Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+		{Key: conf.BitTorrentSeedRatio, Value: "0", Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+	}
+}
+
+// Init restarts the running client if its settings are changed, the torrents
+// in progress are moved to the new one and verified against the downloaded
+// pieces
+func (b *BitTorrent) Init() (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	config := b.currentConfig()
+	if b.client == nil || config == b.config {
+		return "ok", nil
+	}
+	specs := make(map[string]*torrent.TorrentSpec)
+	for _, t := range b.client.Torrents() {
+		gid := t.InfoHash().HexString()
+		v, ok := b.added.Load(gid)
+		if !ok {
+			continue
+		}
+		stats := t.Stats()
+		v.(*addedTorrent).uploaded += stats.BytesWrittenData.Int64()
+		specs[gid] = runningSpec(t)
+	}
+	b.client.Close()
+	b.client = nil
+	b.selected.Clear()
+	c, err := newClient(config)
+	if err != nil {
+		b.added.Clear()
+		return "", errors.Wrap(err, "failed start bittorrent client")
+	}
+	b.client, b.config = c, config
+	for gid, spec := range specs {
+		v, _ := b.added.Load(gid)
+		added := v.(*addedTorrent)
+		if _, err := b.addTorrent(c, spec, added); err != nil {
+			log.Errorf("failed move torrent %s to the new client: %+v", gid, err)
+			b.added.Delete(gid)
+		}
+	}
+	return "ok", nil
+}
+
+func (b *BitTorrent) currentConfig() clientConfig {
+	return clientConfig{
+		DataDir: filepath.Join(conf.Conf.TempDir, b.Name()),
+		Port:    setting.GetInt(conf.BitTorrentPort, 0),
+		DHT:     setting.GetBool(conf.BitTorrentDHT),
+	}
+}
+
+// runningSpec returns the spec to add the torrent to another client
+func runningSpec(t *torrent.Torrent) *torrent.TorrentSpec {
+	mi := t.Metainfo()
+	if t.Info() != nil {
+		if spec, err := torrent.TorrentSpecFromMetaInfoErr(&mi); err == nil {
+			return spec
+		}
+	}
+	return &torrent.TorrentSpec{
+		InfoHash:    t.InfoHash(),
+		Trackers:    mi.UpvertedAnnounceList(),
+		DisplayName: t.Name(),
+		Webseeds:    mi.UrlList,
+	}
+}
+
+func (b *BitTorrent) IsReady() bool {
+	return true
+}
+
+func (b *BitTorrent) getClient() (*torrent.Client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.client != nil {
+		return b.client, nil
+	}
+	config := b.currentConfig()
+	c, err := newClient(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed start bittorrent client")
+	}
+	b.client, b.config = c, config
+	return c, nil
+}
+
+func (b *BitTorrent) AddURL(args *tool.AddUrlArgs) (string, error) {
+	c, err := b.getClient()
+	if err != nil {
+		return "", err
+	}
+	sel := args.SelectFiles
+	if sel == "" {
+		sel = magnetSelection(args.Url)
+	}
+	selection, err := parseSelection(sel)
+	if err != nil {
+		return "", err
+	}
+	// the metainfo saved by the previous run resumes the task without
+	// fetching it again after a restart
+	spec, err := savedSpec(args.TempDir)
+	if err != nil {
+		ctx := args.Ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if spec, err = getSpec(ctx, args.Url); err != nil {
+			return "", err
+		}
+	}
+	t, err := b.addTorrent(c, spec, &addedTorrent{tempDir: args.TempDir, selection: selection})
+	if err != nil {
+		return "", err
+	}
+	return t.InfoHash().HexString(), nil
+}
+
+func (b *BitTorrent) addTorrent(c *torrent.Client, spec *torrent.TorrentSpec, added *addedTorrent) (*torrent.Torrent, error) {
+	spec.Storage = storage.NewFileOpts(storage.NewFileClientOpts{
+		ClientBaseDir:   added.tempDir,
+		PieceCompletion: storage.NewMapPieceCompletion(),
+	})
+	t, isNew, err := c.AddTorrentSpec(spec)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed add torrent")
+	}
+	// the tasks would share the torrent, so the first one to finish would
+	// drop it under the others
+	if !isNew {
+		return nil, errors.Errorf("the torrent %s is already being downloaded or seeded", t.InfoHash().HexString())
+	}
+	gid := t.InfoHash().HexString()
+	b.added.Store(gid, added)
+	go func() {
+		select {
+		case <-t.GotInfo():
+			saveMetainfo(t, added.tempDir)
+			err := selectFiles(t, added.selection)
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			// the torrent may be moved to a new client meanwhile
+			select {
+			case <-t.Closed():
+			default:
+				b.selected.Store(gid, err)
+			}
+		case <-t.Closed():
+		}
+	}()
+	return t, nil
+}
+
+// metainfoPath is beside the temp dir, so the metainfo is not transferred
+// with the downloaded files
+func metainfoPath(tempDir string) string {
+	return filepath.Clean(tempDir) + ".torrent"
+}
+
+func saveMetainfo(t *torrent.Torrent, tempDir string) {
+	f, err := os.Create(metainfoPath(tempDir))
+	if err != nil {
+		log.Warnf("failed save metainfo of torrent %s: %+v", t.InfoHash().HexString(), err)
+		return
+	}
+	defer f.Close()
+	mi := t.Metainfo()
+	if err := mi.Write(f); err != nil {
+		log.Warnf("failed save metainfo of torrent %s: %+v", t.InfoHash().HexString(), err)
+	}
+}
+
+func savedSpec(tempDir string) (*torrent.TorrentSpec, error) {
+	mi, err := metainfo.LoadFromFile(metainfoPath(tempDir))
+	if err != nil {
+		return nil, err
+	}
+	return torrent.TorrentSpecFromMetaInfoErr(mi)
+}
+
+// getSpec returns the torrent spec of the magnet or the torrent file url
+func getSpec(ctx context.Context, u string) (*torrent.TorrentSpec, error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Scheme == "magnet" {
+		return torrent.TorrentSpecFromMagnetUri(u)
+	}
+	resp, err := net.RequestHttp(ctx, http.MethodGet, http.Header{}, u)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed get .torrent file")
+	}
+	defer resp.Body.Close()
+	mi, err := metainfo.Load(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed parse .torrent file")
+	}
+	return torrent.TorrentSpecFromMetaInfoErr(mi)
+}
+
+func (b *BitTorrent) getTorrent(task *tool.DownloadTask) (*torrent.Torrent, error) {
+	b.mu.Lock()
+	c := b.client
+	b.mu.Unlock()
+	var ih metainfo.Hash
+	if err := ih.FromHexString(task.GID); err != nil {
+		return nil, err
+	}
+	if c != nil {
+		if t, ok := c.Torrent(ih); ok {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("torrent %s not found", task.GID)
+}
+
+func (b *BitTorrent) Remove(task *tool.DownloadTask) error {
+	t, err := b.getTorrent(task)
+	if err != nil {
+		return err
+	}
+	t.Drop()
+	b.added.Delete(task.GID)
+	b.selected.Delete(task.GID)
+	if err := os.Remove(metainfoPath(task.TempDir)); err != nil && !os.IsNotExist(err) {
+		log.Warnf("failed remove metainfo of torrent %s: %+v", task.GID, err)
+	}
+	return nil
+}
+
+func (b *BitTorrent) Status(task *tool.DownloadTask) (*tool.Status, error) {
+	t, err := b.getTorrent(task)
+	if err != nil {
+		return nil, err
+	}
+	s := &tool.Status{}
+	v, ok := b.selected.Load(task.GID)
+	if !ok {
+		s.Status = "getting metadata"
+		return s, nil
+	}
+	if err, _ := v.(error); err != nil {
+		s.Err = err
+		return s, nil
+	}
+	var completed int64
+	for _, f := range t.Files() {
+		if f.Priority() != torrent.PiecePriorityNone {
+			s.TotalBytes += f.Length()
+			completed += f.BytesCompleted()
+		}
+	}
+	if s.TotalBytes > 0 {
+		s.Progress = float64(completed) / float64(s.TotalBytes) * 100
+	}
+	stats := t.Stats()
+	s.Status = fmt.Sprintf("downloading from %d peers", stats.ActivePeers)
+	if completed == s.TotalBytes {
+		s.Completed = true
+		removeUnselected(t, task.TempDir)
+	}
+	return s, nil
+}
+
+// removeUnselected removes the unselected files, which may be partially
+// written by the pieces shared with the selected ones, so that they are not
+// transferred
+func removeUnselected(t *torrent.Torrent, tempDir string) {
+	for _, f := range t.Files() {
+		if f.Priority() == torrent.PiecePriorityNone {
+			p := filepath.Join(tempDir, filepath.FromSlash(f.Path()))
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				log.Warnf("failed remove unselected file %s: %+v", p, err)
+			}
+		}
+	}
+}
+
+// Seed seeds the torrent until the seed time or the seed ratio is reached.
+// A negative seed time without seed ratio keeps seeding until the client is
+// stopped, like the seed time of qBittorrent and Transmission, and a zero
+// seed time with the seed ratio has no time limit.
+func (b *BitTorrent) Seed(task *tool.DownloadTask) error {
+	seedTime := setting.GetInt(conf.BitTorrentSeedtime, 0)
+	seedRatio, _ := strconv.ParseFloat(setting.GetStr(conf.BitTorrentSeedRatio, "0"), 64)
+	if seedTime < 0 && seedRatio <= 0 {
+		return nil
+	}
+	timeLimited := seedTime > 0 || (seedTime == 0 && seedRatio <= 0)
+	deadline := time.Now().Add(time.Duration(seedTime) * time.Minute)
+	ticker := time.NewTicker(time.Second * 5)
+	defer ticker.Stop()
+	for {
+		if timeLimited && !time.Now().Before(deadline) {
+			break
+		}
+		if seedRatio > 0 {
+			// the torrent is got every time since it may be moved to a new
+			// client by the changed settings
+			ratio, err := b.seedRatio(task)
+			if err != nil {
+				return err
+			}
+			if ratio >= seedRatio {
+				break
+			}
+			task.Status = fmt.Sprintf("offline download completed, seeding with ratio %.2f", ratio)
+		}
+		select {
+		case <-task.CtxDone():
+			return b.Remove(task)
+		case <-ticker.C:
+		}
+	}
+	return b.Remove(task)
+}
+
+// seedRatio returns the uploaded bytes of the torrent, including the ones by
+// the previous clients, to the size of the selected files
+func (b *BitTorrent) seedRatio(task *tool.DownloadTask) (float64, error) {
+	t, err := b.getTorrent(task)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, f := range t.Files() {
+		if f.Priority() != torrent.PiecePriorityNone {
+			size += f.Length()
+		}
+	}
+	if size == 0 {
+		return 0, nil
+	}
+	stats := t.Stats()
+	uploaded := stats.BytesWrittenData.Int64()
+	b.mu.Lock()
+	if v, ok := b.added.Load(task.GID); ok {
+		uploaded += v.(*addedTorrent).uploaded
+	}
+	b.mu.Unlock()
+	return float64(uploaded) / float64(size), nil
+}
+
+func (b *BitTorrent) Run(task *tool.DownloadTask) error {
+	return errs.NotSupport
+}
+
+var _ tool.Tool = (*BitTorrent)(nil)
+var _ tool.Seeder = (*BitTorrent)(nil)
+
+func init() {
+	tool.Tools.Add(&BitTorrent{})
+}
@@ -0,0 +1,128 @@
+package bittorrent
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/offline_download/tool"
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+func TestParseSelection(t *testing.T) {
+	s, err := parseSelection("0, 2,4-6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []bool{true, false, true, false, true, true, true, false} {
+		if s.contains(i) != want {
+			t.Errorf("contains(%d) = %v", i, !want)
+		}
+	}
+	for _, invalid := range []string{"a", "1-", "3-1", "-1"} {
+		if _, err := parseSelection(invalid); err == nil {
+			t.Errorf("expected error for [%s]", invalid)
+		}
+	}
+	if sel := magnetSelection("magnet:?xt=urn:btih:abc&so=1,3-4"); sel != "1,3-4" {
+		t.Errorf("unexpected magnet selection %s", sel)
+	}
+}
+
+// TestDownload downloads the selected file of a torrent from a seeder found
+// by a local tracker
+func TestDownload(t *testing.T) {
+	conf.Conf = conf.DefaultConfig()
+	seedDir := t.TempDir()
+	contents := map[string][]byte{"a.bin": make([]byte, 300<<10), "b.bin": make([]byte, 200<<10)}
+	_ = os.MkdirAll(filepath.Join(seedDir, "data"), 0o755)
+	for name, content := range contents {
+		_, _ = rand.Read(content)
+		if err := os.WriteFile(filepath.Join(seedDir, "data", name), content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seeder, err := newClient(clientConfig{DataDir: seedDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer seeder.Close()
+	tracker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer := make([]byte, 6)
+		copy(peer, []byte{127, 0, 0, 1})
+		binary.BigEndian.PutUint16(peer[4:], uint16(seeder.LocalPort()))
+		_ = bencode.NewEncoder(w).Encode(map[string]any{"interval": 60, "peers": string(peer)})
+	}))
+	defer tracker.Close()
+
+	info := metainfo.Info{PieceLength: 16 << 10}
+	if err := info.BuildFromFilePath(filepath.Join(seedDir, "data")); err != nil {
+		t.Fatal(err)
+	}
+	mi := metainfo.MetaInfo{Announce: tracker.URL + "/announce"}
+	if mi.InfoBytes, err = bencode.Marshal(info); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = seeder.AddTorrent(&mi); err != nil {
+		t.Fatal(err)
+	}
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = mi.Write(w)
+	}))
+	defer files.Close()
+
+	b := &BitTorrent{}
+	if b.client, err = newClient(clientConfig{DataDir: t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+	defer b.client.Close()
+	task := &tool.DownloadTask{TempDir: t.TempDir()}
+	task.GID, err = b.AddURL(&tool.AddUrlArgs{Url: files.URL + "/data.torrent", TempDir: task.TempDir, SelectFiles: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Minute)
+	for {
+		s, err := b.Status(task)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Err != nil {
+			t.Fatal(s.Err)
+		}
+		if s.Completed {
+			if s.TotalBytes != int64(len(contents["b.bin"])) {
+				t.Errorf("unexpected total bytes %d", s.TotalBytes)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("download timeout: %+v", s)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	got, err := os.ReadFile(filepath.Join(task.TempDir, "data", "b.bin"))
+	if err != nil || !bytes.Equal(got, contents["b.bin"]) {
+		t.Errorf("the selected file is not downloaded: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(task.TempDir, "data", "a.bin")); !os.IsNotExist(err) {
+		t.Errorf("the unselected file is not removed: %v", err)
+	}
+	if _, err := savedSpec(task.TempDir); err != nil {
+		t.Errorf("the metainfo is not saved to resume: %v", err)
+	}
+	if err := b.Remove(task); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(metainfoPath(task.TempDir)); !os.IsNotExist(err) {
+		t.Errorf("the saved metainfo is not removed: %v", err)
+	}
+}
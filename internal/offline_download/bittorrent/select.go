@@ -0,0 +1,78 @@
+package bittorrent
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/anacrolix/torrent"
+)
+
+// selection is the ranges of the indexes of the files to download, nil
+// means all the files
+type selection [][2]int
+
+func (s selection) contains(i int) bool {
+	if s == nil {
+		return true
+	}
+	for _, r := range s {
+		if i >= r[0] && i <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSelection parses the file indexes in the format of the "so" param of
+// the magnet links (BEP 53), such as "0,2,4-6"
+func parseSelection(s string) (selection, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	var res selection
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		begin, err := strconv.Atoi(first)
+		if err != nil || begin < 0 {
+			return nil, fmt.Errorf("invalid file index [%s]", part)
+		}
+		end := begin
+		if isRange {
+			if end, err = strconv.Atoi(last); err != nil || end < begin {
+				return nil, fmt.Errorf("invalid file index range [%s]", part)
+			}
+		}
+		res = append(res, [2]int{begin, end})
+	}
+	return res, nil
+}
+
+// magnetSelection returns the "so" param of the magnet link
+func magnetSelection(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Scheme != "magnet" {
+		return ""
+	}
+	return parsed.Query().Get("so")
+}
+
+// selectFiles starts downloading the selected files of the torrent
+func selectFiles(t *torrent.Torrent, s selection) error {
+	var n int
+	for i, f := range t.Files() {
+		if s.contains(i) {
+			f.Download()
+			n++
+		} else {
+			f.SetPriority(torrent.PiecePriorityNone)
+		}
+	}
+	if n == 0 {
+		return fmt.Errorf("no files of the torrent are selected")
+	}
+	return nil
+}
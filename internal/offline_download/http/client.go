@@ -2,6 +2,7 @@ package http
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
@@ -9,8 +10,13 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/OpenListTeam/OpenList/internal/conf"
 	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/net"
+	"github.com/OpenListTeam/OpenList/internal/offline_download/remote"
 	"github.com/OpenListTeam/OpenList/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/internal/setting"
+	"github.com/OpenListTeam/OpenList/pkg/http_range"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
 )
 
@@ -23,7 +29,11 @@ func (s SimpleHttp) Name() string {
 }
 
 func (s SimpleHttp) Items() []model.SettingItem {
-	return nil
+	return []model.SettingItem{
+		{Key: conf.SimpleHttpConcurrency, Value: "4", Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+		// stream the files with known size into the storages without the temp files
+		{Key: conf.SimpleHttpDirect, Value: "false", Type: conf.TypeBool, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+	}
 }
 
 func (s SimpleHttp) Init() (string, error) {
@@ -47,45 +57,206 @@ func (s SimpleHttp) Status(task *tool.DownloadTask) (*tool.Status, error) {
 }
 
 func (s SimpleHttp) Run(task *tool.DownloadTask) error {
-	u := task.Url
+	header, err := task.Header()
+	if err != nil {
+		return err
+	}
+	rawURL, err := task.FullUrl()
+	if err != nil {
+		return err
+	}
+	if remote.IsSupported(rawURL) {
+		return s.downloadRemote(task, rawURL, header)
+	}
+	if !tool.IsMetalink(rawURL) {
+		return s.download(task, header, &remoteFile{Urls: []string{rawURL}, Hash: task.Hash})
+	}
+	files, err := fetchMetalink(task.Ctx(), rawURL, header)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := s.download(task, header, f); err != nil {
+			return fmt.Errorf("failed download [%s]: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// downloadRemote downloads the ftp, sftp, smb or webdav url, the basic auth
+// is stored as the Authorization header
+func (s SimpleHttp) downloadRemote(task *tool.DownloadTask, rawURL string, header http.Header) error {
+	var cred remote.Credential
+	if task.Credential != "" {
+		var err error
+		if cred, err = remote.StorageCredential(task.Credential, rawURL); err != nil {
+			return err
+		}
+	}
+	if username, password, ok := (&http.Request{Header: header}).BasicAuth(); ok {
+		cred.Username, cred.Password = username, password
+	}
+	return remote.Download(task.Ctx(), rawURL, cred, task.TempDir, task.SetTotalBytes, task.SetProgress)
+}
+
+// remoteFile is a file to download, the urls are the mirrors of it
+type remoteFile struct {
+	Name string
+	Urls []string
+	Hash string
+}
+
+// headerFor returns the headers sent to u, the custom headers and the auth
+// are only sent to the host of the task url but not the mirrors
+func headerFor(header http.Header, taskURL, u string) http.Header {
+	origin, err1 := url.Parse(taskURL)
+	target, err2 := url.Parse(u)
+	if err1 != nil || err2 != nil || !strings.EqualFold(origin.Host, target.Host) {
+		return http.Header{}
+	}
+	return header.Clone()
+}
+
+// validatorOf returns the strong ETag or the Last-Modified of the response,
+// empty if the change of the remote file can't be told
+func validatorOf(h http.Header) string {
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return h.Get("Last-Modified")
+}
+
+// setPrecondition makes the range requests fail if the remote file changed
+func setPrecondition(h http.Header, validator string) {
+	if strings.HasPrefix(validator, `"`) {
+		h.Set("If-Match", validator)
+	} else {
+		h.Set("If-Unmodified-Since", validator)
+	}
+}
+
+// download tries the mirrors of the file in order until one succeeds
+func (s SimpleHttp) download(task *tool.DownloadTask, header http.Header, f *remoteFile) error {
+	var name string
+	var err error
+	for _, u := range f.Urls {
+		if name, err = s.downloadFrom(task, headerFor(header, task.Url, u), u, f.Name); err == nil {
+			break
+		}
+		if utils.IsCanceled(task.Ctx()) {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if f.Hash == "" {
+		return nil
+	}
+	return verifyHash(filepath.Join(task.TempDir, name), f.Hash)
+}
+
+// downloadFrom downloads the file to the temp dir and returns its name, the
+// file is downloaded by multiple connections if the server supports range
+func (s SimpleHttp) downloadFrom(task *tool.DownloadTask, header http.Header, u, name string) (string, error) {
 	// parse url
 	_u, err := url.Parse(u)
 	if err != nil {
-		return err
+		return "", err
 	}
+	// request the first byte to know whether the server supports range
+	probeHeader := header.Clone()
+	probeHeader.Set("Range", "bytes=0-0")
 	req, err := http.NewRequestWithContext(task.Ctx(), http.MethodGet, u, nil)
 	if err != nil {
-		return err
+		return "", err
 	}
+	req.Header = probeHeader
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
-		return fmt.Errorf("http status code %d", resp.StatusCode)
-	}
-	// If Path is empty, use Hostname; otherwise, filePath euqals TempDir which causes os.Create to fail
-	urlPath := _u.Path
-	if urlPath == "" {
-		urlPath = strings.ReplaceAll(_u.Host, ".", "_")
+		return "", fmt.Errorf("http status code %d", resp.StatusCode)
 	}
-	filename := path.Base(urlPath)
-	if n, err := parseFilenameFromContentDisposition(resp.Header.Get("Content-Disposition")); err == nil {
-		filename = n
+	if name == "" {
+		// If Path is empty, use Hostname; otherwise, filePath euqals TempDir which causes os.Create to fail
+		urlPath := _u.Path
+		if urlPath == "" {
+			urlPath = strings.ReplaceAll(_u.Host, ".", "_")
+		}
+		name = path.Base(urlPath)
+		if n, err := tool.ParseFilenameFromContentDisposition(resp.Header.Get("Content-Disposition")); err == nil {
+			name = n
+		}
 	}
 	// save to temp dir
 	_ = os.MkdirAll(task.TempDir, os.ModePerm)
-	filePath := filepath.Join(task.TempDir, filename)
-	file, err := os.Create(filePath)
+	filePath := filepath.Join(task.TempDir, name)
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE, 0o644)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer file.Close()
-	fileSize := resp.ContentLength
-	task.SetTotalBytes(fileSize)
-	err = utils.CopyWithCtx(task.Ctx(), file, resp.Body, fileSize, task.SetProgress)
-	return err
+	var size int64 = -1
+	if resp.StatusCode == http.StatusPartialContent {
+		size = tool.ParseContentRangeSize(resp.Header.Get("Content-Range"))
+	}
+	if size < 0 {
+		// the range isn't supported, download the whole file
+		if err = file.Truncate(0); err != nil {
+			return "", err
+		}
+		task.SetTotalBytes(resp.ContentLength)
+		return name, utils.CopyWithCtx(task.Ctx(), file, resp.Body, resp.ContentLength, task.SetProgress)
+	}
+	_ = resp.Body.Close()
+	task.SetTotalBytes(size)
+	// resume from the end of the partial file left by the last run, unless
+	// the remote file changed or it can't be told
+	var offset int64
+	validator := validatorOf(resp.Header)
+	if info, err := file.Stat(); err == nil && info.Size() <= size &&
+		validator != "" && task.Validators[name] == validator {
+		offset = info.Size()
+	}
+	if task.Validators == nil {
+		task.Validators = make(map[string]string)
+	}
+	task.Validators[name] = validator
+	task.Persist()
+	if err = file.Truncate(offset); err != nil {
+		return "", err
+	}
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	if offset == size {
+		task.SetProgress(100)
+		return name, nil
+	}
+	downloader := net.NewDownloader(func(d *net.Downloader) {
+		d.Concurrency = setting.GetInt(conf.SimpleHttpConcurrency, 4)
+		d.ConcurrencyLimit = nil
+	})
+	rangeHeader := header.Clone()
+	if validator != "" {
+		setPrecondition(rangeHeader, validator)
+	}
+	rc, err := downloader.Download(task.Ctx(), &net.HttpRequestParams{
+		URL:       u,
+		Range:     http_range.Range{Start: offset, Length: size - offset},
+		HeaderRef: rangeHeader,
+		Size:      size,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	return name, utils.CopyWithCtx(task.Ctx(), file, rc, size-offset, func(p float64) {
+		task.SetProgress((float64(offset) + float64(size-offset)*p/100) / float64(size) * 100)
+	})
 }
 
 func init() {
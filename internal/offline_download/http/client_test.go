@@ -0,0 +1,78 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/offline_download/tool"
+)
+
+func TestHeaderFor(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Basic dTpw")
+	header.Set("Cookie", "a=b")
+	if h := headerFor(header, "https://example.com/a.meta4", "https://example.com/a.iso"); h.Get("Authorization") == "" {
+		t.Errorf("expected the auth sent to the same host")
+	}
+	if h := headerFor(header, "https://example.com/a.meta4", "https://mirror.example.org/a.iso"); len(h) != 0 {
+		t.Errorf("expected no header sent to the mirror, got %v", h)
+	}
+}
+
+func TestSecretHeaders(t *testing.T) {
+	task := &tool.DownloadTask{}
+	task.SetHeaders(map[string]string{"authorization": "Basic dTpw", "Referer": "https://example.com"})
+	b, err := json.Marshal(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("dTpw")) {
+		t.Fatalf("expected the auth not persisted: %s", b)
+	}
+	if h, err := task.Header(); err != nil || h.Get("Authorization") == "" || h.Get("Referer") == "" {
+		t.Fatalf("unexpected headers %v: %v", h, err)
+	}
+	var recovered tool.DownloadTask
+	if err := json.Unmarshal(b, &recovered); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recovered.Header(); err == nil {
+		t.Fatal("expected the recovered task without the auth to fail")
+	}
+}
+
+func TestResumeChangedFile(t *testing.T) {
+	db.Init(dbtest.Open(t))
+	content := []byte("the new content of the remote file")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v2"`)
+		http.ServeContent(w, r, "a.txt", time.Now(), bytes.NewReader(content))
+	}))
+	defer srv.Close()
+	task := &tool.DownloadTask{TempDir: t.TempDir(), Validators: map[string]string{"a.txt": `"v1"`}}
+	task.SetCtx(context.Background())
+	// the partial file of the old content
+	if err := os.WriteFile(filepath.Join(task.TempDir, "a.txt"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := SimpleHttp{}
+	if _, err := s.downloadFrom(task, http.Header{}, srv.URL+"/a.txt", ""); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := os.ReadFile(filepath.Join(task.TempDir, "a.txt"))
+	if !bytes.Equal(got, content) {
+		t.Fatalf("expected the file downloaded again, got %q", got)
+	}
+	if task.Validators["a.txt"] != `"v2"` {
+		t.Fatalf("unexpected validators: %v", task.Validators)
+	}
+}
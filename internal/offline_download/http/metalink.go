@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/internal/net"
+)
+
+// metalink supports both metalink 4 (RFC 5854) and metalink 3
+type metalink struct {
+	Files   []metalinkFile `xml:"file"`
+	V3Files []metalinkFile `xml:"files>file"`
+}
+
+type metalinkFile struct {
+	Name   string         `xml:"name,attr"`
+	Hashes []metalinkHash `xml:"hash"`
+	Urls   []metalinkURL  `xml:"url"`
+	// metalink 3
+	V3Hashes []metalinkHash `xml:"verification>hash"`
+	V3Urls   []metalinkURL  `xml:"resources>url"`
+}
+
+type metalinkHash struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type metalinkURL struct {
+	// Priority is from 1 to 999999 in metalink 4, 1 is the highest
+	Priority int `xml:"priority,attr"`
+	// Preference is from 0 to 100 in metalink 3, 100 is the highest
+	Preference int    `xml:"preference,attr"`
+	Value      string `xml:",chardata"`
+}
+
+func (u metalinkURL) rank() int {
+	if u.Priority > 0 {
+		return u.Priority
+	}
+	if u.Preference > 0 {
+		return 101 - u.Preference
+	}
+	return 1000000
+}
+
+func fetchMetalink(ctx context.Context, u string, header http.Header) ([]*remoteFile, error) {
+	resp, err := net.RequestHttp(ctx, http.MethodGet, header.Clone(), u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return parseMetalink(xml.NewDecoder(resp.Body))
+}
+
+func parseMetalink(decoder *xml.Decoder) ([]*remoteFile, error) {
+	var m metalink
+	if err := decoder.Decode(&m); err != nil {
+		return nil, fmt.Errorf("failed parse metalink: %w", err)
+	}
+	var files []*remoteFile
+	for _, f := range append(m.Files, m.V3Files...) {
+		// the name may contain dirs, only the base name is kept
+		name := path.Base(path.Clean("/" + f.Name))
+		if name == "/" || name == "." {
+			return nil, fmt.Errorf("invalid file name [%s] in metalink", f.Name)
+		}
+		urls := append(f.Urls, f.V3Urls...)
+		sort.SliceStable(urls, func(i, j int) bool {
+			return urls[i].rank() < urls[j].rank()
+		})
+		file := &remoteFile{
+			Name: name,
+			Hash: pickHash(append(f.Hashes, f.V3Hashes...)),
+		}
+		for _, u := range urls {
+			v := strings.TrimSpace(u.Value)
+			if strings.HasPrefix(v, "http://") || strings.HasPrefix(v, "https://") {
+				file.Urls = append(file.Urls, v)
+			}
+		}
+		if len(file.Urls) == 0 {
+			return nil, fmt.Errorf("no http mirrors of [%s] in metalink", name)
+		}
+		files = append(files, file)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files in metalink")
+	}
+	return files, nil
+}
+
+var hashRanks = map[string]int{"md5": 0, "sha1": 1, "sha256": 2}
+
+// pickHash returns the strongest supported hash as "type:value"
+func pickHash(hashes []metalinkHash) string {
+	var best string
+	bestRank := -1
+	for _, h := range hashes {
+		t := strings.ReplaceAll(strings.ToLower(h.Type), "-", "")
+		if r, ok := hashRanks[t]; ok && r > bestRank {
+			best, bestRank = t+":"+strings.TrimSpace(h.Value), r
+		}
+	}
+	return best
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestParseMetalink(t *testing.T) {
+	v4 := `<?xml version="1.0" encoding="UTF-8"?>
+<metalink xmlns="urn:ietf:params:xml:ns:metalink">
+  <file name="dir/example.iso">
+    <size>14471447</size>
+    <hash type="md5">0123456789abcdef0123456789abcdef</hash>
+    <hash type="sha-256">f0ad929cd259957e160ea442eb80986b5f01</hash>
+    <url priority="2">https://mirror2.example.com/example.iso</url>
+    <url priority="1">http://mirror1.example.com/example.iso</url>
+    <url priority="1">ftp://ftp.example.com/example.iso</url>
+  </file>
+</metalink>`
+	files, err := parseMetalink(xml.NewDecoder(strings.NewReader(v4)))
+	if err != nil {
+		t.Fatalf("failed parse metalink 4: %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "example.iso" ||
+		files[0].Hash != "sha256:f0ad929cd259957e160ea442eb80986b5f01" ||
+		len(files[0].Urls) != 2 || files[0].Urls[0] != "http://mirror1.example.com/example.iso" {
+		t.Fatalf("unexpected files: %+v", files[0])
+	}
+
+	v3 := `<metalink version="3.0" xmlns="http://www.metalinker.org/">
+  <files>
+    <file name="a.zip">
+      <verification><hash type="sha1">da39a3ee5e6b4b0d3255bfef95601890afd80709</hash></verification>
+      <resources>
+        <url type="http" preference="10">http://low.example.com/a.zip</url>
+        <url type="http" preference="100">http://high.example.com/a.zip</url>
+      </resources>
+    </file>
+  </files>
+</metalink>`
+	files, err = parseMetalink(xml.NewDecoder(strings.NewReader(v3)))
+	if err != nil {
+		t.Fatalf("failed parse metalink 3: %v", err)
+	}
+	if len(files) != 1 || files[0].Hash != "sha1:da39a3ee5e6b4b0d3255bfef95601890afd80709" ||
+		files[0].Urls[0] != "http://high.example.com/a.zip" {
+		t.Fatalf("unexpected files: %+v", files[0])
+	}
+}
+
+func TestParseHash(t *testing.T) {
+	for s, name := range map[string]string{
+		"sha256:" + strings.Repeat("a", 64): "sha256",
+		"SHA-1:" + strings.Repeat("b", 40):  "sha1",
+		strings.Repeat("c", 32):             "md5",
+	} {
+		ht, _, err := parseHash(s)
+		if err != nil || ht.Name != name {
+			t.Errorf("expected %s for %s, got %v %v", name, s, ht, err)
+		}
+	}
+	if _, _, err := parseHash("crc32:1234"); err == nil {
+		t.Errorf("expected the unsupported hash rejected")
+	}
+}
@@ -2,20 +2,48 @@ package http
 
 import (
 	"fmt"
-	"mime"
+	"os"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/pkg/utils"
 )
 
-func parseFilenameFromContentDisposition(contentDisposition string) (string, error) {
-	if contentDisposition == "" {
-		return "", fmt.Errorf("Content-Disposition is empty")
+// parseHash parses the expected hash as "type:value", the type is guessed
+// by the length of the value if it's omitted
+func parseHash(s string) (*utils.HashType, string, error) {
+	t, v, ok := strings.Cut(s, ":")
+	if !ok {
+		t, v = "", s
+	}
+	v = strings.ToLower(strings.TrimSpace(v))
+	t = strings.ReplaceAll(strings.ToLower(t), "-", "")
+	for _, ht := range []*utils.HashType{utils.MD5, utils.SHA1, utils.SHA256} {
+		if t == ht.Name || (t == "" && len(v) == ht.Width) {
+			return ht, v, nil
+		}
+	}
+	return nil, "", fmt.Errorf("unsupported hash [%s]", s)
+}
+
+// verifyHash checks the hash of the downloaded file, the file is removed
+// if the hash doesn't match so it's downloaded again on retry
+func verifyHash(filePath, expected string) error {
+	ht, v, err := parseHash(expected)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
 	}
-	_, params, err := mime.ParseMediaType(contentDisposition)
+	actual, err := utils.HashReader(ht, file)
+	_ = file.Close()
 	if err != nil {
-		return "", err
+		return err
 	}
-	filename := params["filename"]
-	if filename == "" {
-		return "", fmt.Errorf("filename not found in Content-Disposition: [%s]", contentDisposition)
+	if actual != v {
+		_ = os.Remove(filePath)
+		return fmt.Errorf("%s mismatch, expected %s, got %s", ht.Name, v, actual)
 	}
-	return filename, nil
+	return nil
 }
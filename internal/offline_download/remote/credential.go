@@ -0,0 +1,59 @@
+package remote
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/OpenListTeam/OpenList/drivers/ftp"
+	"github.com/OpenListTeam/OpenList/drivers/sftp"
+	"github.com/OpenListTeam/OpenList/drivers/smb"
+	"github.com/OpenListTeam/OpenList/drivers/webdav"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/pkg/errors"
+)
+
+// StorageCredential returns the credential of the storage mounted at the
+// path, the storage must connect to the same server as the url so that the
+// credential can't be sent to the other servers
+func StorageCredential(mountPath, rawURL string) (Credential, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Credential{}, err
+	}
+	storage, err := op.GetStorageByMountPath(mountPath)
+	if err != nil {
+		return Credential{}, errors.WithMessage(err, "failed get credential storage")
+	}
+	var scheme, host string
+	var cred Credential
+	switch a := storage.GetAddition().(type) {
+	case *ftp.Addition:
+		scheme, host = "ftp", a.Address
+		cred = Credential{Username: a.Username, Password: a.Password, Encoding: a.Encoding}
+	case *sftp.Addition:
+		scheme, host = "sftp", a.Address
+		cred = Credential{Username: a.Username, Password: a.Password, PrivateKey: a.PrivateKey, Passphrase: a.Passphrase}
+	case *smb.Addition:
+		scheme, host = "smb", a.Address
+		cred = Credential{Username: a.Username, Password: a.Password}
+	case *webdav.Addition:
+		if a.Vendor == "sharepoint" {
+			return Credential{}, fmt.Errorf("the credential of sharepoint is not supported")
+		}
+		address, err := url.Parse(a.Address)
+		if err != nil {
+			return Credential{}, err
+		}
+		scheme, host = "webdav", address.Host
+		if address.Scheme == "https" {
+			scheme = "webdavs"
+		}
+		cred = Credential{Username: a.Username, Password: a.Password}
+	default:
+		return Credential{}, fmt.Errorf("the storage [%s] has no supported credential", mountPath)
+	}
+	if scheme != u.Scheme || hostPort(scheme, host) != hostPort(u.Scheme, u.Host) {
+		return Credential{}, fmt.Errorf("the storage [%s] is not of the server [%s://%s]", mountPath, u.Scheme, u.Host)
+	}
+	return cred, nil
+}
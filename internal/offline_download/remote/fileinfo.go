@@ -0,0 +1,26 @@
+package remote
+
+import (
+	"io/fs"
+	"time"
+)
+
+type fileInfo struct {
+	name    string
+	size    int64
+	isDir   bool
+	modTime time.Time
+}
+
+func (f *fileInfo) Name() string       { return f.name }
+func (f *fileInfo) Size() int64        { return f.size }
+func (f *fileInfo) ModTime() time.Time { return f.modTime }
+func (f *fileInfo) IsDir() bool        { return f.isDir }
+func (f *fileInfo) Sys() any           { return nil }
+
+func (f *fileInfo) Mode() fs.FileMode {
+	if f.isDir {
+		return fs.ModeDir | 0o755
+	}
+	return 0o644
+}
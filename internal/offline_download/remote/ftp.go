@@ -0,0 +1,97 @@
+package remote
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"path"
+	"time"
+
+	"github.com/axgle/mahonia"
+	"github.com/jlaffaye/ftp"
+)
+
+type ftpFS struct {
+	conn     *ftp.ServerConn
+	encoding string
+}
+
+func newFTP(ctx context.Context, addr string, cred Credential) (*ftpFS, error) {
+	conn, err := ftp.Dial(addr, ftp.DialWithContext(ctx), ftp.DialWithShutTimeout(10*time.Second))
+	if err != nil {
+		return nil, err
+	}
+	username := cred.Username
+	if username == "" {
+		username = "anonymous"
+	}
+	if err = conn.Login(username, cred.Password); err != nil {
+		_ = conn.Quit()
+		return nil, err
+	}
+	return &ftpFS{conn: conn, encoding: cred.Encoding}, nil
+}
+
+func (f *ftpFS) encode(p string) string {
+	if f.encoding == "" {
+		return p
+	}
+	return mahonia.NewEncoder(f.encoding).ConvertString(p)
+}
+
+func (f *ftpFS) decode(p string) string {
+	if f.encoding == "" {
+		return p
+	}
+	return mahonia.NewDecoder(f.encoding).ConvertString(p)
+}
+
+func (f *ftpFS) entryInfo(e *ftp.Entry) fs.FileInfo {
+	return &fileInfo{
+		name:    f.decode(e.Name),
+		size:    int64(e.Size),
+		isDir:   e.Type == ftp.EntryTypeFolder,
+		modTime: e.Time,
+	}
+}
+
+func (f *ftpFS) Stat(p string) (fs.FileInfo, error) {
+	if p == "/" {
+		return &fileInfo{name: "/", isDir: true}, nil
+	}
+	if e, err := f.conn.GetEntry(f.encode(p)); err == nil {
+		e.Name = path.Base(e.Name)
+		return f.entryInfo(e), nil
+	}
+	// MLST is not supported by the server
+	if size, err := f.conn.FileSize(f.encode(p)); err == nil {
+		return &fileInfo{name: path.Base(p), size: size}, nil
+	}
+	if _, err := f.conn.List(f.encode(p)); err != nil {
+		return nil, err
+	}
+	return &fileInfo{name: path.Base(p), isDir: true}, nil
+}
+
+func (f *ftpFS) ReadDir(p string) ([]fs.FileInfo, error) {
+	entries, err := f.conn.List(f.encode(p))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]fs.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		if e.Type == ftp.EntryTypeLink {
+			continue
+		}
+		res = append(res, f.entryInfo(e))
+	}
+	return res, nil
+}
+
+func (f *ftpFS) Open(p string) (io.ReadCloser, error) {
+	return f.conn.Retr(f.encode(p))
+}
+
+func (f *ftpFS) Close() error {
+	return f.conn.Quit()
+}
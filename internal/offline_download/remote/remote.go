@@ -0,0 +1,193 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+)
+
+// Credential is used to login the remote server
+type Credential struct {
+	Username string
+	Password string
+	// PrivateKey and Passphrase are only used by sftp
+	PrivateKey string
+	Passphrase string
+	// Encoding is the encoding of the paths, only used by ftp
+	Encoding string
+}
+
+var defaultPorts = map[string]string{
+	"ftp":     "21",
+	"sftp":    "22",
+	"smb":     "445",
+	"webdav":  "80",
+	"webdavs": "443",
+}
+
+// IsSupported returns whether the url is of the schemes downloaded by
+// the remote clients
+func IsSupported(u string) bool {
+	_u, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	_, ok := defaultPorts[_u.Scheme]
+	return ok
+}
+
+// hostPort returns the host with the default port of the scheme
+func hostPort(scheme, host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), defaultPorts[scheme])
+}
+
+type fileSystem interface {
+	Stat(p string) (fs.FileInfo, error)
+	ReadDir(p string) ([]fs.FileInfo, error)
+	Open(p string) (io.ReadCloser, error)
+	Close() error
+}
+
+// connect returns the file system of the server and the path of the url in it
+func connect(ctx context.Context, u *url.URL, cred Credential) (fileSystem, string, error) {
+	addr := hostPort(u.Scheme, u.Host)
+	p := path.Clean("/" + u.Path)
+	switch u.Scheme {
+	case "ftp":
+		f, err := newFTP(ctx, addr, cred)
+		return f, p, err
+	case "sftp":
+		f, err := newSFTP(ctx, addr, cred)
+		return f, p, err
+	case "smb":
+		share, p, _ := strings.Cut(strings.TrimPrefix(p, "/"), "/")
+		if share == "" {
+			return nil, "", fmt.Errorf("share name is required in smb url")
+		}
+		f, err := newSMB(ctx, addr, share, cred)
+		if p == "" {
+			p = "."
+		}
+		return f, p, err
+	case "webdav", "webdavs":
+		scheme := "http"
+		if u.Scheme == "webdavs" {
+			scheme = "https"
+		}
+		f, err := newWebDav(scheme+"://"+u.Host, cred)
+		return f, p, err
+	}
+	return nil, "", fmt.Errorf("unsupported scheme [%s]", u.Scheme)
+}
+
+type file struct {
+	remotePath string
+	localPath  string
+	size       int64
+}
+
+// Download downloads the file or the dir of the url into the local dir
+// recursively, the credential in the url takes precedence
+func Download(ctx context.Context, rawURL string, cred Credential, dir string, setTotal func(int64), setProgress func(float64)) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.User != nil {
+		cred.Username = u.User.Username()
+		cred.Password, _ = u.User.Password()
+	}
+	f, p, err := connect(ctx, u, cred)
+	if err != nil {
+		return fmt.Errorf("failed connect [%s]: %w", u.Host, err)
+	}
+	defer f.Close()
+	info, err := f.Stat(p)
+	if err != nil {
+		return err
+	}
+	name := path.Base(p)
+	if name == "/" || name == "." {
+		name = strings.ReplaceAll(u.Hostname(), ".", "_")
+	}
+	var files []file
+	if err = walk(ctx, f, p, filepath.Join(dir, name), info, &files); err != nil {
+		return err
+	}
+	var total, done int64
+	for _, fi := range files {
+		total += fi.size
+	}
+	setTotal(total)
+	for _, fi := range files {
+		err = download(ctx, f, fi, func(p float64) {
+			if total > 0 {
+				setProgress((float64(done) + float64(fi.size)*p/100) / float64(total) * 100)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("failed download [%s]: %w", fi.remotePath, err)
+		}
+		done += fi.size
+	}
+	setProgress(100)
+	return nil
+}
+
+// walk collects the files in the dir and creates the local dirs
+func walk(ctx context.Context, f fileSystem, remotePath, localPath string, info fs.FileInfo, files *[]file) error {
+	if utils.IsCanceled(ctx) {
+		return ctx.Err()
+	}
+	if !info.IsDir() {
+		*files = append(*files, file{remotePath: remotePath, localPath: localPath, size: info.Size()})
+		return nil
+	}
+	if err := os.MkdirAll(localPath, os.ModePerm); err != nil {
+		return err
+	}
+	entries, err := f.ReadDir(remotePath)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		// the names from the server must not escape the local dir
+		if e.Name() == "." || e.Name() == ".." || strings.ContainsAny(e.Name(), `/\`) {
+			continue
+		}
+		err = walk(ctx, f, path.Join(remotePath, e.Name()), filepath.Join(localPath, e.Name()), e, files)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func download(ctx context.Context, f fileSystem, fi file, setProgress func(float64)) error {
+	rc, err := f.Open(fi.remotePath)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err = os.MkdirAll(filepath.Dir(fi.localPath), os.ModePerm); err != nil {
+		return err
+	}
+	out, err := os.Create(fi.localPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return utils.CopyWithCtx(ctx, out, rc, fi.size, setProgress)
+}
@@ -0,0 +1,69 @@
+package remote
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+type sftpFS struct {
+	*sftp.Client
+	conn *ssh.Client
+}
+
+func newSFTP(ctx context.Context, addr string, cred Credential) (*sftpFS, error) {
+	var auth ssh.AuthMethod
+	if len(cred.PrivateKey) > 0 {
+		var err error
+		var signer ssh.Signer
+		if len(cred.Passphrase) > 0 {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(cred.PrivateKey), []byte(cred.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(cred.PrivateKey))
+		}
+		if err != nil {
+			return nil, err
+		}
+		auth = ssh.PublicKeys(signer)
+	} else {
+		auth = ssh.Password(cred.Password)
+	}
+	config := &ssh.ClientConfig{
+		User:            cred.Username,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+	netConn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c, chans, reqs, err := ssh.NewClientConn(netConn, addr, config)
+	if err != nil {
+		_ = netConn.Close()
+		return nil, err
+	}
+	conn := ssh.NewClient(c, chans, reqs)
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &sftpFS{Client: client, conn: conn}, nil
+}
+
+func (f *sftpFS) Open(p string) (io.ReadCloser, error) {
+	return f.Client.Open(p)
+}
+
+func (f *sftpFS) ReadDir(p string) ([]fs.FileInfo, error) {
+	return f.Client.ReadDir(p)
+}
+
+func (f *sftpFS) Close() error {
+	_ = f.Client.Close()
+	return f.conn.Close()
+}
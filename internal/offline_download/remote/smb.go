@@ -0,0 +1,65 @@
+package remote
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net"
+	"strings"
+
+	"github.com/hirochachacha/go-smb2"
+)
+
+type smbFS struct {
+	share   *smb2.Share
+	session *smb2.Session
+}
+
+func newSMB(ctx context.Context, addr, shareName string, cred Credential) (*smbFS, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &smb2.Dialer{
+		Initiator: &smb2.NTLMInitiator{
+			User:     cred.Username,
+			Password: cred.Password,
+		},
+	}
+	s, err := dialer.DialContext(ctx, conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	share, err := s.Mount(shareName)
+	if err != nil {
+		_ = s.Logoff()
+		return nil, err
+	}
+	return &smbFS{share: share, session: s}, nil
+}
+
+// smbPath returns the path relative to the share
+func smbPath(p string) string {
+	if p = strings.TrimPrefix(p, "/"); p == "" {
+		return "."
+	}
+	return p
+}
+
+func (f *smbFS) Stat(p string) (fs.FileInfo, error) {
+	return f.share.Stat(smbPath(p))
+}
+
+func (f *smbFS) ReadDir(p string) ([]fs.FileInfo, error) {
+	return f.share.ReadDir(smbPath(p))
+}
+
+func (f *smbFS) Open(p string) (io.ReadCloser, error) {
+	return f.share.Open(smbPath(p))
+}
+
+func (f *smbFS) Close() error {
+	_ = f.share.Umount()
+	return f.session.Logoff()
+}
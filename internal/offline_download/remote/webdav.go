@@ -0,0 +1,41 @@
+package remote
+
+import (
+	"crypto/tls"
+	"io"
+	"io/fs"
+	"net/http"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/pkg/gowebdav"
+)
+
+type webdavFS struct {
+	client *gowebdav.Client
+}
+
+func newWebDav(address string, cred Credential) (*webdavFS, error) {
+	c := gowebdav.NewClient(address, cred.Username, cred.Password)
+	c.SetTransport(&http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: conf.Conf.TlsInsecureSkipVerify},
+	})
+	return &webdavFS{client: c}, nil
+}
+
+func (f *webdavFS) Stat(p string) (fs.FileInfo, error) {
+	return f.client.Stat(p)
+}
+
+func (f *webdavFS) ReadDir(p string) ([]fs.FileInfo, error) {
+	return f.client.ReadDir(p)
+}
+
+func (f *webdavFS) Open(p string) (io.ReadCloser, error) {
+	rc, _, err := f.client.ReadStream(p, nil)
+	return rc, err
+}
+
+func (f *webdavFS) Close() error {
+	return nil
+}
@@ -2,9 +2,11 @@ package tool
 
 import (
 	"context"
+	"encoding/base64"
 	"net/url"
 	stdpath "path"
 	"path/filepath"
+	"strings"
 
 	_115 "github.com/OpenListTeam/OpenList/drivers/115"
 	"github.com/OpenListTeam/OpenList/drivers/pikpak"
@@ -34,6 +36,17 @@ type AddURLArgs struct {
 	DstDirPath   string
 	Tool         string
 	DeletePolicy DeletePolicy
+	// Headers, the basic auth and the expected Hash as "type:value" are
+	// only supported by SimpleHttp
+	Headers  map[string]string
+	Username string
+	Password string
+	Hash     string
+	// SelectFiles is only supported by BitTorrent
+	SelectFiles string
+	// Credential is the mount path of the storage whose credential is used
+	// to login the ftp, sftp, smb or webdav server of the url
+	Credential string
 }
 
 func AddURL(ctx context.Context, args *AddURLArgs) (task.TaskExtensionInfo, error) {
@@ -58,8 +71,15 @@ func AddURL(ctx context.Context, args *AddURLArgs) (task.TaskExtensionInfo, erro
 			return nil, errors.WithStack(errs.NotFolder)
 		}
 	}
-	// try putting url
-	if args.Tool == "SimpleHttp" {
+	headers := make(map[string]string, len(args.Headers)+1)
+	for k, v := range args.Headers {
+		headers[k] = v
+	}
+	if args.Username != "" || args.Password != "" {
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(args.Username+":"+args.Password))
+	}
+	// try putting url, the storage can't send the headers or verify the hash
+	if args.Tool == "SimpleHttp" && isHttpURL(args.URL) && len(headers) == 0 && args.Hash == "" && !IsMetalink(args.URL) {
 		err = tryPutUrl(ctx, args.DstDirPath, args.URL)
 		if err == nil || !errors.Is(err, errs.NotImplement) {
 			return nil, err
@@ -108,19 +128,40 @@ func AddURL(ctx context.Context, args *AddURLArgs) (task.TaskExtensionInfo, erro
 	taskCreator, _ := ctx.Value("user").(*model.User) // taskCreator is nil when convert failed
 	t := &DownloadTask{
 		TaskExtension: task.TaskExtension{
-			Creator: taskCreator,
+			Creator:  taskCreator,
+			Priority: task.PriorityFromCtx(ctx),
+			Group:    task.GroupFromCtx(ctx),
 		},
-		Url:          args.URL,
 		DstDirPath:   args.DstDirPath,
 		TempDir:      tempDir,
 		DeletePolicy: deletePolicy,
 		Toolname:     args.Tool,
+		Hash:         args.Hash,
+		SelectFiles:  args.SelectFiles,
+		Credential:   args.Credential,
 		tool:         tool,
 	}
+	t.SetUrl(args.URL)
+	t.SetHeaders(headers)
 	DownloadTaskManager.Add(t)
 	return t, nil
 }
 
+func isHttpURL(u string) bool {
+	_u, err := url.Parse(u)
+	return err == nil && (_u.Scheme == "http" || _u.Scheme == "https")
+}
+
+// IsMetalink returns whether the url is a metalink file listing the mirrors
+func IsMetalink(u string) bool {
+	_u, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	ext := strings.ToLower(stdpath.Ext(_u.Path))
+	return ext == ".metalink" || ext == ".meta4"
+}
+
 func tryPutUrl(ctx context.Context, path, urlStr string) error {
 	var dstName string
 	u, err := url.Parse(urlStr)
@@ -1,14 +1,21 @@
 package tool
 
 import (
+	"context"
+
 	"github.com/OpenListTeam/OpenList/internal/model"
 )
 
 type AddUrlArgs struct {
+	// Ctx is the context of the task, which is done when the task is canceled
+	Ctx     context.Context
 	Url     string
 	UID     string
 	TempDir string
 	Signal  chan int
+	// SelectFiles is the indexes of the files in the torrent to download,
+	// such as "0,2,4-6", all the files are downloaded if empty
+	SelectFiles string
 }
 
 type Status struct {
@@ -36,3 +43,9 @@ type Tool interface {
 	// Run for simple http download
 	Run(task *DownloadTask) error
 }
+
+// Seeder is implemented by the tools seeding the torrents in process
+type Seeder interface {
+	// Seed blocks until the seeding limits are reached, then removes the torrent
+	Seed(task *DownloadTask) error
+}
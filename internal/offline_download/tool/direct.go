@@ -0,0 +1,129 @@
+package tool
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/net"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/setting"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	"github.com/pkg/errors"
+)
+
+// canPutDirectly returns whether the url can be streamed into the dst
+// storage, the hash can't be verified before the file is uploaded
+func (t *DownloadTask) canPutDirectly() bool {
+	return t.Toolname == "SimpleHttp" && setting.GetBool(conf.SimpleHttpDirect) &&
+		isHttpURL(t.Url) && t.Hash == "" && !IsMetalink(t.Url)
+}
+
+// putDirectly streams the url into the dst storage without the temp file,
+// false is returned if the size is unknown so the file must be downloaded
+// to the temp dir first. The drivers needing a seekable or hashed stream
+// still cache the stream in a temp file by themselves.
+func (t *DownloadTask) putDirectly() (bool, error) {
+	header, err := t.Header()
+	if err != nil {
+		return true, err
+	}
+	t.Status = "getting the file info"
+	name, size, ranged, err := probeURL(t, header)
+	if err != nil || size <= 0 {
+		return false, nil
+	}
+	storage, dstDirActualPath, err := op.GetStorageAndActualPath(t.DstDirPath)
+	if err != nil {
+		return true, errors.WithMessage(err, "failed get dst storage")
+	}
+	link := &model.Link{URL: t.Url, Header: header}
+	if ranged {
+		link.Concurrency = setting.GetInt(conf.SimpleHttpConcurrency, 4)
+	}
+	fs := stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: time.Now(),
+		},
+		Ctx: t.Ctx(),
+	}
+	ss, err := stream.NewSeekableStream(fs, link)
+	if err != nil {
+		return true, errors.WithMessagef(err, "failed get [%s] stream", t.Url)
+	}
+	t.SetTotalBytes(size)
+	t.Status = "streaming into the storage"
+	if err = op.Put(t.Ctx(), storage, dstDirActualPath, ss, t.SetProgress, true); err != nil {
+		return true, err
+	}
+	t.PutObjName = name
+	return true, nil
+}
+
+// probeURL requests the first byte of the url to get the name and the size
+// of the file, and whether the server supports range
+func probeURL(t *DownloadTask, header http.Header) (string, int64, bool, error) {
+	probeHeader := header.Clone()
+	probeHeader.Set("Range", "bytes=0-0")
+	resp, err := net.RequestHttp(t.Ctx(), http.MethodGet, probeHeader, t.Url)
+	if err != nil {
+		return "", 0, false, err
+	}
+	_ = resp.Body.Close()
+	u, err := url.Parse(t.Url)
+	if err != nil {
+		return "", 0, false, err
+	}
+	urlPath := u.Path
+	if urlPath == "" {
+		urlPath = strings.ReplaceAll(u.Host, ".", "_")
+	}
+	name := stdpath.Base(urlPath)
+	if n, err := ParseFilenameFromContentDisposition(resp.Header.Get("Content-Disposition")); err == nil {
+		name = stdpath.Base(n)
+	}
+	if resp.StatusCode == http.StatusPartialContent {
+		return name, ParseContentRangeSize(resp.Header.Get("Content-Range")), true, nil
+	}
+	return name, resp.ContentLength, false, nil
+}
+
+// ParseFilenameFromContentDisposition returns the filename in the
+// Content-Disposition header, the RFC 5987 filename* is preferred
+func ParseFilenameFromContentDisposition(contentDisposition string) (string, error) {
+	if contentDisposition == "" {
+		return "", fmt.Errorf("Content-Disposition is empty")
+	}
+	_, params, err := mime.ParseMediaType(contentDisposition)
+	if err != nil {
+		return "", err
+	}
+	filename := params["filename"]
+	if filename == "" {
+		return "", fmt.Errorf("filename not found in Content-Disposition: [%s]", contentDisposition)
+	}
+	return filename, nil
+}
+
+// ParseContentRangeSize returns the total size in the Content-Range header,
+// -1 if it's unknown
+func ParseContentRangeSize(contentRange string) int64 {
+	i := strings.LastIndex(contentRange, "/")
+	if i < 0 {
+		return -1
+	}
+	size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
+}
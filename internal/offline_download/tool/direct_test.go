@@ -0,0 +1,130 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/drivers/local"
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+)
+
+// initDirectTest mounts a local storage of a temporary dir on /local and
+// enables streaming the SimpleHttp downloads into the storages
+func initDirectTest(t *testing.T, direct bool) string {
+	db.Init(dbtest.Open(t))
+	conf.Conf.TempDir = t.TempDir()
+	if err := op.SaveSettingItem(&model.SettingItem{Key: conf.SimpleHttpDirect, Value: fmt.Sprint(direct)}); err != nil {
+		t.Fatalf("failed to save settings: %v", err)
+	}
+	root := t.TempDir()
+	ctx := context.Background()
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed to create local storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, id) })
+	return root
+}
+
+func TestCanPutDirectly(t *testing.T) {
+	initDirectTest(t, true)
+	for _, c := range []struct {
+		task *DownloadTask
+		ok   bool
+	}{
+		{&DownloadTask{Toolname: "SimpleHttp", Url: "https://example.com/a.iso"}, true},
+		{&DownloadTask{Toolname: "SimpleHttp", Url: "https://example.com/a.iso", Hash: "md5:" + string(bytes.Repeat([]byte("a"), 32))}, false},
+		{&DownloadTask{Toolname: "SimpleHttp", Url: "https://example.com/a.meta4"}, false},
+		{&DownloadTask{Toolname: "SimpleHttp", Url: "ftp://example.com/a.iso"}, false},
+		{&DownloadTask{Toolname: "aria2", Url: "https://example.com/a.iso"}, false},
+	} {
+		if ok := c.task.canPutDirectly(); ok != c.ok {
+			t.Errorf("expected %v for %s by %s, got %v", c.ok, c.task.Url, c.task.Toolname, ok)
+		}
+	}
+	initDirectTest(t, false)
+	task := &DownloadTask{Toolname: "SimpleHttp", Url: "https://example.com/a.iso"}
+	if task.canPutDirectly() {
+		t.Error("expected no direct put when it's disabled")
+	}
+}
+
+func TestPutDirectly(t *testing.T) {
+	root := initDirectTest(t, true)
+	content := []byte("streamed into the storage directly")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="fallback.txt"; filename*=UTF-8''%E4%BD%A0%E5%A5%BD.txt`)
+		http.ServeContent(w, r, "", time.Now(), bytes.NewReader(content))
+	}))
+	defer srv.Close()
+	if err := os.Mkdir(filepath.Join(root, "dst"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	task := &DownloadTask{Toolname: "SimpleHttp", Url: srv.URL + "/download?id=1", DstDirPath: "/local/dst"}
+	task.SetCtx(context.Background())
+	ok, err := task.putDirectly()
+	if !ok || err != nil {
+		t.Fatalf("expected the file put directly, got %v: %+v", ok, err)
+	}
+	if task.PutObjName != "你好.txt" {
+		t.Errorf("expected the name from filename*, got %q", task.PutObjName)
+	}
+	got, err := os.ReadFile(filepath.Join(root, "dst", "你好.txt"))
+	if err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("unexpected content %q: %v", got, err)
+	}
+}
+
+func TestPutDirectlyUnknownSize(t *testing.T) {
+	root := initDirectTest(t, true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the range is ignored and the body is chunked without the length
+		_, _ = w.Write([]byte("unknown"))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte(" size"))
+	}))
+	defer srv.Close()
+	task := &DownloadTask{Toolname: "SimpleHttp", Url: srv.URL + "/a.txt", DstDirPath: "/local"}
+	task.SetCtx(context.Background())
+	if ok, err := task.putDirectly(); ok || err != nil {
+		t.Fatalf("expected to fall back to the temp file, got %v: %+v", ok, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.txt")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing put, got %v", err)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	if size := ParseContentRangeSize("bytes 0-0/1234"); size != 1234 {
+		t.Errorf("unexpected size %d", size)
+	}
+	if size := ParseContentRangeSize("bytes 0-0/*"); size != -1 {
+		t.Errorf("unexpected size %d", size)
+	}
+	for header, name := range map[string]string{
+		`attachment; filename="a b.txt"`:                                               "a b.txt",
+		`attachment; filename="fallback.txt"; filename*=UTF-8''%E4%BD%A0%E5%A5%BD.txt`: "你好.txt",
+	} {
+		if n, err := ParseFilenameFromContentDisposition(header); err != nil || n != name {
+			t.Errorf("expected %s for %s, got %s: %v", name, header, n, err)
+		}
+	}
+	if _, err := ParseFilenameFromContentDisposition("inline"); err == nil {
+		t.Error("expected no filename in inline")
+	}
+}
@@ -17,7 +17,6 @@ import (
 	"github.com/OpenListTeam/OpenList/pkg/utils"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"github.com/xhofe/tache"
 )
 
 type TransferTask struct {
@@ -52,6 +51,10 @@ func (t *TransferTask) GetStatus() string {
 	return t.Status
 }
 
+func (t *TransferTask) GetStorages() []string {
+	return []string{t.SrcStorageMp, t.DstStorageMp}
+}
+
 func (t *TransferTask) OnSucceeded() {
 	if t.DeletePolicy == DeleteOnUploadSucceed || t.DeletePolicy == DeleteAlways {
 		if t.SrcStorage == nil {
@@ -63,6 +66,10 @@ func (t *TransferTask) OnSucceeded() {
 }
 
 func (t *TransferTask) OnFailed() {
+	// the temp files are kept for the paused task to be resumed
+	if t.IsPaused() {
+		return
+	}
 	if t.DeletePolicy == DeleteOnUploadFailed || t.DeletePolicy == DeleteAlways {
 		if t.SrcStorage == nil {
 			removeStdTemp(t)
@@ -73,7 +80,7 @@ func (t *TransferTask) OnFailed() {
 }
 
 var (
-	TransferTaskManager *tache.Manager[*TransferTask]
+	TransferTaskManager *task.Scheduler[*TransferTask]
 )
 
 func transferStd(ctx context.Context, tempDir, dstDirPath string, deletePolicy DeletePolicy) error {
@@ -89,7 +96,9 @@ func transferStd(ctx context.Context, tempDir, dstDirPath string, deletePolicy D
 	for _, entry := range entries {
 		t := &TransferTask{
 			TaskExtension: task.TaskExtension{
-				Creator: taskCreator,
+				Creator:  taskCreator,
+				Priority: task.PriorityFromCtx(ctx),
+				Group:    task.GroupFromCtx(ctx),
 			},
 			SrcObjPath:   stdpath.Join(tempDir, entry.Name()),
 			DstDirPath:   dstDirActualPath,
@@ -119,7 +128,9 @@ func transferStdPath(t *TransferTask) error {
 			dstObjPath := stdpath.Join(t.DstDirPath, info.Name())
 			t := &TransferTask{
 				TaskExtension: task.TaskExtension{
-					Creator: t.Creator,
+					Creator:  t.Creator,
+					Priority: t.GetPriority(),
+					Group:    t.GetGroup(),
 				},
 				SrcObjPath:   srcRawPath,
 				DstDirPath:   dstObjPath,
@@ -189,7 +200,9 @@ func transferObj(ctx context.Context, tempDir, dstDirPath string, deletePolicy D
 	for _, obj := range objs {
 		t := &TransferTask{
 			TaskExtension: task.TaskExtension{
-				Creator: taskCreator,
+				Creator:  taskCreator,
+				Priority: task.PriorityFromCtx(ctx),
+				Group:    task.GroupFromCtx(ctx),
 			},
 			SrcObjPath:   stdpath.Join(srcObjActualPath, obj.GetName()),
 			DstDirPath:   dstDirActualPath,
@@ -224,7 +237,9 @@ func transferObjPath(t *TransferTask) error {
 			dstObjPath := stdpath.Join(t.DstDirPath, srcObj.GetName())
 			TransferTaskManager.Add(&TransferTask{
 				TaskExtension: task.TaskExtension{
-					Creator: t.Creator,
+					Creator:  t.Creator,
+					Priority: t.GetPriority(),
+					Group:    t.GetGroup(),
 				},
 				SrcObjPath:   srcObjPath,
 				DstDirPath:   dstObjPath,
@@ -0,0 +1,25 @@
+package op
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/model"
+)
+
+func CreateAuditLog(l *model.AuditLog) error {
+	return db.CreateAuditLog(l)
+}
+
+func GetAuditLogs(pageIndex, pageSize int, action, username string) ([]model.AuditLog, int64, error) {
+	return db.GetAuditLogs(pageIndex, pageSize, action, username)
+}
+
+// CleanAuditLogs deletes the audit logs older than the retention days, zero
+// keeps them forever
+func CleanAuditLogs(retentionDays int) error {
+	if retentionDays <= 0 {
+		return nil
+	}
+	return db.DeleteAuditLogsBefore(time.Now().AddDate(0, 0, -retentionDays))
+}
@@ -6,6 +6,7 @@ import (
 	"slices"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/internal/cache"
 	"github.com/OpenListTeam/OpenList/internal/driver"
 	"github.com/OpenListTeam/OpenList/internal/errs"
 	"github.com/OpenListTeam/OpenList/internal/model"
@@ -13,18 +14,20 @@ import (
 	"github.com/OpenListTeam/OpenList/pkg/generic_sync"
 	"github.com/OpenListTeam/OpenList/pkg/singleflight"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
-	"github.com/Xhofe/go-cache"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 // In order to facilitate adding some other things before and after file op
 
-var listCache = cache.NewMemCache(cache.WithShards[[]model.Obj](64))
+var listCache = cache.NewLocal[[]model.Obj]("list", 64)
 var listG singleflight.Group[[]model.Obj]
 
 func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj model.Obj) {
+	defer HandleDirChangeHook(storage, path)
 	key := Key(storage, path)
+	// the other nodes drop the dir and list it again
+	defer listCache.Invalidate(key)
 	objs, ok := listCache.Get(key)
 	if ok {
 		for i, obj := range objs {
@@ -44,7 +47,9 @@ func updateCacheObj(storage driver.Driver, path string, oldObj model.Obj, newObj
 }
 
 func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
+	defer HandleDirChangeHook(storage, path)
 	key := Key(storage, path)
+	defer listCache.Invalidate(key)
 	objs, ok := listCache.Get(key)
 	if ok {
 		for i, oldObj := range objs {
@@ -60,7 +65,9 @@ func delCacheObj(storage driver.Driver, path string, obj model.Obj) {
 var addSortDebounceMap generic_sync.MapOf[string, func(func())]
 
 func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
+	defer HandleDirChangeHook(storage, path)
 	key := Key(storage, path)
+	defer listCache.Invalidate(key)
 	objs, ok := listCache.Get(key)
 	if ok {
 		for i, obj := range objs {
@@ -92,11 +99,16 @@ func addCacheObj(storage driver.Driver, path string, newObj model.Obj) {
 }
 
 func ClearCache(storage driver.Driver, path string) {
+	clearCache(storage, path)
+	HandleDirChangeHook(storage, path)
+}
+
+func clearCache(storage driver.Driver, path string) {
 	objs, ok := listCache.Get(Key(storage, path))
 	if ok {
 		for _, obj := range objs {
 			if obj.IsDir() {
-				ClearCache(storage, stdpath.Join(path, obj.GetName()))
+				clearCache(storage, stdpath.Join(path, obj.GetName()))
 			}
 		}
 	}
@@ -243,7 +255,7 @@ func GetUnwrap(ctx context.Context, storage driver.Driver, path string) (model.O
 	return model.UnwrapObj(obj), err
 }
 
-var linkCache = cache.NewMemCache(cache.WithShards[*model.Link](16))
+var linkCache = cache.NewLocal[*model.Link]("link", 16)
 var linkG singleflight.Group[*model.Link]
 
 // Link get link, if is an url. should have an expiry time
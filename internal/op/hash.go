@@ -0,0 +1,26 @@
+package op
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/model"
+)
+
+func GetHashCaches(storageID uint, paths []string) ([]model.HashCache, error) {
+	return db.GetHashCaches(storageID, paths)
+}
+
+func SaveHashCache(h *model.HashCache) error {
+	return db.SaveHashCache(h)
+}
+
+func GetHashCachesUnder(storageID uint, path string) ([]model.HashCache, error) {
+	return db.GetHashCachesUnder(storageID, path)
+}
+
+func MoveHashCaches(storageID uint, src, dst string) error {
+	return db.MoveHashCaches(storageID, src, dst)
+}
+
+func DeleteHashCaches(storageID uint, path string) error {
+	return db.DeleteHashCaches(storageID, path)
+}
@@ -29,6 +29,27 @@ func HandleObjsUpdateHook(parent string, objs []model.Obj) {
 	}
 }
 
+// DirChangeHook is called with the full path of a dir whose cached listing was mutated
+type DirChangeHook = func(dir string)
+
+var (
+	dirChangeHooks = make([]DirChangeHook, 0)
+)
+
+func RegisterDirChangeHook(hook DirChangeHook) {
+	dirChangeHooks = append(dirChangeHooks, hook)
+}
+
+func HandleDirChangeHook(storage driver.Driver, path string) {
+	if len(dirChangeHooks) == 0 {
+		return
+	}
+	dir := utils.GetFullPath(storage.GetStorage().MountPath, path)
+	for _, hook := range dirChangeHooks {
+		hook(dir)
+	}
+}
+
 // Setting
 type SettingItemHook func(item *model.SettingItem) error
 
@@ -4,17 +4,18 @@ import (
 	stdpath "path"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/internal/cache"
 	"github.com/OpenListTeam/OpenList/internal/db"
 	"github.com/OpenListTeam/OpenList/internal/errs"
 	"github.com/OpenListTeam/OpenList/internal/model"
 	"github.com/OpenListTeam/OpenList/pkg/singleflight"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
-	"github.com/Xhofe/go-cache"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
 
-var metaCache = cache.NewMemCache(cache.WithShards[*model.Meta](2))
+// the metas are kept in memory as they carry the passwords
+var metaCache = cache.NewLocal[*model.Meta]("meta", 2)
 
 // metaG maybe not needed
 var metaG singleflight.Group[*model.Meta]
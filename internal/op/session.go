@@ -0,0 +1,74 @@
+package op
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/cache"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/pkg/singleflight"
+)
+
+// sessionActivityInterval limits how often the last activity is written
+const sessionActivityInterval = time.Minute
+
+var sessionCache = cache.NewShared[*model.Session]("session", 2)
+var sessionG singleflight.Group[*model.Session]
+
+func CreateSession(s *model.Session) error {
+	if err := db.DeleteExpiredSessions(time.Now()); err != nil {
+		return err
+	}
+	return db.CreateSession(s)
+}
+
+func GetSession(id string) (*model.Session, error) {
+	if s, ok := sessionCache.Get(id); ok {
+		return s, nil
+	}
+	s, err, _ := sessionG.Do(id, func() (*model.Session, error) {
+		_s, err := db.GetSessionByID(id)
+		if err != nil {
+			return nil, err
+		}
+		sessionCache.Set(id, _s, cache.WithEx[*model.Session](time.Hour))
+		return _s, nil
+	})
+	return s, err
+}
+
+func GetSessionsByUserID(userID uint) ([]model.Session, error) {
+	return db.GetSessionsByUserID(userID)
+}
+
+// TouchSession records the activity of the session, the cached
+// session is replaced instead of modified as it is shared
+func TouchSession(s *model.Session, ip string) error {
+	now := time.Now()
+	if s.IP == ip && now.Sub(s.LastActive) < sessionActivityInterval {
+		return nil
+	}
+	if err := db.UpdateSessionActivity(s.ID, ip, now); err != nil {
+		return err
+	}
+	ns := *s
+	ns.IP, ns.LastActive = ip, now
+	sessionCache.Set(s.ID, &ns, cache.WithEx[*model.Session](time.Hour))
+	return nil
+}
+
+func DeleteSession(id string) error {
+	sessionCache.Del(id)
+	return db.DeleteSessionByID(id)
+}
+
+func DeleteSessionsByUserID(userID uint) error {
+	sessions, err := db.GetSessionsByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		sessionCache.Del(s.ID)
+	}
+	return db.DeleteSessionsByUserID(userID)
+}
@@ -6,21 +6,24 @@ import (
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/internal/cache"
 	"github.com/OpenListTeam/OpenList/internal/db"
 	"github.com/OpenListTeam/OpenList/internal/model"
 	"github.com/OpenListTeam/OpenList/pkg/singleflight"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
-	"github.com/Xhofe/go-cache"
 	"github.com/pkg/errors"
 )
 
-var settingCache = cache.NewMemCache(cache.WithShards[*model.SettingItem](4))
+// the settings are cached by each node as the private ones, such as the
+// tokens and the secrets, mustn't leave the node, only the changes are
+// propagated to the other nodes
+var settingCache = cache.NewLocal[*model.SettingItem]("setting", 4)
 var settingG singleflight.Group[*model.SettingItem]
 var settingCacheF = func(item *model.SettingItem) {
 	settingCache.Set(item.Key, item, cache.WithEx[*model.SettingItem](time.Hour))
 }
 
-var settingGroupCache = cache.NewMemCache(cache.WithShards[[]model.SettingItem](4))
+var settingGroupCache = cache.NewLocal[[]model.SettingItem]("setting_group", 4)
 var settingGroupG singleflight.Group[[]model.SettingItem]
 var settingGroupCacheF = func(key string, item []model.SettingItem) {
 	settingGroupCache.Set(key, item, cache.WithEx[[]model.SettingItem](time.Hour))
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/internal/cache"
+	"github.com/OpenListTeam/OpenList/internal/cluster"
 	"github.com/OpenListTeam/OpenList/internal/db"
 	"github.com/OpenListTeam/OpenList/internal/driver"
 	"github.com/OpenListTeam/OpenList/internal/errs"
@@ -105,12 +108,28 @@ func initStorage(ctx context.Context, storage model.Storage, storageDriver drive
 			storagesMap.Store(driverStorage.MountPath, storageDriver)
 		}
 	}()
+	err = initDriver(ctx, storageDriver)
+	storagesMap.Store(driverStorage.MountPath, storageDriver)
+	if err != nil {
+		driverStorage.SetStatus(err.Error())
+		err = errors.Wrap(err, "failed init storage")
+	} else {
+		driverStorage.SetStatus(WORK)
+	}
+	MustSaveDriverStorage(storageDriver)
+	return err
+}
+
+// initDriver unmarshals the addition of the storage set to the driver and
+// initializes the driver
+func initDriver(ctx context.Context, storageDriver driver.Driver) (err error) {
+	storage := storageDriver.GetStorage()
 	// Unmarshal Addition
-	err = utils.Json.UnmarshalFromString(driverStorage.Addition, storageDriver.GetAddition())
+	err = utils.Json.UnmarshalFromString(storage.Addition, storageDriver.GetAddition())
 	if err == nil {
 		if ref, ok := storageDriver.(driver.Reference); ok {
-			if strings.HasPrefix(driverStorage.Remark, "ref:/") {
-				refMountPath := driverStorage.Remark
+			if strings.HasPrefix(storage.Remark, "ref:/") {
+				refMountPath := storage.Remark
 				i := strings.Index(refMountPath, "\n")
 				if i > 0 {
 					refMountPath = refMountPath[4:i]
@@ -133,14 +152,6 @@ func initStorage(ctx context.Context, storage model.Storage, storageDriver drive
 	if err == nil {
 		err = storageDriver.Init(ctx)
 	}
-	storagesMap.Store(driverStorage.MountPath, storageDriver)
-	if err != nil {
-		driverStorage.SetStatus(err.Error())
-		err = errors.Wrap(err, "failed init storage")
-	} else {
-		driverStorage.SetStatus(WORK)
-	}
-	MustSaveDriverStorage(storageDriver)
 	return err
 }
 
@@ -253,6 +264,9 @@ func DeleteStorageById(ctx context.Context, id uint) error {
 	if err := db.DeleteStorageById(id); err != nil {
 		return errors.WithMessage(err, "failed delete storage in database")
 	}
+	if err := db.DeleteHashCachesByStorageID(id); err != nil {
+		return errors.WithMessage(err, "failed delete hash caches of storage")
+	}
 	return nil
 }
 
@@ -271,14 +285,102 @@ func saveDriverStorage(driver driver.Driver) error {
 	if err != nil {
 		return errors.Wrap(err, "error while marshal addition")
 	}
+	changed := str != storage.Addition
 	storage.Addition = str
 	err = db.UpdateStorage(storage)
 	if err != nil {
 		return errors.WithMessage(err, "failed update storage in database")
 	}
+	if changed {
+		// the other nodes use the refreshed tokens too
+		cache.Publish(storageAdditionTopic, strconv.FormatUint(uint64(storage.ID), 10))
+	}
 	return nil
 }
 
+const storageAdditionTopic = "storage_addition"
+
+func init() {
+	cache.Subscribe(storageAdditionTopic, reloadStorage)
+}
+
+// reloadStorage initializes the storage saved by another node again, the
+// loaded driver is replaced instead of modified as it may be in use
+func reloadStorage(payload string) {
+	id, err := strconv.ParseUint(payload, 10, 64)
+	if err != nil {
+		return
+	}
+	storage, err := db.GetStorageById(uint(id))
+	if err != nil {
+		log.Warnf("failed get storage [%d] to reload: %+v", id, err)
+		return
+	}
+	oldDriver, err := GetStorageByMountPath(storage.MountPath)
+	if err != nil {
+		return
+	}
+	driverNew, err := GetDriver(storage.Driver)
+	if err != nil {
+		return
+	}
+	ctx := context.Background()
+	storageDriver := driverNew()
+	storageDriver.SetStorage(*storage)
+	// the old driver keeps serving until the new one is initialized
+	if err = reinitDriver(ctx, storageDriver); err != nil {
+		log.Warnf("failed reload storage [%s]: %+v", storage.MountPath, err)
+		return
+	}
+	storageDriver.GetStorage().SetStatus(WORK)
+	storagesMap.Store(storage.MountPath, storageDriver)
+	if err = oldDriver.Drop(ctx); err != nil {
+		log.Warnf("failed drop the replaced storage [%s]: %+v", storage.MountPath, err)
+	}
+	go callStorageHooks("update", storageDriver)
+}
+
+// reinitDriver is initDriver recovering from the panic of the driver, the
+// driver failed to init is dropped to stop what it has started
+func reinitDriver(ctx context.Context, storageDriver driver.Driver) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("[panic] err: %v\nstack: %s", e, getCurrentGoroutineStack())
+		}
+		if err != nil {
+			func() {
+				defer func() { _ = recover() }()
+				_ = storageDriver.Drop(ctx)
+			}()
+		}
+	}()
+	return initDriver(ctx, storageDriver)
+}
+
+// LockDriverToken is called by the drivers before refreshing their tokens, so
+// the tokens are refreshed by one node at a time and the others reload them
+func LockDriverToken(driver driver.Driver) (unlock func(), err error) {
+	storage := driver.GetStorage()
+	unlock, ok := cluster.TryLock("token:" + strconv.FormatUint(uint64(storage.ID), 10))
+	if !ok {
+		return nil, errors.New("the token is being refreshed by another node, please retry later")
+	}
+	if !cluster.Enabled() {
+		return unlock, nil
+	}
+	saved, err := db.GetStorageById(storage.ID)
+	if err != nil {
+		unlock()
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	if saved.Addition != storage.Addition {
+		// the storage is reloaded with them soon
+		unlock()
+		return nil, errors.New("the token has been refreshed by another node, please retry later")
+	}
+	return unlock, nil
+}
+
 // getStoragesByPath get storage by longest match path, contains balance storage.
 // for example, there is /a/b,/a/c,/a/d/e,/a/d/e.balance
 // getStoragesByPath(/a/d/e/f) => /a/d/e,/a/d/e.balance
@@ -0,0 +1,85 @@
+package op
+
+import (
+	"regexp"
+
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultSubscriptionInterval = 30
+	MinSubscriptionInterval     = 5
+)
+
+func validateSubscription(s *model.Subscription) error {
+	if s.URL == "" {
+		return errors.New("url is required")
+	}
+	if s.DstDir == "" {
+		return errors.New("dst dir is required")
+	}
+	if _, err := regexp.Compile(s.Include); err != nil {
+		return errors.WithMessage(err, "invalid include regexp")
+	}
+	if _, err := regexp.Compile(s.Exclude); err != nil {
+		return errors.WithMessage(err, "invalid exclude regexp")
+	}
+	if s.MaxSize > 0 && s.MinSize > s.MaxSize {
+		return errors.New("min size is larger than max size")
+	}
+	if s.Interval == 0 {
+		s.Interval = DefaultSubscriptionInterval
+	} else if s.Interval < MinSubscriptionInterval {
+		s.Interval = MinSubscriptionInterval
+	}
+	return nil
+}
+
+func CreateSubscription(s *model.Subscription) error {
+	if err := validateSubscription(s); err != nil {
+		return err
+	}
+	s.ID = 0
+	return db.CreateSubscription(s)
+}
+
+func UpdateSubscription(s *model.Subscription) error {
+	if err := validateSubscription(s); err != nil {
+		return err
+	}
+	return db.UpdateSubscription(s)
+}
+
+func UpdateSubscriptionFetched(s *model.Subscription) error {
+	return db.UpdateSubscriptionFetched(s)
+}
+
+func GetSubscriptionById(id uint) (*model.Subscription, error) {
+	return db.GetSubscriptionById(id)
+}
+
+func GetSubscriptions(userId uint, pageIndex, pageSize int) ([]model.Subscription, int64, error) {
+	return db.GetSubscriptions(userId, pageIndex, pageSize)
+}
+
+func GetEnabledSubscriptions() ([]model.Subscription, error) {
+	return db.GetEnabledSubscriptions()
+}
+
+func DeleteSubscriptionById(id uint) error {
+	return db.DeleteSubscriptionById(id)
+}
+
+func GetSubscriptionItemsByGUIDs(subscriptionId uint, guids []string) ([]model.SubscriptionItem, error) {
+	return db.GetSubscriptionItemsByGUIDs(subscriptionId, guids)
+}
+
+func SaveSubscriptionItem(item *model.SubscriptionItem) error {
+	return db.SaveSubscriptionItem(item)
+}
+
+func GetSubscriptionItems(subscriptionId uint, pageIndex, pageSize int) ([]model.SubscriptionItem, int64, error) {
+	return db.GetSubscriptionItems(subscriptionId, pageIndex, pageSize)
+}
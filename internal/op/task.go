@@ -0,0 +1,10 @@
+package op
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/model"
+)
+
+func GetTasks(q model.TaskQuery) ([]model.Task, int64, error) {
+	return db.GetTasks(q)
+}
@@ -3,19 +3,45 @@ package op
 import (
 	"time"
 
+	"github.com/OpenListTeam/OpenList/internal/cache"
 	"github.com/OpenListTeam/OpenList/internal/db"
 	"github.com/OpenListTeam/OpenList/internal/errs"
 	"github.com/OpenListTeam/OpenList/internal/model"
 	"github.com/OpenListTeam/OpenList/pkg/singleflight"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
-	"github.com/Xhofe/go-cache"
 )
 
-var userCache = cache.NewMemCache(cache.WithShards[*model.User](2))
+// the users are kept in memory as they carry the password hashes
+var userCache = cache.NewLocal[*model.User]("user", 2)
 var userG singleflight.Group[*model.User]
 var guestUser *model.User
 var adminUser *model.User
 
+const roleUserTopic = "role_user"
+
+func init() {
+	cache.Subscribe(roleUserTopic, func(payload string) {
+		switch payload {
+		case "admin":
+			adminUser = nil
+		case "guest":
+			guestUser = nil
+		}
+	})
+}
+
+// resetRoleUser drops the cached admin or guest on all the nodes
+func resetRoleUser(u *model.User) {
+	if u.IsAdmin() {
+		adminUser = nil
+		cache.Publish(roleUserTopic, "admin")
+	}
+	if u.IsGuest() {
+		guestUser = nil
+		cache.Publish(roleUserTopic, "guest")
+	}
+}
+
 func GetAdmin() (*model.User, error) {
 	if adminUser == nil {
 		user, err := db.GetUserByRole(model.ADMIN)
@@ -70,6 +96,7 @@ func GetUsers(pageIndex, pageSize int) (users []model.User, count int64, err err
 
 func CreateUser(u *model.User) error {
 	u.BasePath = utils.FixAndCleanPath(u.BasePath)
+	u.FtpRoot = utils.FixAndCleanPath(u.FtpRoot)
 	return db.CreateUser(u)
 }
 
@@ -82,6 +109,9 @@ func DeleteUserById(id uint) error {
 		return errs.DeleteAdminOrGuest
 	}
 	userCache.Del(old.Username)
+	if err := DeleteSessionsByUserID(id); err != nil {
+		return err
+	}
 	return db.DeleteUserById(id)
 }
 
@@ -90,14 +120,10 @@ func UpdateUser(u *model.User) error {
 	if err != nil {
 		return err
 	}
-	if u.IsAdmin() {
-		adminUser = nil
-	}
-	if u.IsGuest() {
-		guestUser = nil
-	}
+	resetRoleUser(u)
 	userCache.Del(old.Username)
 	u.BasePath = utils.FixAndCleanPath(u.BasePath)
+	u.FtpRoot = utils.FixAndCleanPath(u.FtpRoot)
 	return db.UpdateUser(u)
 }
 
@@ -119,12 +145,7 @@ func DelUserCache(username string) error {
 	if err != nil {
 		return err
 	}
-	if user.IsAdmin() {
-		adminUser = nil
-	}
-	if user.IsGuest() {
-		guestUser = nil
-	}
+	resetRoleUser(user)
 	userCache.Del(username)
 	return nil
 }
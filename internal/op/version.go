@@ -0,0 +1,55 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/internal/driver"
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+func getVersionedFile(ctx context.Context, storage driver.Driver, path string) (driver.Versioner, model.Obj, error) {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return nil, nil, errors.Errorf("storage not init: %s", storage.GetStorage().Status)
+	}
+	v, ok := storage.(driver.Versioner)
+	if !ok {
+		return nil, nil, errs.NotImplement
+	}
+	file, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed to get file")
+	}
+	if file.IsDir() {
+		return nil, nil, errors.WithStack(errs.NotFile)
+	}
+	return v, file, nil
+}
+
+// ListVersions lists the previous revisions of the file
+func ListVersions(ctx context.Context, storage driver.Driver, path string) ([]model.Obj, error) {
+	v, file, err := getVersionedFile(ctx, storage, path)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := v.ListVersions(ctx, file)
+	return versions, errors.WithStack(err)
+}
+
+// RestoreVersion makes the revision the current content of the file
+func RestoreVersion(ctx context.Context, storage driver.Driver, path, version string) error {
+	path = utils.FixAndCleanPath(path)
+	v, file, err := getVersionedFile(ctx, storage, path)
+	if err != nil {
+		return err
+	}
+	err = v.RestoreVersion(ctx, file, version)
+	if err == nil {
+		ClearCache(storage, stdpath.Dir(path))
+		linkCache.Del(Key(storage, path))
+	}
+	return errors.WithStack(err)
+}
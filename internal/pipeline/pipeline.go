@@ -0,0 +1,406 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/task"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/pkg/errors"
+	"github.com/xhofe/tache"
+)
+
+type StepType string
+
+const (
+	StepOfflineDownload StepType = "offline_download"
+	StepDecompress      StepType = "decompress"
+	StepCopy            StepType = "copy"
+	StepMove            StepType = "move"
+	StepRemove          StepType = "remove"
+)
+
+type StepState string
+
+const (
+	StepPending   StepState = "pending"
+	StepRunning   StepState = "running"
+	StepSucceeded StepState = "succeeded"
+	StepFailed    StepState = "failed"
+	// StepSkipped means the step whose outputs are the inputs didn't succeed
+	StepSkipped StepState = "skipped"
+)
+
+type FailurePolicy string
+
+const (
+	// FailStop stops the pipeline at the failed step
+	FailStop FailurePolicy = "stop"
+	// FailContinue keeps running the steps not depending on the failed one
+	FailContinue FailurePolicy = "continue"
+)
+
+type Step struct {
+	Name string   `json:"name"`
+	Type StepType `json:"type"`
+	// From is the name of the step whose outputs are the inputs of this
+	// step, the previous step is used if both From and Src are empty
+	From   string   `json:"from"`
+	Src    []string `json:"src"`
+	DstDir string   `json:"dst_dir"`
+	// MaxRetry is the times to run the step again before it fails
+	MaxRetry int `json:"max_retry"`
+
+	// offline_download
+	Urls         []string `json:"urls,omitempty"`
+	Tool         string   `json:"tool,omitempty"`
+	DeletePolicy string   `json:"delete_policy,omitempty"`
+	// decompress, the archive password is only kept in memory, see
+	// MarshalJSON
+	ArchivePass    string `json:"archive_pass,omitempty"`
+	HasArchivePass bool   `json:"has_archive_pass,omitempty"`
+	InnerPath      string `json:"inner_path,omitempty"`
+	CacheFull      bool   `json:"cache_full,omitempty"`
+	PutIntoNewDir  bool   `json:"put_into_new_dir,omitempty"`
+
+	State   StepState `json:"state"`
+	Retried int       `json:"retried"`
+	Group   string    `json:"group"`
+	Outputs []string  `json:"outputs"`
+	Error   string    `json:"error"`
+}
+
+func (s *Step) needInputs() bool {
+	return s.Type != StepOfflineDownload
+}
+
+// MarshalJSON keeps the archive password out of the persisted task and the
+// task list
+func (s Step) MarshalJSON() ([]byte, error) {
+	type alias Step
+	a := alias(s)
+	a.HasArchivePass = s.HasArchivePass || s.ArchivePass != ""
+	a.ArchivePass = ""
+	return json.Marshal(a)
+}
+
+var errArchivePassLost = errors.New("the archive password isn't kept after restart, please add the pipeline again")
+
+// PipelineTask runs the steps one by one, each step starts the tasks of its
+// type in a group and waits for all of them to finish
+type PipelineTask struct {
+	task.TaskExtension
+	Name      string        `json:"name"`
+	OnFailure FailurePolicy `json:"on_failure"`
+	Steps     []*Step       `json:"steps"`
+	mu        sync.Mutex
+	status    string
+}
+
+func (t *PipelineTask) GetName() string {
+	return fmt.Sprintf("pipeline %s (%d steps)", t.Name, len(t.Steps))
+}
+
+func (t *PipelineTask) GetStatus() string {
+	return t.status
+}
+
+// GetSteps returns a copy of the steps with their states
+func (t *PipelineTask) GetSteps() []Step {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	steps := make([]Step, len(t.Steps))
+	for i, s := range t.Steps {
+		steps[i] = *s
+	}
+	return steps
+}
+
+func (t *PipelineTask) MarshalJSON() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	type alias PipelineTask
+	return json.Marshal((*alias)(t))
+}
+
+func (t *PipelineTask) updateStep(i int, f func(s *Step)) {
+	t.mu.Lock()
+	f(t.Steps[i])
+	var done int
+	for _, s := range t.Steps {
+		if s.State == StepSucceeded || s.State == StepFailed || s.State == StepSkipped {
+			done++
+		}
+	}
+	t.mu.Unlock()
+	t.SetProgress(float64(done) / float64(len(t.Steps)) * 100)
+	t.Persist()
+}
+
+func (t *PipelineTask) step(i int) Step {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return *t.Steps[i]
+}
+
+func (t *PipelineTask) Run() error {
+	t.ReinitCtx()
+	t.ClearEndTime()
+	t.SetStartTime(time.Now())
+	defer func() { t.SetEndTime(time.Now()) }()
+	// the failed steps and the ones skipped because of them are run again
+	for i := range t.Steps {
+		t.updateStep(i, func(s *Step) {
+			if s.State == StepFailed || s.State == StepSkipped {
+				s.State = StepPending
+				s.Retried = 0
+				s.Error = ""
+			}
+		})
+	}
+	var failed []string
+	for i := range t.Steps {
+		if utils.IsCanceled(t.Ctx()) {
+			return t.Ctx().Err()
+		}
+		step := t.step(i)
+		if step.State == StepSucceeded {
+			continue
+		}
+		var inputs []string
+		if step.needInputs() {
+			var ok bool
+			if inputs, ok = t.inputs(i); !ok {
+				t.updateStep(i, func(s *Step) {
+					s.State = StepSkipped
+					s.Error = "the step providing the inputs didn't succeed"
+				})
+				continue
+			}
+		}
+		outputs, err := t.runStep(i, inputs)
+		if err != nil {
+			if utils.IsCanceled(t.Ctx()) {
+				// the tasks of the step are canceled, run it from the start on resume
+				t.updateStep(i, func(s *Step) {
+					s.State = StepPending
+					s.Group = ""
+				})
+				return t.Ctx().Err()
+			}
+			t.updateStep(i, func(s *Step) {
+				s.State = StepFailed
+				s.Error = err.Error()
+			})
+			if t.OnFailure != FailContinue {
+				return errors.WithMessagef(err, "step [%s] failed", step.Name)
+			}
+			failed = append(failed, step.Name)
+			continue
+		}
+		t.updateStep(i, func(s *Step) {
+			s.State = StepSucceeded
+			s.Outputs = outputs
+		})
+	}
+	t.status = "all steps finished"
+	if len(failed) > 0 {
+		return errors.Errorf("steps [%s] failed", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// inputs returns the inputs of the step, false if the step providing them
+// didn't succeed
+func (t *PipelineTask) inputs(i int) ([]string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	step := t.Steps[i]
+	if len(step.Src) > 0 {
+		return step.Src, true
+	}
+	from := i - 1
+	if step.From != "" {
+		from = indexOf(t.Steps, step.From)
+	}
+	if from < 0 || t.Steps[from].State != StepSucceeded {
+		return nil, false
+	}
+	return t.Steps[from].Outputs, true
+}
+
+func (t *PipelineTask) runStep(i int, inputs []string) ([]string, error) {
+	for {
+		outputs, err := t.execStep(i, inputs)
+		step := t.step(i)
+		if err == nil || utils.IsCanceled(t.Ctx()) || step.Retried >= step.MaxRetry {
+			return outputs, err
+		}
+		t.updateStep(i, func(s *Step) {
+			s.Retried++
+			s.Group = ""
+			s.Error = err.Error()
+		})
+	}
+}
+
+func (t *PipelineTask) execStep(i int, inputs []string) ([]string, error) {
+	step := t.step(i)
+	t.status = fmt.Sprintf("step %d/%d [%s]: starting", i+1, len(t.Steps), step.Name)
+	// the tasks of the step may be recovered after restarting
+	if step.State != StepRunning || len(task.GetByGroup(step.Group)) == 0 {
+		step.Group = fmt.Sprintf("%s-%d-%d", t.GetID(), i, time.Now().UnixNano())
+		t.updateStep(i, func(s *Step) {
+			s.State = StepRunning
+			s.Group = step.Group
+			s.Outputs = nil
+		})
+		ctx := context.WithValue(t.Ctx(), conf.TaskGroupKey, step.Group)
+		outputs, err := start(ctx, &step, inputs)
+		if err != nil {
+			task.CancelGroup(step.Group)
+			return nil, err
+		}
+		t.updateStep(i, func(s *Step) {
+			s.Outputs = outputs
+		})
+		step.Outputs = outputs
+	}
+	if err := t.wait(i, step.Group); err != nil {
+		return nil, err
+	}
+	if step.Type == StepOfflineDownload {
+		return append(step.Outputs, downloadOutputs(step)...), nil
+	}
+	return step.Outputs, nil
+}
+
+// Coordinator keeps the pipeline from taking the running slots of its
+// creator, the tasks of the steps need them
+func (t *PipelineTask) Coordinator() bool {
+	return true
+}
+
+// wait waits for the tasks in the group to finish
+func (t *PipelineTask) wait(i int, group string) error {
+	step := t.step(i)
+	for {
+		tasks := task.GetByGroup(group)
+		var running int
+		var errs []string
+		for _, tsk := range tasks {
+			switch {
+			case tsk.GetState() == tache.StateSucceeded:
+			case utils.SliceContains(task.FinishedStates, int(tsk.GetState())):
+				errs = append(errs, fmt.Sprintf("%s: %v", tsk.GetName(), tsk.GetErr()))
+			default:
+				running++
+			}
+		}
+		if running == 0 {
+			if len(errs) > 0 {
+				return errors.New(strings.Join(errs, "; "))
+			}
+			return nil
+		}
+		t.status = fmt.Sprintf("step %d/%d [%s]: waiting for %d of %d tasks", i+1, len(t.Steps), step.Name,
+			running, len(tasks))
+		select {
+		case <-t.CtxDone():
+			task.CancelGroup(group)
+			return t.Ctx().Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func indexOf(steps []*Step, name string) int {
+	for i, s := range steps {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Validate checks the definition of the steps, the steps without a name
+// are named by their positions
+func Validate(steps []*Step, onFailure FailurePolicy) error {
+	if len(steps) == 0 {
+		return errors.New("no steps")
+	}
+	if onFailure != "" && onFailure != FailStop && onFailure != FailContinue {
+		return errors.Errorf("invalid failure policy [%s]", onFailure)
+	}
+	for i, s := range steps {
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("%d", i+1)
+		}
+		if idx := indexOf(steps, s.Name); idx != i {
+			return errors.Errorf("duplicate step name [%s]", s.Name)
+		}
+		switch s.Type {
+		case StepOfflineDownload:
+			if len(s.Urls) == 0 || s.Tool == "" {
+				return errors.Errorf("step [%s] needs urls and tool", s.Name)
+			}
+		case StepDecompress, StepCopy, StepMove, StepRemove:
+		default:
+			return errors.Errorf("step [%s] has invalid type [%s]", s.Name, s.Type)
+		}
+		if s.Type != StepRemove && s.DstDir == "" {
+			return errors.Errorf("step [%s] needs dst_dir", s.Name)
+		}
+		if s.From != "" {
+			if idx := indexOf(steps, s.From); idx < 0 || idx >= i {
+				return errors.Errorf("step [%s] is from [%s] which isn't a previous step", s.Name, s.From)
+			}
+		}
+		if s.needInputs() && len(s.Src) == 0 && s.From == "" && i == 0 {
+			return errors.Errorf("step [%s] has no inputs", s.Name)
+		}
+		if s.MaxRetry < 0 {
+			s.MaxRetry = 0
+		}
+	}
+	return nil
+}
+
+// Add validates the steps and adds the pipeline, the paths of the steps
+// must be the full paths
+func Add(ctx context.Context, name string, onFailure FailurePolicy, steps []*Step) (*PipelineTask, error) {
+	if err := Validate(steps, onFailure); err != nil {
+		return nil, err
+	}
+	if onFailure == "" {
+		onFailure = FailStop
+	}
+	for _, s := range steps {
+		s.State = StepPending
+		s.Retried = 0
+		s.Group = ""
+		s.Outputs = nil
+		s.Error = ""
+		s.HasArchivePass = s.ArchivePass != ""
+	}
+	taskCreator, _ := ctx.Value("user").(*model.User)
+	t := &PipelineTask{
+		TaskExtension: task.TaskExtension{
+			Creator:  taskCreator,
+			Priority: task.PriorityFromCtx(ctx),
+		},
+		Name:      name,
+		OnFailure: onFailure,
+		Steps:     steps,
+	}
+	PipelineTaskManager.Add(t)
+	return t, nil
+}
+
+var PipelineTaskManager *task.Scheduler[*PipelineTask]
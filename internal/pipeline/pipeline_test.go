@@ -0,0 +1,61 @@
+package pipeline
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name  string
+		steps []*Step
+		ok    bool
+	}{
+		{"empty", nil, false},
+		{"download", []*Step{
+			{Name: "dl", Type: StepOfflineDownload, Urls: []string{"https://example.com/a.zip"}, Tool: "SimpleHttp", DstDir: "/tmp"},
+			{Type: StepDecompress, DstDir: "/out"},
+			{Type: StepCopy, DstDir: "/s3"},
+			{Type: StepRemove, From: "dl"},
+		}, true},
+		{"no inputs", []*Step{{Type: StepCopy, DstDir: "/s3"}}, false},
+		{"no dst", []*Step{{Type: StepMove, Src: []string{"/a"}}}, false},
+		{"unknown from", []*Step{
+			{Type: StepRemove, Src: []string{"/a"}},
+			{Type: StepRemove, From: "x"},
+		}, false},
+		{"later from", []*Step{
+			{Type: StepRemove, Src: []string{"/a"}, From: "2"},
+			{Type: StepRemove, Src: []string{"/b"}},
+		}, false},
+		{"duplicate", []*Step{
+			{Name: "a", Type: StepRemove, Src: []string{"/a"}},
+			{Name: "a", Type: StepRemove, Src: []string{"/b"}},
+		}, false},
+		{"bad type", []*Step{{Type: "rename", Src: []string{"/a"}, DstDir: "/b"}}, false},
+	}
+	for _, c := range cases {
+		if err := Validate(c.steps, ""); (err == nil) != c.ok {
+			t.Errorf("%s: expected ok %v, got %v", c.name, c.ok, err)
+		}
+	}
+	if err := Validate([]*Step{{Type: StepRemove, Src: []string{"/a"}}}, "retry"); err == nil {
+		t.Errorf("expected the invalid failure policy rejected")
+	}
+}
+
+func TestInputs(t *testing.T) {
+	p := &PipelineTask{Steps: []*Step{
+		{Name: "a", State: StepSucceeded, Outputs: []string{"/a"}},
+		{Name: "b", State: StepFailed},
+		{Name: "c"},
+		{Name: "d", From: "a"},
+		{Name: "e", Src: []string{"/e"}},
+	}}
+	if _, ok := p.inputs(2); ok {
+		t.Errorf("expected no inputs after a failed step")
+	}
+	if inputs, ok := p.inputs(3); !ok || len(inputs) != 1 || inputs[0] != "/a" {
+		t.Errorf("unexpected inputs from a: %v", inputs)
+	}
+	if inputs, ok := p.inputs(4); !ok || inputs[0] != "/e" {
+		t.Errorf("unexpected inputs of e: %v", inputs)
+	}
+}
@@ -0,0 +1,112 @@
+package pipeline
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/drivers/local"
+	_ "github.com/OpenListTeam/OpenList/internal/archive/zip"
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/fs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	_ "github.com/OpenListTeam/OpenList/internal/offline_download/http"
+	"github.com/OpenListTeam/OpenList/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/task"
+	"github.com/xhofe/tache"
+)
+
+// initRunTest mounts a local storage of a temporary dir on /local and starts
+// the schedulers of the steps
+func initRunTest(t *testing.T) string {
+	db.Init(dbtest.Open(t))
+	conf.Conf.TempDir = t.TempDir()
+	root := t.TempDir()
+	ctx := context.Background()
+	id, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  fmt.Sprintf(`{"root_folder_path":%q}`, root),
+	})
+	if err != nil {
+		t.Fatalf("failed to create local storage: %+v", err)
+	}
+	t.Cleanup(func() { _ = op.DeleteStorageById(ctx, id) })
+	tool.DownloadTaskManager = task.NewScheduler[*tool.DownloadTask]("download", false, tache.WithWorks(2))
+	tool.TransferTaskManager = task.NewScheduler[*tool.TransferTask]("transfer", false, tache.WithWorks(2))
+	fs.ArchiveDownloadTaskManager = task.NewScheduler[*fs.ArchiveDownloadTask]("decompress", false, tache.WithWorks(2))
+	fs.ArchiveContentUploadTaskManager.Scheduler = task.NewScheduler[*fs.ArchiveContentUploadTask]("decompress_upload", false, tache.WithWorks(2))
+	PipelineTaskManager = task.NewScheduler[*PipelineTask]("pipeline", false, tache.WithWorks(1))
+	return root
+}
+
+func TestRunDownloadDecompressMove(t *testing.T) {
+	root := initRunTest(t)
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	w, _ := zw.Create("docs/hello.txt")
+	_, _ = w.Write([]byte("hello pipeline"))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "a.zip", time.Now(), bytes.NewReader(archive.Bytes()))
+	}))
+	defer srv.Close()
+	for _, dir := range []string{"extract", "done"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := Add(context.Background(), "test", FailStop, []*Step{
+		{Name: "download", Type: StepOfflineDownload, Urls: []string{srv.URL + "/a.zip"}, Tool: "SimpleHttp", DstDir: "/local/download"},
+		{Name: "extract", Type: StepDecompress, DstDir: "/local/extract"},
+		{Name: "move", Type: StepMove, DstDir: "/local/done"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	PipelineTaskManager.Wait()
+	if p.GetState() != tache.StateSucceeded {
+		t.Fatalf("the pipeline failed: %v, steps: %+v", p.GetErr(), p.GetSteps())
+	}
+	got, err := os.ReadFile(filepath.Join(root, "done", "extract", "docs", "hello.txt"))
+	if err != nil || string(got) != "hello pipeline" {
+		t.Fatalf("the extracted file is not moved: %q, %v", got, err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "extract")); !os.IsNotExist(err) {
+		t.Errorf("the extracted dir is not moved away: %v", err)
+	}
+}
+
+func TestArchivePassNotPersisted(t *testing.T) {
+	p := &PipelineTask{Steps: []*Step{
+		{Name: "extract", Type: StepDecompress, Src: []string{"/a.zip"}, DstDir: "/out", ArchivePass: "secret", HasArchivePass: true},
+	}}
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("secret")) {
+		t.Fatalf("expected the archive password not persisted: %s", b)
+	}
+	var recovered PipelineTask
+	if err := json.Unmarshal(b, &recovered); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := start(context.Background(), recovered.Steps[0], []string{"/a.zip"}); err != errArchivePassLost {
+		t.Fatalf("expected the recovered step to fail for the lost password, got %v", err)
+	}
+}
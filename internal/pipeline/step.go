@@ -0,0 +1,123 @@
+package pipeline
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/internal/fs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/internal/task"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// start starts the tasks of the step in the group of the context, the
+// outputs known before the tasks finish are returned
+func start(ctx context.Context, step *Step, inputs []string) ([]string, error) {
+	var outputs []string
+	switch step.Type {
+	case StepOfflineDownload:
+		for _, u := range step.Urls {
+			t, err := tool.AddURL(ctx, &tool.AddURLArgs{
+				URL:          u,
+				DstDirPath:   step.DstDir,
+				Tool:         step.Tool,
+				DeletePolicy: tool.DeletePolicy(step.DeletePolicy),
+			})
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed add [%s]", u)
+			}
+			// the url is put to the storage directly
+			if t == nil {
+				outputs = append(outputs, stdpath.Join(step.DstDir, urlName(u)))
+			}
+		}
+	case StepDecompress:
+		if step.HasArchivePass && step.ArchivePass == "" {
+			return nil, errArchivePassLost
+		}
+		for _, src := range inputs {
+			_, err := fs.ArchiveDecompress(ctx, src, step.DstDir, model.ArchiveDecompressArgs{
+				ArchiveInnerArgs: model.ArchiveInnerArgs{
+					ArchiveArgs: model.ArchiveArgs{
+						LinkArgs: model.LinkArgs{Header: http.Header{}},
+						Password: step.ArchivePass,
+					},
+					InnerPath: utils.FixAndCleanPath(step.InnerPath),
+				},
+				CacheFull:     step.CacheFull,
+				PutIntoNewDir: step.PutIntoNewDir,
+			})
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed decompress [%s]", src)
+			}
+			dst := step.DstDir
+			if step.PutIntoNewDir {
+				name := stdpath.Base(src)
+				dst = stdpath.Join(dst, strings.TrimSuffix(name, stdpath.Ext(name)))
+			}
+			if !utils.SliceContains(outputs, dst) {
+				outputs = append(outputs, dst)
+			}
+		}
+	case StepCopy:
+		for _, src := range inputs {
+			if _, err := fs.Copy(ctx, src, step.DstDir); err != nil {
+				return nil, errors.WithMessagef(err, "failed copy [%s]", src)
+			}
+			outputs = append(outputs, stdpath.Join(step.DstDir, stdpath.Base(src)))
+		}
+	case StepMove:
+		for _, src := range inputs {
+			if err := fs.Move(ctx, src, step.DstDir); err != nil {
+				return nil, errors.WithMessagef(err, "failed move [%s]", src)
+			}
+			outputs = append(outputs, stdpath.Join(step.DstDir, stdpath.Base(src)))
+		}
+	case StepRemove:
+		for _, src := range inputs {
+			if err := fs.Remove(ctx, src); err != nil {
+				return nil, errors.WithMessagef(err, "failed remove [%s]", src)
+			}
+		}
+	}
+	return outputs, nil
+}
+
+// downloadOutputs returns the objects transferred to the dst dir, or the
+// dst dir itself if the tool downloads to the storage directly
+func downloadOutputs(step Step) []string {
+	dstDir := utils.FixAndCleanPath(step.DstDir)
+	var outputs []string
+	var inPlace bool
+	for _, t := range task.GetByGroup(step.Group) {
+		switch t := t.(type) {
+		case *tool.TransferTask:
+			// the objects in the sub dirs are transferred by the other tasks
+			if utils.FixAndCleanPath(stdpath.Join(t.DstStorageMp, t.DstDirPath)) == dstDir {
+				outputs = append(outputs, stdpath.Join(dstDir, stdpath.Base(t.SrcObjPath)))
+			}
+		case *tool.DownloadTask:
+			if t.PutObjName != "" {
+				outputs = append(outputs, stdpath.Join(dstDir, t.PutObjName))
+			} else if t.TempDir == t.DstDirPath {
+				inPlace = true
+			}
+		}
+	}
+	if inPlace && !utils.SliceContains(outputs, dstDir) {
+		outputs = append(outputs, dstDir)
+	}
+	return outputs
+}
+
+func urlName(u string) string {
+	if parsed, err := url.Parse(u); err == nil {
+		return stdpath.Base(parsed.Path)
+	}
+	return "UnnamedURL"
+}
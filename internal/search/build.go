@@ -9,6 +9,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/internal/cluster"
 	"github.com/OpenListTeam/OpenList/internal/conf"
 	"github.com/OpenListTeam/OpenList/internal/errs"
 	"github.com/OpenListTeam/OpenList/internal/fs"
@@ -26,8 +27,31 @@ var (
 	Quit = atomic.Pointer[chan struct{}]{}
 )
 
+// indexLock keeps the nodes of the cluster from building the index at once
+const indexLock = "build_index"
+
+// lockedByOther caches whether another node builds the index for a while,
+// as Running is checked on every update of the index
+var lockedByOther struct {
+	sync.Mutex
+	checked time.Time
+	locked  bool
+}
+
 func Running() bool {
-	return Quit.Load() != nil
+	if Quit.Load() != nil {
+		return true
+	}
+	if !cluster.Enabled() {
+		return false
+	}
+	lockedByOther.Lock()
+	defer lockedByOther.Unlock()
+	if time.Since(lockedByOther.checked) > 5*time.Second {
+		lockedByOther.locked = cluster.Locked(indexLock)
+		lockedByOther.checked = time.Now()
+	}
+	return lockedByOther.locked
 }
 
 func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth int, count bool) error {
@@ -43,6 +67,12 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 		// other goroutine is running
 		return errs.BuildIndexIsRunning
 	}
+	unlock, ok := cluster.TryLock(indexLock)
+	if !ok {
+		// other node is running
+		Quit.Store(nil)
+		return errs.BuildIndexIsRunning
+	}
 	var (
 		indexMQ = mq.NewInMemoryMQ[ObjWithParent]()
 		running = atomic.Bool{} // current goroutine running
@@ -53,6 +83,7 @@ func BuildIndex(ctx context.Context, indexPaths, ignorePaths []string, maxDepth
 	go func() {
 		ticker := time.NewTicker(time.Second)
 		defer func() {
+			unlock()
 			Quit.Store(nil)
 			wg.Done()
 			// notify walk to exit when StopIndex api called
@@ -35,6 +35,42 @@ var (
 	ServerUploadLimit   Limiter
 )
 
+// BlockBurstLimiter splits WaitN into bursts, so waiting for more tokens
+// than the burst size does not fail
+type BlockBurstLimiter struct {
+	*rate.Limiter
+}
+
+func (l BlockBurstLimiter) WaitN(ctx context.Context, total int) error {
+	for total > 0 {
+		n := l.Burst()
+		if l.Limiter.Limit() == rate.Inf || n > total {
+			n = total
+		}
+		err := l.Limiter.WaitN(ctx, n)
+		if err != nil {
+			return err
+		}
+		total -= n
+	}
+	return nil
+}
+
+// SpeedLimit converts a speed in KB/s to the limit and burst of a limiter,
+// a negative speed means unlimited
+func SpeedLimit(speed int) (rate.Limit, int) {
+	if speed < 0 {
+		return rate.Inf, 0
+	}
+	return rate.Limit(speed) * 1024.0, speed * 1024
+}
+
+// NewSpeedLimiter returns a Limiter limited to speed KB/s
+func NewSpeedLimiter(speed int) Limiter {
+	limit, burst := SpeedLimit(speed)
+	return BlockBurstLimiter{Limiter: rate.NewLimiter(limit, burst)}
+}
+
 type RateLimitReader struct {
 	io.Reader
 	Limiter Limiter
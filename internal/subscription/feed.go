@@ -0,0 +1,147 @@
+package subscription
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Item is an entry of the feed
+type Item struct {
+	GUID       string
+	Title      string
+	URL        string
+	Size       int64
+	Categories []string
+}
+
+// feed supports both RSS 2.0 and Atom
+type feed struct {
+	Items   []rssItem   `xml:"channel>item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	Title      string   `xml:"title"`
+	Link       string   `xml:"link"`
+	GUID       string   `xml:"guid"`
+	Categories []string `xml:"category"`
+	Enclosure  struct {
+		URL    string `xml:"url,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"enclosure"`
+	// the size in the torrent extension of the anime feeds
+	ContentLength int64 `xml:"torrent>contentLength"`
+	// the human readable size of nyaa, such as "1.2 GiB"
+	NyaaSize string `xml:"size"`
+}
+
+type atomEntry struct {
+	ID    string `xml:"id"`
+	Title string `xml:"title"`
+	Links []struct {
+		Href   string `xml:"href,attr"`
+		Rel    string `xml:"rel,attr"`
+		Length int64  `xml:"length,attr"`
+	} `xml:"link"`
+	Categories []struct {
+		Term string `xml:"term,attr"`
+	} `xml:"category"`
+}
+
+func parseFeed(r io.Reader) ([]Item, error) {
+	var f feed
+	decoder := xml.NewDecoder(r)
+	// the feeds are not always in utf-8, the charset is ignored
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	if err := decoder.Decode(&f); err != nil {
+		return nil, fmt.Errorf("failed parse feed: %w", err)
+	}
+	var items []Item
+	for _, i := range f.Items {
+		item := Item{
+			GUID:       strings.TrimSpace(i.GUID),
+			Title:      strings.TrimSpace(i.Title),
+			URL:        strings.TrimSpace(i.Enclosure.URL),
+			Size:       i.Enclosure.Length,
+			Categories: trimAll(i.Categories),
+		}
+		if item.URL == "" {
+			item.URL = strings.TrimSpace(i.Link)
+		}
+		if i.ContentLength > 0 {
+			item.Size = i.ContentLength
+		} else if size, err := parseSize(i.NyaaSize); err == nil && item.Size <= 0 {
+			item.Size = size
+		}
+		items = append(items, item)
+	}
+	for _, e := range f.Entries {
+		item := Item{
+			GUID:  strings.TrimSpace(e.ID),
+			Title: strings.TrimSpace(e.Title),
+		}
+		for _, l := range e.Links {
+			if l.Rel == "enclosure" {
+				item.URL, item.Size = strings.TrimSpace(l.Href), l.Length
+				break
+			}
+			if item.URL == "" && (l.Rel == "" || l.Rel == "alternate") {
+				item.URL = strings.TrimSpace(l.Href)
+			}
+		}
+		for _, c := range e.Categories {
+			item.Categories = append(item.Categories, strings.TrimSpace(c.Term))
+		}
+		items = append(items, item)
+	}
+	for i := range items {
+		if items[i].GUID == "" {
+			items[i].GUID = items[i].URL
+		}
+	}
+	return items, nil
+}
+
+func trimAll(ss []string) []string {
+	res := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if s = strings.TrimSpace(s); s != "" {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+var sizeUnits = map[string]float64{
+	"b": 1, "kb": 1e3, "mb": 1e6, "gb": 1e9, "tb": 1e12,
+	"kib": 1 << 10, "mib": 1 << 20, "gib": 1 << 30, "tib": 1 << 40,
+}
+
+// parseSize parses the human readable size such as "1.2 GiB"
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, err
+	}
+	unit := strings.ToLower(strings.TrimSpace(s[i:]))
+	if unit == "" {
+		unit = "b"
+	}
+	m, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit [%s]", unit)
+	}
+	return int64(n * m), nil
+}
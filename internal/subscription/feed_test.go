@@ -0,0 +1,82 @@
+package subscription
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+)
+
+func TestParseFeed(t *testing.T) {
+	rss := `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:nyaa="https://nyaa.si/xmlns/nyaa">
+<channel>
+	<item>
+		<title>Show - 01 [1080p]</title>
+		<link>https://nyaa.si/download/1.torrent</link>
+		<guid isPermaLink="true">https://nyaa.si/view/1</guid>
+		<nyaa:size>1.5 GiB</nyaa:size>
+		<nyaa:category>Anime - English-translated</nyaa:category>
+	</item>
+	<item>
+		<title>Episode 2</title>
+		<enclosure url="https://example.com/2.mp3" length="1024" type="audio/mpeg"/>
+		<category>Podcast</category>
+	</item>
+</channel>
+</rss>`
+	items, err := parseFeed(strings.NewReader(rss))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	if items[0].GUID != "https://nyaa.si/view/1" || items[0].URL != "https://nyaa.si/download/1.torrent" ||
+		items[0].Size != 3<<29 || items[0].Categories[0] != "Anime - English-translated" {
+		t.Errorf("unexpected item %+v", items[0])
+	}
+	if items[1].GUID != "https://example.com/2.mp3" || items[1].Size != 1024 {
+		t.Errorf("unexpected item %+v", items[1])
+	}
+
+	atom := `<feed xmlns="http://www.w3.org/2005/Atom">
+	<entry>
+		<id>tag:github.com,2008:Repository/1/v1.0.0</id>
+		<title>v1.0.0</title>
+		<link rel="alternate" href="https://github.com/a/b/releases/tag/v1.0.0"/>
+		<category term="release"/>
+	</entry>
+</feed>`
+	items, err = parseFeed(strings.NewReader(atom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].GUID != "tag:github.com,2008:Repository/1/v1.0.0" ||
+		items[0].URL != "https://github.com/a/b/releases/tag/v1.0.0" || items[0].Categories[0] != "release" {
+		t.Errorf("unexpected items %+v", items)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	items := []Item{
+		{GUID: "1", Title: "Show - 01 [1080p]", URL: "u1", Size: 1 << 30, Categories: []string{"Anime"}},
+		{GUID: "2", Title: "Show - 02 [720p]", URL: "u2", Size: 1 << 29, Categories: []string{"Anime"}},
+		{GUID: "3", Title: "Show - 03 [1080p] (batch)", URL: "u3", Size: 1 << 30, Categories: []string{"anime"}},
+		{GUID: "4", Title: "Show - 04 [1080p]", URL: "u4", Size: 1 << 34, Categories: []string{"Anime"}},
+		{GUID: "5", Title: "Show - 05 [1080p]", URL: "u5", Categories: []string{"Music"}},
+	}
+	sub := &model.Subscription{
+		Include:    `1080p`,
+		Exclude:    `(?i)batch`,
+		Categories: "anime, live action",
+		MaxSize:    1 << 32,
+	}
+	res, err := filter(sub, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || res[0].GUID != "1" {
+		t.Errorf("unexpected items %+v", res)
+	}
+}
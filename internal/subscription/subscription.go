@@ -0,0 +1,241 @@
+package subscription
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/cluster"
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/net"
+	"github.com/OpenListTeam/OpenList/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/pkg/cron"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// MaxAttempts is the max times to add a failed item again
+const MaxAttempts = 3
+
+var (
+	fetching sync.Map
+	pollCron *cron.Cron
+)
+
+// Init polls the due subscriptions every minute, only on the leader of the
+// cluster
+func Init() {
+	pollCron = cron.NewCron(time.Minute).LeaderOnly()
+	pollCron.Do(poll)
+}
+
+func poll() {
+	if !conf.StoragesLoaded {
+		return
+	}
+	subs, err := op.GetEnabledSubscriptions()
+	if err != nil {
+		utils.Log.Errorf("failed get subscriptions: %+v", err)
+		return
+	}
+	for i := range subs {
+		sub := &subs[i]
+		if !due(sub) {
+			continue
+		}
+		go func() {
+			unlock, err := lock(sub.ID)
+			if err != nil {
+				return
+			}
+			defer unlock()
+			// the subscription may be fetched manually after it's listed
+			sub, err := op.GetSubscriptionById(sub.ID)
+			if err != nil || sub.Disabled || !due(sub) {
+				return
+			}
+			if err := fetchAndSave(context.Background(), sub); err != nil {
+				utils.Log.Warnf("failed fetch subscription [%s]: %+v", sub.Name, err)
+			}
+		}()
+	}
+}
+
+func due(sub *model.Subscription) bool {
+	return sub.LastFetched == nil || time.Since(*sub.LastFetched) >= time.Duration(sub.Interval)*time.Minute
+}
+
+// lock prevents the subscription from being fetched by the poll and the
+// manual fetch at the same time, on the node and across the cluster
+func lock(id uint) (func(), error) {
+	if _, ok := fetching.LoadOrStore(id, struct{}{}); ok {
+		return nil, errors.New("the subscription is being fetched")
+	}
+	unlock, ok := cluster.TryLock("subscription:" + strconv.FormatUint(uint64(id), 10))
+	if !ok {
+		fetching.Delete(id)
+		return nil, errors.New("the subscription is being fetched")
+	}
+	return func() {
+		unlock()
+		fetching.Delete(id)
+	}, nil
+}
+
+// Fetch fetches the feed of the subscription and adds the matched items
+// which haven't been added as offline download tasks of the owner
+func Fetch(ctx context.Context, sub *model.Subscription) error {
+	unlock, err := lock(sub.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return fetchAndSave(ctx, sub)
+}
+
+func fetchAndSave(ctx context.Context, sub *model.Subscription) error {
+	err := fetch(ctx, sub)
+	now := time.Now()
+	sub.LastFetched = &now
+	sub.LastError = ""
+	if err != nil {
+		sub.LastError = err.Error()
+	} else {
+		sub.Initialized = true
+	}
+	if uErr := op.UpdateSubscriptionFetched(sub); uErr != nil {
+		utils.Log.Errorf("failed update subscription [%s]: %+v", sub.Name, uErr)
+	}
+	return err
+}
+
+func fetch(ctx context.Context, sub *model.Subscription) error {
+	user, err := op.GetUserById(sub.UserID)
+	if err != nil {
+		return errors.WithMessage(err, "failed get the owner")
+	}
+	if user.Disabled || !user.CanAddOfflineDownloadTasks() {
+		return errors.New("the owner is not allowed to add offline download tasks")
+	}
+	dstDir, err := user.JoinPath(sub.DstDir)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, "user", user)
+	resp, err := net.RequestHttp(ctx, http.MethodGet, http.Header{}, sub.URL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	items, err := parseFeed(resp.Body)
+	if err != nil {
+		return err
+	}
+	items, err = filter(sub, items)
+	if err != nil {
+		return err
+	}
+	guids := make([]string, 0, len(items))
+	for _, item := range items {
+		guids = append(guids, item.GUID)
+	}
+	records, err := op.GetSubscriptionItemsByGUIDs(sub.ID, guids)
+	if err != nil {
+		return err
+	}
+	fetched := make(map[string]*model.SubscriptionItem, len(records))
+	for i := range records {
+		fetched[records[i].GUID] = &records[i]
+	}
+	skip := sub.SkipExisting && !sub.Initialized
+	for _, item := range items {
+		record, ok := fetched[item.GUID]
+		if ok && (record.Status != model.SubscriptionItemFailed || record.Attempts >= MaxAttempts) {
+			continue
+		}
+		if !ok {
+			record = &model.SubscriptionItem{
+				SubscriptionID: sub.ID,
+				GUID:           item.GUID,
+				Title:          item.Title,
+				URL:            item.URL,
+				Size:           item.Size,
+			}
+			fetched[item.GUID] = record
+		}
+		if skip {
+			record.Status = model.SubscriptionItemSkipped
+		} else {
+			record.Attempts++
+			record.Status, record.Error = model.SubscriptionItemAdded, ""
+			t, err := tool.AddURL(ctx, &tool.AddURLArgs{
+				URL:          item.URL,
+				DstDirPath:   dstDir,
+				Tool:         sub.Tool,
+				DeletePolicy: tool.DeletePolicy(sub.DeletePolicy),
+			})
+			if err != nil {
+				record.Status, record.Error = model.SubscriptionItemFailed, err.Error()
+			} else if t != nil {
+				record.TaskID = t.GetID()
+			}
+		}
+		if err := op.SaveSubscriptionItem(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filter returns the items matching the rules of the subscription
+func filter(sub *model.Subscription, items []Item) ([]Item, error) {
+	include, err := regexp.Compile(sub.Include)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid include regexp")
+	}
+	var exclude *regexp.Regexp
+	if sub.Exclude != "" {
+		if exclude, err = regexp.Compile(sub.Exclude); err != nil {
+			return nil, errors.WithMessage(err, "invalid exclude regexp")
+		}
+	}
+	var categories []string
+	for _, c := range strings.Split(sub.Categories, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			categories = append(categories, strings.ToLower(c))
+		}
+	}
+	res := make([]Item, 0, len(items))
+	for _, item := range items {
+		if item.URL == "" || item.GUID == "" {
+			continue
+		}
+		if !include.MatchString(item.Title) || exclude != nil && exclude.MatchString(item.Title) {
+			continue
+		}
+		// the items with unknown size are not filtered by size
+		if item.Size > 0 && (item.Size < sub.MinSize || sub.MaxSize > 0 && item.Size > sub.MaxSize) {
+			continue
+		}
+		if len(categories) > 0 && !inCategories(item.Categories, categories) {
+			continue
+		}
+		res = append(res, item)
+	}
+	return res, nil
+}
+
+func inCategories(itemCategories, categories []string) bool {
+	for _, c := range itemCategories {
+		if utils.SliceContains(categories, strings.ToLower(c)) {
+			return true
+		}
+	}
+	return false
+}
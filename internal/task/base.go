@@ -10,81 +10,279 @@ import (
 	"github.com/xhofe/tache"
 )
 
+// TaskExtension guards the fields of tache.Base with mu, as they're read by
+// the scheduler and the persister while the task runs
 type TaskExtension struct {
 	tache.Base
-	ctx          context.Context
-	ctxInitMutex sync.Mutex
-	Creator      *model.User
-	startTime    *time.Time
-	endTime      *time.Time
-	totalBytes   int64
+	ctx      context.Context
+	mu       sync.Mutex
+	Creator  *model.User
+	Priority int `json:"priority"`
+	// Group is shared by the task and the tasks it creates, it's used to
+	// wait for all the tasks started by a pipeline step
+	Group string `json:"group,omitempty"`
+	// Paused marks the task canceled by Pause, it's resumed by Resume
+	Paused     bool `json:"paused,omitempty"`
+	startTime  *time.Time
+	endTime    *time.Time
+	totalBytes int64
 }
 
 func (t *TaskExtension) SetCreator(creator *model.User) {
+	t.mu.Lock()
 	t.Creator = creator
+	t.ctx = nil
+	t.mu.Unlock()
 	t.Persist()
 }
 
 func (t *TaskExtension) GetCreator() *model.User {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.Creator
 }
 
+func (t *TaskExtension) SetPriority(priority int) {
+	t.mu.Lock()
+	t.Priority = priority
+	t.ctx = nil
+	t.mu.Unlock()
+	t.Persist()
+}
+
+func (t *TaskExtension) GetPriority() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Priority
+}
+
+func (t *TaskExtension) GetGroup() string {
+	return t.Group
+}
+
+func (t *TaskExtension) SetPaused(paused bool) {
+	t.mu.Lock()
+	t.Paused = paused
+	t.mu.Unlock()
+	t.Persist()
+}
+
+func (t *TaskExtension) IsPaused() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Paused
+}
+
+func (t *TaskExtension) SetID(id string) {
+	t.mu.Lock()
+	t.ID = id
+	t.mu.Unlock()
+	t.Persist()
+}
+
+func (t *TaskExtension) GetID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.ID
+}
+
+func (t *TaskExtension) SetState(state tache.State) {
+	t.mu.Lock()
+	t.State = state
+	t.mu.Unlock()
+	t.Persist()
+}
+
+func (t *TaskExtension) GetState() tache.State {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.State
+}
+
+func (t *TaskExtension) SetProgress(progress float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Base.SetProgress(progress)
+}
+
+func (t *TaskExtension) GetProgress() float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Base.GetProgress()
+}
+
+func (t *TaskExtension) SetErr(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Base.SetErr(err)
+}
+
+func (t *TaskExtension) GetErr() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Base.GetErr()
+}
+
+func (t *TaskExtension) SetRetry(retry int, maxRetry int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Retry, t.MaxRetry = retry, maxRetry
+}
+
+func (t *TaskExtension) GetRetry() (int, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Retry, t.MaxRetry
+}
+
+func (t *TaskExtension) SetCancelFunc(cancelFunc context.CancelFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Base.SetCancelFunc(cancelFunc)
+}
+
+func (t *TaskExtension) Cancel() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Base.Cancel()
+}
 func (t *TaskExtension) SetStartTime(startTime time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.startTime = &startTime
 }
 
 func (t *TaskExtension) GetStartTime() *time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.startTime
 }
 
 func (t *TaskExtension) SetEndTime(endTime time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.endTime = &endTime
 }
 
 func (t *TaskExtension) GetEndTime() *time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.endTime
 }
 
 func (t *TaskExtension) ClearEndTime() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.endTime = nil
 }
 
 func (t *TaskExtension) SetTotalBytes(totalBytes int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	t.totalBytes = totalBytes
 }
 
 func (t *TaskExtension) GetTotalBytes() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.totalBytes
 }
 
 func (t *TaskExtension) Ctx() context.Context {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	if t.ctx == nil {
-		t.ctxInitMutex.Lock()
-		if t.ctx == nil {
-			t.ctx = context.WithValue(t.Base.Ctx(), "user", t.Creator)
-		}
-		t.ctxInitMutex.Unlock()
+		ctx := context.WithValue(t.Base.Ctx(), "user", t.Creator)
+		ctx = context.WithValue(ctx, conf.TaskPriorityKey, t.Priority)
+		t.ctx = context.WithValue(ctx, conf.TaskGroupKey, t.Group)
 	}
 	return t.ctx
 }
 
+// SetCtx sets the context of the task, the context with the creator is rebuilt
+func (t *TaskExtension) SetCtx(ctx context.Context) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Base.SetCtx(ctx)
+	t.ctx = nil
+}
+
+// CtxDone overrides the one of tache.Base, which reads the context unlocked
+func (t *TaskExtension) CtxDone() <-chan struct{} {
+	return t.Ctx().Done()
+}
+
+// lockFields holds the lock of the fields while the task is serialized
+func (t *TaskExtension) lockFields() {
+	t.mu.Lock()
+}
+
+func (t *TaskExtension) unlockFields() {
+	t.mu.Unlock()
+}
+
 func (t *TaskExtension) ReinitCtx() {
 	if !conf.Conf.Tasks.AllowRetryCanceled {
 		return
 	}
+	t.mu.Lock()
+	baseCtx := t.Base.Ctx()
+	t.mu.Unlock()
 	select {
-	case <-t.Base.Ctx().Done():
+	case <-baseCtx.Done():
 		ctx, cancel := context.WithCancel(context.Background())
 		t.SetCtx(ctx)
 		t.SetCancelFunc(cancel)
-		t.ctx = nil
 	default:
 	}
 }
 
+const (
+	MinPriority = -10
+	MaxPriority = 10
+)
+
+// ClampPriority limits the priority set by the user to the range, only the
+// admins can raise a task above the default priority
+func ClampPriority(priority int, user *model.User) int {
+	upper := 0
+	if user != nil && user.IsAdmin() {
+		upper = MaxPriority
+	}
+	return min(max(priority, MinPriority), upper)
+}
+
+// PriorityFromCtx returns the priority of the tasks created with the context
+func PriorityFromCtx(ctx context.Context) int {
+	priority, _ := ctx.Value(conf.TaskPriorityKey).(int)
+	return priority
+}
+
+// GroupFromCtx returns the group of the tasks created with the context
+func GroupFromCtx(ctx context.Context) string {
+	group, _ := ctx.Value(conf.TaskGroupKey).(string)
+	return group
+}
+
+// Distributable is implemented by the tasks able to run on any node of the
+// cluster, the other tasks only run on the node that creates them
+type Distributable interface {
+	Distributable() bool
+}
+
+func isDistributable(t any) bool {
+	d, ok := t.(Distributable)
+	return ok && d.Distributable()
+}
+
 type TaskExtensionInfo interface {
 	tache.TaskWithInfo
 	GetCreator() *model.User
+	GetPriority() int
+	SetPriority(priority int)
+	GetGroup() string
+	SetPaused(paused bool)
+	IsPaused() bool
 	GetStartTime() *time.Time
 	GetEndTime() *time.Time
 	GetTotalBytes() int64
@@ -0,0 +1,63 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/cluster"
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/xhofe/tache"
+)
+
+type sharedTask struct {
+	testTask
+}
+
+func (t *sharedTask) Distributable() bool {
+	return true
+}
+
+func TestClusterClaim(t *testing.T) {
+	initTestDB(t)
+	conf.Conf.Cluster = conf.Cluster{Enable: true, NodeID: "a", LeaseTTL: 3}
+	if err := cluster.Init(); err != nil {
+		t.Fatalf("failed to init cluster: %+v", err)
+	}
+	defer cluster.Close()
+	s := NewScheduler[*sharedTask]("shared", false, tache.WithWorks(1),
+		tache.WithPersistDebounce(time.Millisecond*10))
+	s.SetWorkersNumActive(0)
+	claimed := &sharedTask{testTask{Value: "claimed"}}
+	local := &sharedTask{testTask{Value: "local"}}
+	s.Add(claimed)
+	s.Add(local)
+	if tasks := getTasks(t, model.TaskQuery{Type: "shared"}); len(tasks) != 2 || tasks[0].Node != "" || tasks[1].Node != "" {
+		t.Fatalf("expected the tasks unclaimed, got %+v", tasks)
+	}
+	// another node claims the task first
+	if err := db.GetDb().Model(&model.Task{}).Where("id = ?", claimed.GetID()).Update("node", "b").Error; err != nil {
+		t.Fatal(err)
+	}
+	s.SetWorkersNumActive(1)
+	s.Wait()
+	if _, ok := s.GetByID(claimed.GetID()); ok || claimed.GetState() != tache.StatePending {
+		t.Fatalf("expected the task claimed by b dropped, got state %d", claimed.GetState())
+	}
+	if local.GetState() != tache.StateSucceeded {
+		t.Fatalf("expected the local task succeeded, got state %d", local.GetState())
+	}
+	// b isn't alive, so its task is taken over
+	s.sync()
+	s.Wait()
+	task, ok := s.GetByID(claimed.GetID())
+	if !ok || task.GetState() != tache.StateSucceeded {
+		t.Fatalf("expected the task of b taken over, got %+v", s.GetAll())
+	}
+	for _, row := range getTasks(t, model.TaskQuery{Type: "shared"}) {
+		if row.Node != "a" || row.State != tache.StateSucceeded {
+			t.Fatalf("unexpected task: %+v", row)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package task
+
+// GetByGroup returns the tasks of all the types in the group
+func GetByGroup(group string) []TaskExtensionInfo {
+	var tasks []TaskExtensionInfo
+	if group == "" {
+		return tasks
+	}
+	for _, s := range getSchedulers() {
+		tasks = append(tasks, s.groupTasks(group)...)
+	}
+	return tasks
+}
+
+// CancelGroup cancels the tasks of all the types in the group
+func CancelGroup(group string) {
+	if group == "" {
+		return
+	}
+	for _, s := range getSchedulers() {
+		s.cancelGroup(group)
+	}
+}
+
+func (s *Scheduler[T]) groupTasks(group string) []TaskExtensionInfo {
+	var tasks []TaskExtensionInfo
+	for _, t := range s.GetByCondition(func(t T) bool { return t.GetGroup() == group }) {
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
+func (s *Scheduler[T]) cancelGroup(group string) {
+	s.CancelByCondition(func(t T) bool { return t.GetGroup() == group })
+}
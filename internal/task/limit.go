@@ -0,0 +1,136 @@
+package task
+
+import (
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+)
+
+// StorageTask is implemented by the tasks accessing storages, the running
+// tasks on each storage are limited by the mount paths returned
+type StorageTask interface {
+	GetStorages() []string
+}
+
+// limits counts the running tasks of all the schedulers by the creators
+// and the storages, so the caps are shared between the task types
+type limits struct {
+	sync.Mutex
+	perUser    int
+	perStorage int
+	users      map[uint]int
+	storages   map[string]int
+	schedulers []scheduler
+}
+
+// scheduler is the part of Scheduler used across the task types
+type scheduler interface {
+	dispatch()
+	groupTasks(group string) []TaskExtensionInfo
+	cancelGroup(group string)
+	flush()
+}
+
+var runningLimits = &limits{
+	users:    make(map[uint]int),
+	storages: make(map[string]int),
+}
+
+// SetConcurrencyLimits sets the max running tasks of each user and each
+// storage, zero means no limit
+func SetConcurrencyLimits(perUser, perStorage int) {
+	runningLimits.Lock()
+	runningLimits.perUser = perUser
+	runningLimits.perStorage = perStorage
+	runningLimits.Unlock()
+	dispatchAll()
+}
+
+func registerScheduler(s scheduler) {
+	runningLimits.Lock()
+	defer runningLimits.Unlock()
+	runningLimits.schedulers = append(runningLimits.schedulers, s)
+}
+
+// dispatchAll runs the queued tasks of all the schedulers,
+// it's called after the running tasks are released
+func dispatchAll() {
+	for _, s := range getSchedulers() {
+		s.dispatch()
+	}
+}
+
+func getSchedulers() []scheduler {
+	runningLimits.Lock()
+	defer runningLimits.Unlock()
+	return runningLimits.schedulers
+}
+
+// Coordinator is implemented by the tasks only waiting for the tasks they
+// create, they aren't limited as the created tasks need the running slots
+type Coordinator interface {
+	Coordinator() bool
+}
+
+type owner struct {
+	user     uint
+	storages []string
+}
+
+func ownerOf(t TaskExtensionInfo) owner {
+	var o owner
+	if c, ok := t.(Coordinator); ok && c.Coordinator() {
+		return o
+	}
+	if creator := t.GetCreator(); creator != nil {
+		o.user = creator.ID
+	}
+	if st, ok := t.(StorageTask); ok {
+		for _, s := range st.GetStorages() {
+			if s != "" && !utils.SliceContains(o.storages, s) {
+				o.storages = append(o.storages, s)
+			}
+		}
+	}
+	return o
+}
+
+// allow must be called with the lock held
+func (l *limits) allow(o owner) bool {
+	if l.perUser > 0 && o.user != 0 && l.users[o.user] >= l.perUser {
+		return false
+	}
+	if l.perStorage > 0 {
+		for _, s := range o.storages {
+			if l.storages[s] >= l.perStorage {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// acquire must be called with the lock held
+func (l *limits) acquire(o owner) {
+	if o.user != 0 {
+		l.users[o.user]++
+	}
+	for _, s := range o.storages {
+		l.storages[s]++
+	}
+}
+
+func (l *limits) release(o owner) {
+	l.Lock()
+	defer l.Unlock()
+	if o.user != 0 {
+		if l.users[o.user]--; l.users[o.user] <= 0 {
+			delete(l.users, o.user)
+		}
+	}
+	for _, s := range o.storages {
+		if l.storages[s]--; l.storages[s] <= 0 {
+			delete(l.storages, s)
+		}
+	}
+}
@@ -17,4 +17,7 @@ type Manager[T tache.Task] interface {
 	RemoveByCondition(condition func(task T) bool)
 	Retry(id string)
 	RetryAllFailed()
+	Pause(id string)
+	Resume(id string)
+	SetPriority(id string, priority int)
 }
@@ -0,0 +1,263 @@
+package task
+
+import (
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/cluster"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/xhofe/tache"
+)
+
+// FinishedStates are the states of the tasks that won't run anymore
+var FinishedStates = []int{tache.StateSucceeded, tache.StateCanceled, tache.StateFailed}
+
+// persister saves each task of a scheduler as a row of the tasks table,
+// the rows of the tasks not changed since the last save are skipped. In
+// the cluster, a row is only saved if it's still claimed by the node which
+// this node knows, see claim.
+type persister[T TaskExtensionInfo] struct {
+	taskType string
+	recover  bool
+	mu       sync.Mutex
+	saved    map[string]model.Task
+	nodes    map[string]string
+	legacy   bool
+}
+
+func newPersister[T TaskExtensionInfo](taskType string, recover bool) *persister[T] {
+	return &persister[T]{
+		taskType: taskType,
+		recover:  recover,
+		saved:    make(map[string]model.Task),
+		nodes:    make(map[string]string),
+	}
+}
+
+// load returns the serialized tasks to recover, the unfinished tasks are
+// marked as failed and only kept as history if the recovery is disabled
+func (p *persister[T]) load() ([]json.RawMessage, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.recover {
+		return nil, db.InterruptTasks(p.taskType, cluster.NodeID(), FinishedStates, tache.StateFailed,
+			"the task is interrupted and cannot be recovered")
+	}
+	tasks, err := db.GetTasksByType(p.taskType)
+	if err != nil {
+		return nil, err
+	}
+	var payloads []json.RawMessage
+	if len(tasks) == 0 {
+		// migrate the tasks saved by the previous versions
+		if item, err := db.GetTaskDataByType(p.taskType); err == nil && item.PersistData != "" {
+			p.legacy = true
+			return payloads, json.Unmarshal([]byte(item.PersistData), &payloads)
+		}
+	}
+	node := cluster.NodeID()
+	for _, t := range tasks {
+		// the tasks claimed by the other nodes are left to them
+		if node != "" && t.Node != "" && t.Node != node {
+			continue
+		}
+		p.track(t)
+		payloads = append(payloads, json.RawMessage(t.Payload))
+	}
+	return payloads, nil
+}
+
+// track records the row loaded from the database as saved
+func (p *persister[T]) track(t model.Task) {
+	p.saved[t.ID] = t
+	p.nodes[t.ID] = t.Node
+}
+
+// assign sets the node of the new task, empty means any node can claim it
+func (p *persister[T]) assign(id, node string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.nodes[id]; !ok {
+		p.nodes[id] = node
+	}
+}
+
+// forget drops the task claimed by another node
+func (p *persister[T]) forget(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.saved, id)
+	delete(p.nodes, id)
+}
+
+// claim marks the task as run by this node before it runs, false means
+// another node has claimed it
+func (p *persister[T]) claim(task T) (bool, error) {
+	node := cluster.NodeID()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := task.GetID()
+	from := p.nodes[id]
+	if from == node {
+		return true, nil
+	}
+	payload, err := marshalTask(task)
+	if err != nil {
+		return false, err
+	}
+	t := p.row(task, payload)
+	t.Node = node
+	t.CreatedAt = time.Now()
+	if old, ok := p.saved[id]; ok {
+		t.CreatedAt = old.CreatedAt
+	}
+	ok, err := db.SaveClaimedTask(&t, from)
+	if err != nil {
+		return false, err
+	}
+	if ok {
+		p.saved[id] = t
+		p.nodes[id] = node
+	} else {
+		delete(p.saved, id)
+		delete(p.nodes, id)
+	}
+	return ok, nil
+}
+
+// save returns the tasks claimed by the other nodes, they aren't saved
+func (p *persister[T]) save(tasks []T) (lost []string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	for _, task := range tasks {
+		if pt, ok := any(task).(tache.Persistable); ok && !pt.Persistable() {
+			continue
+		}
+		payload, err := marshalTask(task)
+		if err != nil {
+			return lost, err
+		}
+		t := p.row(task, payload)
+		if old, ok := p.saved[t.ID]; ok {
+			if sameTask(&old, &t) {
+				continue
+			}
+			t.CreatedAt = old.CreatedAt
+		} else {
+			t.CreatedAt = now
+		}
+		if cluster.Enabled() {
+			ok, err := db.SaveClaimedTask(&t, t.Node)
+			if err != nil {
+				return lost, err
+			}
+			if !ok {
+				lost = append(lost, t.ID)
+				delete(p.saved, t.ID)
+				delete(p.nodes, t.ID)
+				continue
+			}
+		} else if err := db.SaveTask(&t); err != nil {
+			return lost, err
+		}
+		p.saved[t.ID] = t
+	}
+	if p.legacy {
+		p.legacy = false
+		if err := db.UpdateTaskData(&model.TaskItem{Key: p.taskType, PersistData: "[]"}); err != nil {
+			log.Warnf("failed to clear the legacy %s tasks: %+v", p.taskType, err)
+		}
+	}
+	return lost, nil
+}
+
+// remove marks the rows of the tasks removed from the scheduler
+func (p *persister[T]) remove(ids []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := db.RemoveTasks(ids); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		delete(p.saved, id)
+		delete(p.nodes, id)
+	}
+	return nil
+}
+
+// fieldLocker is implemented by TaskExtension, the fields are read by
+// json.Marshal directly so they're locked while the task is serialized
+type fieldLocker interface {
+	lockFields()
+	unlockFields()
+}
+
+func marshalTask(task any) ([]byte, error) {
+	if l, ok := task.(fieldLocker); ok {
+		l.lockFields()
+		defer l.unlockFields()
+	}
+	return json.Marshal(task)
+}
+
+func (p *persister[T]) row(task T, payload []byte) model.Task {
+	t := model.Task{
+		ID:         task.GetID(),
+		Type:       p.taskType,
+		Name:       task.GetName(),
+		State:      int(task.GetState()),
+		Status:     task.GetStatus(),
+		Priority:   task.GetPriority(),
+		Progress:   task.GetProgress(),
+		TotalBytes: task.GetTotalBytes(),
+		StartTime:  task.GetStartTime(),
+		EndTime:    task.GetEndTime(),
+		Node:       p.nodes[task.GetID()],
+		Payload:    string(payload),
+	}
+	// NaN can't be saved to the database
+	if math.IsNaN(t.Progress) || math.IsInf(t.Progress, 0) {
+		t.Progress = 100
+	}
+	if err := task.GetErr(); err != nil {
+		t.Error = err.Error()
+	}
+	if creator := task.GetCreator(); creator != nil {
+		t.CreatorID = creator.ID
+		t.Creator = creator.Username
+	}
+	return t
+}
+
+func sameTask(a, b *model.Task) bool {
+	return a.Name == b.Name && a.CreatorID == b.CreatorID && a.Creator == b.Creator &&
+		a.State == b.State && a.Status == b.Status && a.Priority == b.Priority && a.Progress == b.Progress &&
+		a.TotalBytes == b.TotalBytes && a.Error == b.Error && a.Node == b.Node && a.Payload == b.Payload &&
+		sameTime(a.StartTime, b.StartTime) && sameTime(a.EndTime, b.EndTime)
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// CleanHistory deletes the finished tasks older than the retention days and
+// the ones exceeding the max count, zero means no limit
+func CleanHistory(retentionDays, maxCount int) error {
+	if retentionDays > 0 {
+		if err := db.DeleteTasksBefore(FinishedStates, time.Now().AddDate(0, 0, -retentionDays)); err != nil {
+			return err
+		}
+	}
+	if maxCount > 0 {
+		return db.DeleteTasksExceeding(FinishedStates, maxCount)
+	}
+	return nil
+}
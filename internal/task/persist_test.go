@@ -0,0 +1,112 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/xhofe/tache"
+)
+
+type testTask struct {
+	TaskExtension
+	Value string `json:"value"`
+}
+
+func (t *testTask) GetName() string {
+	return "test " + t.Value
+}
+
+func (t *testTask) GetStatus() string {
+	return ""
+}
+
+func (t *testTask) Run() error {
+	t.SetTotalBytes(int64(len(t.Value)))
+	return nil
+}
+
+func initTestDB(t *testing.T) {
+	db.Init(dbtest.Open(t))
+	// the schedulers of the test save their changes before the next test
+	// opens another database
+	t.Cleanup(func() {
+		for _, s := range getSchedulers() {
+			s.flush()
+		}
+	})
+}
+
+func newTestManager(running bool) *Scheduler[*testTask] {
+	return NewScheduler[*testTask]("test", true, tache.WithRunning(running),
+		tache.WithPersistDebounce(time.Millisecond*10))
+}
+
+func getTasks(t *testing.T, q model.TaskQuery) []model.Task {
+	t.Helper()
+	for _, s := range getSchedulers() {
+		s.flush()
+	}
+	q.Validate()
+	tasks, _, err := db.GetTasks(q)
+	if err != nil {
+		t.Fatalf("failed to get tasks: %+v", err)
+	}
+	return tasks
+}
+
+func TestPersist(t *testing.T) {
+	initTestDB(t)
+	creator := &model.User{ID: 2, Username: "alice"}
+	m := newTestManager(true)
+	m.Add(&testTask{TaskExtension: TaskExtension{Creator: creator}, Value: "done"})
+	m.Wait()
+	tasks := getTasks(t, model.TaskQuery{Type: "test"})
+	if len(tasks) != 1 || tasks[0].Name != "test done" || tasks[0].Creator != "alice" ||
+		tasks[0].State != tache.StateSucceeded || tasks[0].TotalBytes != 4 {
+		t.Fatalf("unexpected tasks: %+v", tasks)
+	}
+	// the wildcards in the keyword are matched literally
+	if tasks := getTasks(t, model.TaskQuery{Keyword: "test_d%"}); len(tasks) != 0 {
+		t.Fatalf("expected no task matching the wildcards, got %+v", tasks)
+	}
+
+	paused := newTestManager(false)
+	pending := &testTask{TaskExtension: TaskExtension{Creator: creator}, Value: "pending"}
+	paused.Add(pending)
+	if tasks := getTasks(t, model.TaskQuery{States: []int{tache.StatePending}}); len(tasks) != 1 {
+		t.Fatalf("unexpected pending tasks: %+v", tasks)
+	}
+	// the tasks are recovered by a new manager of the same type
+	recovered := newTestManager(false)
+	if task, ok := recovered.GetByID(pending.GetID()); !ok || task.Value != "pending" {
+		t.Fatalf("expected the pending task recovered, got %+v", recovered.GetAll())
+	}
+	recovered.Remove(pending.GetID())
+	tasks = getTasks(t, model.TaskQuery{CreatorID: 2, Keyword: "pending"})
+	if len(tasks) != 1 || !tasks[0].Removed {
+		t.Fatalf("expected the removed task kept as history, got %+v", tasks)
+	}
+	if tasks, _ := db.GetTasksByType("test"); len(tasks) != 1 {
+		t.Fatalf("expected only the succeeded task to be recovered, got %+v", tasks)
+	}
+
+	m.Add(&testTask{TaskExtension: TaskExtension{Creator: creator}, Value: "second"})
+	m.Wait()
+	if err := CleanHistory(30, 0); err != nil {
+		t.Fatalf("failed to clean history: %+v", err)
+	}
+	if tasks := getTasks(t, model.TaskQuery{}); len(tasks) != 3 {
+		t.Fatalf("expected no task deleted, got %+v", tasks)
+	}
+	// only the newest finished task is kept
+	if err := CleanHistory(0, 1); err != nil {
+		t.Fatalf("failed to clean history: %+v", err)
+	}
+	tasks = getTasks(t, model.TaskQuery{})
+	if len(tasks) != 2 || tasks[0].Name != "test second" || tasks[1].Name != "test pending" {
+		t.Fatalf("unexpected tasks after cleaning: %+v", tasks)
+	}
+}
@@ -0,0 +1,546 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/cluster"
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/pkg/cron"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/xhofe/tache"
+)
+
+// Scheduler runs the tasks of a type by their priorities, the tasks with
+// the same priority are run from the creator with the fewest running tasks,
+// then by the order they are queued. The running tasks of each creator and
+// each storage are limited across all the schedulers, see SetConcurrencyLimits.
+type Scheduler[T TaskExtensionInfo] struct {
+	opts      *tache.Options
+	persister *persister[T]
+
+	mu      sync.Mutex
+	tasks   map[string]T
+	queue   []T
+	seq     uint64
+	seqs    map[string]uint64
+	cancels map[string]context.CancelFunc
+	active  map[string]bool
+	workers int64
+
+	dirtyMu   sync.Mutex
+	dirty     map[string]struct{}
+	removed   map[string]struct{}
+	scheduled bool
+	persistMu sync.Mutex
+}
+
+// NewScheduler creates a scheduler of the tasks persisted as the type, the
+// unfinished tasks are recovered at start if recover is true, otherwise
+// they are marked as failed and only kept as history
+func NewScheduler[T TaskExtensionInfo](taskType string, recover bool, opts ...tache.Option) *Scheduler[T] {
+	options := tache.DefaultOptions()
+	for _, opt := range opts {
+		opt(options)
+	}
+	s := &Scheduler[T]{
+		opts:      options,
+		persister: newPersister[T](taskType, recover),
+		tasks:     make(map[string]T),
+		seqs:      make(map[string]uint64),
+		cancels:   make(map[string]context.CancelFunc),
+		active:    make(map[string]bool),
+		workers:   int64(options.Works),
+		dirty:     make(map[string]struct{}),
+		removed:   make(map[string]struct{}),
+	}
+	s.recover(taskType)
+	registerScheduler(s)
+	if cluster.Enabled() {
+		cron.NewCron(syncInterval).Do(s.sync)
+	}
+	return s
+}
+
+const syncInterval = 5 * time.Second
+
+// sync adds the tasks queued by the other nodes and the ones left by the
+// dead nodes, they run here if this node claims them first
+func (s *Scheduler[T]) sync() {
+	if !cluster.Enabled() {
+		return
+	}
+	rows, err := db.GetOtherNodesTasks(s.persister.taskType, cluster.NodeID(), FinishedStates)
+	if err != nil {
+		log.Errorf("failed to sync the %s tasks: %+v", s.persister.taskType, err)
+		return
+	}
+	alive := make(map[string]bool)
+	for _, row := range rows {
+		if row.Node != "" {
+			if _, ok := alive[row.Node]; !ok {
+				alive[row.Node] = cluster.Alive(row.Node)
+			}
+			if alive[row.Node] {
+				continue
+			}
+		}
+		if _, ok := s.GetByID(row.ID); ok {
+			continue
+		}
+		var t T
+		if err := json.Unmarshal([]byte(row.Payload), &t); err != nil {
+			log.Errorf("failed to sync the %s task: %+v", s.persister.taskType, err)
+			continue
+		}
+		// the task depends on the files of the node that created it
+		if !isDistributable(t) {
+			continue
+		}
+		s.persister.mu.Lock()
+		s.persister.track(row)
+		s.persister.mu.Unlock()
+		s.Add(t)
+	}
+}
+
+func (s *Scheduler[T]) recover(taskType string) {
+	payloads, err := s.persister.load()
+	if err != nil {
+		log.Errorf("failed to recover the %s tasks: %+v", taskType, err)
+		return
+	}
+	for _, payload := range payloads {
+		var t T
+		if err := json.Unmarshal(payload, &t); err != nil {
+			log.Errorf("failed to recover the %s task: %+v", taskType, err)
+			continue
+		}
+		if r, ok := any(t).(tache.Recoverable); ok && !r.Recoverable() {
+			t.SetState(tache.StateFailed)
+			t.SetErr(fmt.Errorf("the task is interrupted and cannot be recovered"))
+		}
+		s.Add(t)
+	}
+}
+
+// Add adds the task and runs it when it's the turn
+func (s *Scheduler[T]) Add(t T) {
+	if t.GetID() == "" {
+		t.SetID(uuid.NewString())
+	}
+	id := t.GetID()
+	if isDistributable(t) {
+		s.persister.assign(id, "")
+	} else {
+		s.persister.assign(id, cluster.NodeID())
+	}
+	t.SetPersist(func() { s.markDirty(id) })
+	if _, maxRetry := t.GetRetry(); maxRetry == 0 {
+		t.SetRetry(0, s.opts.MaxRetry)
+	}
+	switch t.GetState() {
+	case tache.StateRunning:
+		t.SetState(tache.StatePending)
+	case tache.StateCanceling:
+		t.SetState(tache.StateCanceled)
+		t.SetErr(context.Canceled)
+	case tache.StateFailing:
+		t.SetState(tache.StateFailed)
+	}
+	s.mu.Lock()
+	s.resetCtx(t)
+	s.tasks[id] = t
+	if !isFinished(t) {
+		s.push(t)
+	}
+	s.mu.Unlock()
+	s.markDirty(id)
+	s.dispatch()
+}
+
+func isFinished[T TaskExtensionInfo](t T) bool {
+	return slices.Contains(FinishedStates, int(t.GetState()))
+}
+
+// resetCtx gives the task a new context, must be called with the lock held
+func (s *Scheduler[T]) resetCtx(t T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.SetCtx(ctx)
+	t.SetCancelFunc(cancel)
+	s.cancels[t.GetID()] = cancel
+}
+
+// push must be called with the lock held
+func (s *Scheduler[T]) push(t T) {
+	s.seq++
+	s.seqs[t.GetID()] = s.seq
+	s.queue = append(s.queue, t)
+}
+
+// unqueue removes the task from the queue, must be called with the lock held
+func (s *Scheduler[T]) unqueue(id string) bool {
+	if _, ok := s.seqs[id]; !ok {
+		return false
+	}
+	delete(s.seqs, id)
+	s.queue = slices.DeleteFunc(s.queue, func(t T) bool {
+		return t.GetID() == id
+	})
+	return true
+}
+
+func (s *Scheduler[T]) dispatch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.opts.Running && int64(len(s.active)) < s.workers {
+		i, o := s.pick()
+		if i < 0 {
+			return
+		}
+		t := s.queue[i]
+		s.queue = slices.Delete(s.queue, i, i+1)
+		delete(s.seqs, t.GetID())
+		s.active[t.GetID()] = true
+		go s.execute(t, o)
+	}
+}
+
+// pick returns the index of the next task to run and acquires its
+// running slots, must be called with the lock held
+func (s *Scheduler[T]) pick() (int, owner) {
+	runningLimits.Lock()
+	defer runningLimits.Unlock()
+	best := -1
+	var bestOwner owner
+	for i, t := range s.queue {
+		o := ownerOf(t)
+		if !runningLimits.allow(o) {
+			continue
+		}
+		if best < 0 || s.before(t, o, s.queue[best], bestOwner) {
+			best, bestOwner = i, o
+		}
+	}
+	if best >= 0 {
+		runningLimits.acquire(bestOwner)
+	}
+	return best, bestOwner
+}
+
+func (s *Scheduler[T]) before(a T, ao owner, b T, bo owner) bool {
+	if a.GetPriority() != b.GetPriority() {
+		return a.GetPriority() > b.GetPriority()
+	}
+	if ar, br := runningLimits.users[ao.user], runningLimits.users[bo.user]; ar != br {
+		return ar < br
+	}
+	return s.seqs[a.GetID()] < s.seqs[b.GetID()]
+}
+
+func (s *Scheduler[T]) execute(t T, o owner) {
+	id := t.GetID()
+	defer func() {
+		runningLimits.release(o)
+		s.mu.Lock()
+		delete(s.active, id)
+		if _, ok := s.tasks[id]; ok && t.GetState() == tache.StateWaitingRetry {
+			s.push(t)
+		}
+		s.mu.Unlock()
+		dispatchAll()
+	}()
+	if t.GetState() == tache.StateCanceling {
+		t.SetState(tache.StateCanceled)
+		t.SetErr(context.Canceled)
+		return
+	}
+	if ok, err := s.persister.claim(t); err != nil {
+		t.SetErr(fmt.Errorf("failed to claim the task: %w", err))
+		t.SetState(tache.StateFailed)
+		return
+	} else if !ok {
+		log.Debugf("the task [%s] is claimed by another node", id)
+		s.drop(id)
+		return
+	}
+	if s.opts.Timeout != nil {
+		ctx, cancel := context.WithTimeout(t.Ctx(), *s.opts.Timeout)
+		defer cancel()
+		t.SetCtx(ctx)
+	}
+	tache.Worker[T]{}.Execute(t)
+	// the task canceled by Pause is kept canceled to be resumed, the
+	// one finished before it's canceled isn't paused any more
+	if t.IsPaused() {
+		if t.GetState() == tache.StateSucceeded {
+			t.SetPaused(false)
+		} else {
+			t.SetState(tache.StateCanceled)
+			t.SetErr(context.Canceled)
+		}
+	}
+}
+
+// Wait waits for all the tasks to be done, just for test
+func (s *Scheduler[T]) Wait() {
+	for {
+		s.mu.Lock()
+		idle := len(s.active) == 0 && (len(s.queue) == 0 || !s.opts.Running)
+		s.mu.Unlock()
+		if idle {
+			return
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
+
+// flush saves the changes now instead of after the debounce, just for test
+func (s *Scheduler[T]) flush() {
+	s.persist()
+}
+
+func (s *Scheduler[T]) Cancel(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.tasks[id]; ok {
+		s.cancel(t)
+	}
+}
+
+// cancel must be called with the lock held
+func (s *Scheduler[T]) cancel(t T) {
+	if isFinished(t) {
+		return
+	}
+	t.Cancel()
+	if s.unqueue(t.GetID()) {
+		t.SetState(tache.StateCanceled)
+		t.SetErr(context.Canceled)
+	}
+}
+
+func (s *Scheduler[T]) CancelAll() {
+	s.CancelByCondition(func(T) bool { return true })
+}
+
+func (s *Scheduler[T]) CancelByCondition(condition func(task T) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tasks {
+		if condition(t) {
+			s.cancel(t)
+		}
+	}
+}
+
+func (s *Scheduler[T]) GetAll() []T {
+	return s.GetByCondition(func(T) bool { return true })
+}
+
+func (s *Scheduler[T]) GetByID(id string) (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[id]
+	return t, ok
+}
+
+func (s *Scheduler[T]) GetByState(state ...tache.State) []T {
+	return s.GetByCondition(func(t T) bool {
+		return slices.Contains(state, t.GetState())
+	})
+}
+
+func (s *Scheduler[T]) GetByCondition(condition func(task T) bool) []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var tasks []T
+	for _, t := range s.tasks {
+		if condition(t) {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// Remove removes the task from the scheduler, its row is kept as history
+func (s *Scheduler[T]) Remove(id string) {
+	s.mu.Lock()
+	_, ok := s.tasks[id]
+	delete(s.tasks, id)
+	delete(s.cancels, id)
+	s.unqueue(id)
+	s.mu.Unlock()
+	if ok {
+		s.markRemoved(id)
+	}
+}
+
+// drop removes the task claimed by another node, its row is left to the node
+func (s *Scheduler[T]) drop(id string) {
+	s.mu.Lock()
+	if cancel, ok := s.cancels[id]; ok && s.active[id] {
+		cancel()
+	}
+	delete(s.tasks, id)
+	delete(s.cancels, id)
+	s.unqueue(id)
+	s.mu.Unlock()
+	s.persister.forget(id)
+}
+
+func (s *Scheduler[T]) RemoveAll() {
+	s.RemoveByCondition(func(T) bool { return true })
+}
+
+func (s *Scheduler[T]) RemoveByState(state ...tache.State) {
+	for _, t := range s.GetByState(state...) {
+		s.Remove(t.GetID())
+	}
+}
+
+func (s *Scheduler[T]) RemoveByCondition(condition func(task T) bool) {
+	for _, t := range s.GetByCondition(condition) {
+		s.Remove(t.GetID())
+	}
+}
+
+// Retry runs the task again if it's not queued or running
+func (s *Scheduler[T]) Retry(id string) {
+	s.mu.Lock()
+	t, ok := s.tasks[id]
+	if !ok || s.active[id] {
+		s.mu.Unlock()
+		return
+	}
+	if _, queued := s.seqs[id]; queued {
+		s.mu.Unlock()
+		return
+	}
+	if conf.Conf.Tasks.AllowRetryCanceled || t.IsPaused() {
+		select {
+		case <-t.Ctx().Done():
+			s.resetCtx(t)
+		default:
+		}
+	}
+	t.SetPaused(false)
+	t.SetState(tache.StateWaitingRetry)
+	t.SetErr(nil)
+	t.SetRetry(0, s.opts.MaxRetry)
+	s.push(t)
+	s.mu.Unlock()
+	s.dispatch()
+}
+
+func (s *Scheduler[T]) RetryAllFailed() {
+	for _, t := range s.GetByState(tache.StateFailed) {
+		s.Retry(t.GetID())
+	}
+}
+
+// Pause cancels the task and marks it paused, so that it's listed as undone
+// and can be resumed. A resumed task runs again from the start, only the http
+// offline downloads continue their partial files; the upload, copy and
+// transfer tasks restart as op.Put can't resume an upload
+func (s *Scheduler[T]) Pause(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tasks[id]
+	if !ok || isFinished(t) {
+		return
+	}
+	t.SetPaused(true)
+	s.cancel(t)
+}
+
+// Resume queues the paused task again like Retry
+func (s *Scheduler[T]) Resume(id string) {
+	if t, ok := s.GetByID(id); ok && t.IsPaused() {
+		s.Retry(id)
+	}
+}
+
+func (s *Scheduler[T]) SetPriority(id string, priority int) {
+	if t, ok := s.GetByID(id); ok {
+		t.SetPriority(priority)
+		s.dispatch()
+	}
+}
+
+func (s *Scheduler[T]) SetWorkersNumActive(active int64) {
+	s.mu.Lock()
+	s.workers = active
+	s.mu.Unlock()
+	s.dispatch()
+}
+
+func (s *Scheduler[T]) markDirty(id string) {
+	s.dirtyMu.Lock()
+	defer s.dirtyMu.Unlock()
+	s.dirty[id] = struct{}{}
+	s.schedulePersist()
+}
+
+func (s *Scheduler[T]) markRemoved(id string) {
+	s.dirtyMu.Lock()
+	defer s.dirtyMu.Unlock()
+	delete(s.dirty, id)
+	s.removed[id] = struct{}{}
+	s.schedulePersist()
+}
+
+// schedulePersist saves the changes once in the debounce duration,
+// must be called with the dirty lock held
+func (s *Scheduler[T]) schedulePersist() {
+	if s.scheduled {
+		return
+	}
+	s.scheduled = true
+	var d time.Duration
+	if s.opts.PersistDebounce != nil {
+		d = *s.opts.PersistDebounce
+	}
+	time.AfterFunc(d, s.persist)
+}
+
+func (s *Scheduler[T]) persist() {
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+	s.dirtyMu.Lock()
+	dirty, removed := s.dirty, s.removed
+	s.dirty, s.removed = make(map[string]struct{}), make(map[string]struct{})
+	s.scheduled = false
+	s.dirtyMu.Unlock()
+	var tasks []T
+	s.mu.Lock()
+	for id := range dirty {
+		if t, ok := s.tasks[id]; ok {
+			tasks = append(tasks, t)
+		}
+	}
+	s.mu.Unlock()
+	lost, err := s.persister.save(tasks)
+	if err != nil {
+		log.Errorf("failed to save the %s tasks: %+v", s.persister.taskType, err)
+	}
+	for _, id := range lost {
+		log.Warnf("the %s task [%s] is claimed by another node", s.persister.taskType, id)
+		s.drop(id)
+	}
+	ids := make([]string, 0, len(removed))
+	for id := range removed {
+		ids = append(ids, id)
+	}
+	if err := s.persister.remove(ids); err != nil {
+		log.Errorf("failed to remove the %s tasks: %+v", s.persister.taskType, err)
+	}
+}
+
+var _ Manager[TaskExtensionInfo] = (*Scheduler[TaskExtensionInfo])(nil)
@@ -0,0 +1,228 @@
+package task
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/xhofe/tache"
+)
+
+type orderTask struct {
+	TaskExtension
+	Value string `json:"value"`
+	mu    *sync.Mutex
+	order *[]string
+}
+
+func (t *orderTask) GetName() string {
+	return t.Value
+}
+
+func (t *orderTask) GetStatus() string {
+	return ""
+}
+
+func (t *orderTask) Run() error {
+	t.mu.Lock()
+	*t.order = append(*t.order, t.Value)
+	t.mu.Unlock()
+	return nil
+}
+
+func TestSchedulerOrder(t *testing.T) {
+	initTestDB(t)
+	var mu sync.Mutex
+	var order []string
+	newTask := func(user uint, value string, priority int) *orderTask {
+		return &orderTask{
+			TaskExtension: TaskExtension{Creator: &model.User{ID: user}, Priority: priority},
+			Value:         value, mu: &mu, order: &order,
+		}
+	}
+	s := NewScheduler[*orderTask]("order", false, tache.WithWorks(1),
+		tache.WithPersistDebounce(time.Millisecond*10))
+	s.SetWorkersNumActive(0)
+	s.Add(newTask(1, "a1", 0))
+	s.Add(newTask(1, "a2", 0))
+	s.Add(newTask(2, "b1", 0))
+	high := newTask(2, "b2", 1)
+	s.Add(high)
+	paused := newTask(1, "a3", 5)
+	s.Add(paused)
+	s.Pause(paused.GetID())
+	if paused.GetState() != tache.StateCanceled || !paused.IsPaused() {
+		t.Fatalf("expected the task paused, got state %d", paused.GetState())
+	}
+	s.SetWorkersNumActive(1)
+	s.Wait()
+	expected := []string{"b2", "a1", "a2", "b1"}
+	if len(order) != len(expected) {
+		t.Fatalf("unexpected order: %v", order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("unexpected order: %v", order)
+		}
+	}
+	s.Resume(paused.GetID())
+	s.Wait()
+	if paused.GetState() != tache.StateSucceeded || order[len(order)-1] != "a3" {
+		t.Fatalf("expected the resumed task to succeed, got state %d, order %v", paused.GetState(), order)
+	}
+	if tasks := getTasks(t, model.TaskQuery{Type: "order", Keyword: "a3"}); len(tasks) != 1 || tasks[0].Priority != 5 {
+		t.Fatalf("expected the priority persisted, got %+v", tasks)
+	}
+}
+
+func TestSchedulerUserLimit(t *testing.T) {
+	initTestDB(t)
+	SetConcurrencyLimits(1, 0)
+	defer SetConcurrencyLimits(0, 0)
+	var mu sync.Mutex
+	var order []string
+	s := NewScheduler[*orderTask]("limit", false, tache.WithWorks(4),
+		tache.WithPersistDebounce(time.Millisecond*10))
+	for _, v := range []string{"a", "b", "c"} {
+		s.Add(&orderTask{
+			TaskExtension: TaskExtension{Creator: &model.User{ID: 1}},
+			Value:         v, mu: &mu, order: &order,
+		})
+	}
+	s.Wait()
+	if len(order) != 3 || order[0] != "a" || order[1] != "b" || order[2] != "c" {
+		t.Fatalf("expected the tasks of a user to run one by one, got %v", order)
+	}
+	if tasks := getTasks(t, model.TaskQuery{Type: "limit"}); len(tasks) != 3 {
+		t.Fatalf("unexpected tasks: %+v", tasks)
+	}
+}
+
+type coordinatorTask struct {
+	TaskExtension
+	s     *Scheduler[*coordinatorTask]
+	child *coordinatorTask
+}
+
+func (t *coordinatorTask) GetName() string {
+	return "coordinator"
+}
+
+func (t *coordinatorTask) GetStatus() string {
+	return ""
+}
+
+func (t *coordinatorTask) Coordinator() bool {
+	return t.child != nil
+}
+
+func (t *coordinatorTask) Run() error {
+	if t.child == nil {
+		return nil
+	}
+	t.s.Add(t.child)
+	timeout := time.After(time.Second * 5)
+	for t.child.GetState() != tache.StateSucceeded {
+		select {
+		case <-timeout:
+			return errors.New("timeout waiting for the child task")
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+	return nil
+}
+
+func TestSchedulerCoordinatorLimit(t *testing.T) {
+	initTestDB(t)
+	SetConcurrencyLimits(1, 0)
+	defer SetConcurrencyLimits(0, 0)
+	s := NewScheduler[*coordinatorTask]("coordinator", false, tache.WithWorks(2),
+		tache.WithPersistDebounce(time.Millisecond*10))
+	creator := &model.User{ID: 1}
+	parent := &coordinatorTask{
+		TaskExtension: TaskExtension{Creator: creator},
+		s:             s,
+		child:         &coordinatorTask{TaskExtension: TaskExtension{Creator: creator}},
+	}
+	s.Add(parent)
+	s.Wait()
+	if parent.GetState() != tache.StateSucceeded {
+		t.Fatalf("expected the coordinator not to take the slot of its child, got state %d: %v",
+			parent.GetState(), parent.GetErr())
+	}
+}
+
+type blockingTask struct {
+	TaskExtension
+	started chan struct{}
+	failed  bool
+}
+
+func (t *blockingTask) GetName() string {
+	return "blocking"
+}
+
+func (t *blockingTask) GetStatus() string {
+	return ""
+}
+
+func (t *blockingTask) Run() error {
+	select {
+	case t.started <- struct{}{}:
+	default:
+	}
+	<-t.Ctx().Done()
+	return t.Ctx().Err()
+}
+
+func (t *blockingTask) OnFailed() {
+	t.failed = !t.IsPaused()
+}
+
+func TestSchedulerPauseRunning(t *testing.T) {
+	initTestDB(t)
+	s := NewScheduler[*blockingTask]("pause", false, tache.WithWorks(1),
+		tache.WithPersistDebounce(time.Millisecond*10))
+	bt := &blockingTask{started: make(chan struct{}, 1)}
+	s.Add(bt)
+	<-bt.started
+	s.Pause(bt.GetID())
+	s.Wait()
+	if bt.GetState() != tache.StateCanceled || !bt.IsPaused() || bt.failed {
+		t.Fatalf("expected the running task paused, got state %d, failed %v", bt.GetState(), bt.failed)
+	}
+	s.Resume(bt.GetID())
+	<-bt.started
+	if bt.IsPaused() {
+		t.Fatal("expected the resumed task not paused")
+	}
+	s.Cancel(bt.GetID())
+	s.Wait()
+	if bt.GetState() != tache.StateFailed || !bt.failed {
+		t.Fatalf("expected the canceled task failed by its hook, got state %d", bt.GetState())
+	}
+}
+
+func TestClampPriority(t *testing.T) {
+	admin := &model.User{Role: model.ADMIN}
+	user := &model.User{Role: model.GENERAL}
+	tests := []struct {
+		priority int
+		user     *model.User
+		want     int
+	}{
+		{5, admin, 5},
+		{100, admin, MaxPriority},
+		{5, user, 0},
+		{-5, user, -5},
+		{-100, user, MinPriority},
+		{5, nil, 0},
+	}
+	for _, tt := range tests {
+		if got := ClampPriority(tt.priority, tt.user); got != tt.want {
+			t.Errorf("expected priority %d of %d, got %d", tt.want, tt.priority, got)
+		}
+	}
+}
@@ -2,9 +2,14 @@ package cron
 
 import "time"
 
+// IsLeader reports whether this node runs the crons marked as LeaderOnly,
+// it's replaced when the node joins a cluster
+var IsLeader = func() bool { return true }
+
 type Cron struct {
-	d  time.Duration
-	ch chan struct{}
+	d          time.Duration
+	ch         chan struct{}
+	leaderOnly bool
 }
 
 func NewCron(d time.Duration) *Cron {
@@ -14,6 +19,13 @@ func NewCron(d time.Duration) *Cron {
 	}
 }
 
+// LeaderOnly skips the runs on the nodes other than the leader of the
+// cluster, it's used by the jobs which mustn't run on multiple nodes
+func (c *Cron) LeaderOnly() *Cron {
+	c.leaderOnly = true
+	return c
+}
+
 func (c *Cron) Do(f func()) {
 	go func() {
 		ticker := time.NewTicker(c.d)
@@ -21,7 +33,9 @@ func (c *Cron) Do(f func()) {
 		for {
 			select {
 			case <-ticker.C:
-				f()
+				if !c.leaderOnly || IsLeader() {
+					f()
+				}
 			case <-c.ch:
 				return
 			}
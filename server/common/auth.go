@@ -1,12 +1,15 @@
 package common
 
 import (
+	"strings"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/internal/conf"
 	"github.com/OpenListTeam/OpenList/internal/model"
-	"github.com/Xhofe/go-cache"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
@@ -18,23 +21,38 @@ type UserClaims struct {
 	jwt.RegisteredClaims
 }
 
-var validTokenCache = cache.NewMemCache[bool]()
-
-func GenerateToken(user *model.User) (tokenString string, err error) {
+// GenerateToken creates a session of the user and returns a token carrying the session id
+func GenerateToken(c *gin.Context, user *model.User, method string) (tokenString string, err error) {
+	now := time.Now()
+	expiresAt := now.Add(time.Duration(conf.Conf.TokenExpiresIn) * time.Hour)
+	session := &model.Session{
+		ID:         uuid.NewString(),
+		UserID:     user.ID,
+		Method:     method,
+		Device:     deviceOf(c.Request.UserAgent()),
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+		CreatedAt:  now,
+		LastActive: now,
+		ExpiresAt:  expiresAt,
+	}
 	claim := UserClaims{
 		Username: user.Username,
 		PwdTS:    user.PwdTS,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(conf.Conf.TokenExpiresIn) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ID:        session.ID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		}}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
 	tokenString, err = token.SignedString(SecretKey)
 	if err != nil {
 		return "", err
 	}
-	validTokenCache.Set(tokenString, true)
+	if err = op.CreateSession(session); err != nil {
+		return "", err
+	}
 	return tokenString, err
 }
 
@@ -42,9 +60,6 @@ func ParseToken(tokenString string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return SecretKey, nil
 	})
-	if IsTokenInvalidated(tokenString) {
-		return nil, errors.New("token is invalidated")
-	}
 	if err != nil {
 		if ve, ok := err.(*jwt.ValidationError); ok {
 			if ve.Errors&jwt.ValidationErrorMalformed != 0 {
@@ -64,15 +79,63 @@ func ParseToken(tokenString string) (*UserClaims, error) {
 	return nil, errors.New("couldn't handle this token")
 }
 
+// GetSession returns the session of the token, it fails if the
+// session has been revoked or has expired
+func GetSession(claims *UserClaims) (*model.Session, error) {
+	if claims.ID == "" {
+		return nil, errors.New("token is invalidated")
+	}
+	session, err := op.GetSession(claims.ID)
+	if err != nil || session.ExpiresAt.Before(time.Now()) {
+		return nil, errors.New("token is invalidated")
+	}
+	return session, nil
+}
+
 func InvalidateToken(tokenString string) error {
 	if tokenString == "" {
 		return nil // don't invalidate empty guest token
 	}
-	validTokenCache.Del(tokenString)
-	return nil
+	claims, err := ParseToken(tokenString)
+	if err != nil || claims.ID == "" {
+		return nil
+	}
+	return op.DeleteSession(claims.ID)
 }
 
-func IsTokenInvalidated(tokenString string) bool {
-	_, ok := validTokenCache.Get(tokenString)
-	return !ok
+// deviceOf describes the browser and the os of a user agent
+func deviceOf(ua string) string {
+	browser := "Unknown"
+	for _, b := range []struct{ key, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(ua, b.key) {
+			browser = b.name
+			break
+		}
+	}
+	system := ""
+	for _, o := range []struct{ key, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.key) {
+			system = o.name
+			break
+		}
+	}
+	if system == "" {
+		return browser
+	}
+	return browser + " on " + system
 }
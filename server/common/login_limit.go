@@ -0,0 +1,229 @@
+package common
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/cache"
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/setting"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+)
+
+const (
+	LoginProtocolWeb    = "web"
+	LoginProtocolLdap   = "ldap"
+	LoginProtocolWebDAV = "webdav"
+	LoginProtocolFTP    = "ftp"
+	LoginProtocolSFTP   = "sftp"
+)
+
+// LoginLockedError is returned when the account or the ip is locked
+// because of too many failed login attempts
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many unsuccessful sign-in attempts, try again after %s",
+		e.Until.Format(time.RFC3339))
+}
+
+// LoginLock is the failure counter of an account or an ip
+type LoginLock struct {
+	Key         string    `json:"key"`
+	Failures    int       `json:"failures"`
+	Locks       int       `json:"locks"`
+	LockedUntil time.Time `json:"locked_until"`
+	LastFailure time.Time `json:"last_failure"`
+}
+
+func (l *LoginLock) locked(now time.Time) bool {
+	return now.Before(l.LockedUntil)
+}
+
+// loginLocks keeps the counters of each account and ip in its own key, the
+// counters are changed atomically and shared with the other nodes, so that
+// an attacker can't spread the attempts over the nodes. A counter expires
+// once it's neither locked nor failed in the max lock duration.
+var loginLocks = cache.NewCounters("login_lock")
+
+const (
+	loginFailures    = "failures"
+	loginLocksCount  = "locks"
+	loginLockedUntil = "locked_until"
+	loginLastFailure = "last_failure"
+)
+
+func getLoginLock(key string) *LoginLock {
+	fields := loginLocks.Get(key)
+	if fields == nil {
+		return nil
+	}
+	return newLoginLock(key, fields)
+}
+
+func newLoginLock(key string, fields map[string]int64) *LoginLock {
+	l := &LoginLock{
+		Key:      key,
+		Failures: int(fields[loginFailures]),
+		Locks:    int(fields[loginLocksCount]),
+	}
+	if ms := fields[loginLockedUntil]; ms > 0 {
+		l.LockedUntil = time.UnixMilli(ms)
+	}
+	if ms := fields[loginLastFailure]; ms > 0 {
+		l.LastFailure = time.UnixMilli(ms)
+	}
+	return l
+}
+
+func loginAccountKey(username string) string {
+	return "user:" + username
+}
+
+func loginIPKey(ip string) string {
+	return "ip:" + hostOf(ip)
+}
+
+func loginKeys(username, ip string) []string {
+	keys := make([]string, 0, 2)
+	if ip != "" {
+		keys = append(keys, loginIPKey(ip))
+	}
+	if username != "" {
+		keys = append(keys, loginAccountKey(username))
+	}
+	return keys
+}
+
+func loginLockSettings() (maxFailures int, lockDuration, maxLockDuration time.Duration) {
+	maxFailures = setting.GetInt(conf.LoginMaxFailures, 5)
+	lockDuration = time.Duration(setting.GetInt(conf.LoginLockDuration, 5)) * time.Minute
+	maxLockDuration = time.Duration(setting.GetInt(conf.LoginMaxLockDuration, 1440)) * time.Minute
+	if maxLockDuration < lockDuration {
+		maxLockDuration = lockDuration
+	}
+	return
+}
+
+// CheckLogin returns a *LoginLockedError if the account or the ip
+// is locked, empty username or ip is not checked
+func CheckLogin(username, ip string) error {
+	now := time.Now()
+	var until time.Time
+	for _, k := range loginKeys(username, ip) {
+		if l := getLoginLock(k); l != nil && l.locked(now) && l.LockedUntil.After(until) {
+			until = l.LockedUntil
+		}
+	}
+	if !until.IsZero() {
+		return &LoginLockedError{Until: until}
+	}
+	return nil
+}
+
+// CaptchaRequired reports whether the web login of the account or from
+// the ip has to pass a captcha
+func CaptchaRequired(username, ip string) bool {
+	threshold := setting.GetInt(conf.LoginCaptchaFailures, 0)
+	if threshold <= 0 {
+		return false
+	}
+	for _, k := range loginKeys(username, ip) {
+		// a locked counter has been reset, but the captcha is still required
+		if l := getLoginLock(k); l != nil && (l.Failures >= threshold || l.Locks > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoginFailed records a failed login attempt of the account from the ip.
+// Once a counter reaches the max failures, it is locked for the lock
+// duration, which doubles every time it is locked again.
+func LoginFailed(username, ip, protocol string, reason error) {
+	now := time.Now()
+	maxFailures, lockDuration, maxLockDuration := loginLockSettings()
+	var lockedUntil time.Time
+	for _, k := range loginKeys(username, ip) {
+		loginLocks.Set(k, map[string]int64{loginLastFailure: now.UnixMilli()}, maxLockDuration)
+		failures := loginLocks.Incr(k, loginFailures, 1, maxLockDuration)
+		// only the attempt reaching the max failures locks the counter, the
+		// ones failed at the same time on the other nodes count for the next
+		if maxFailures <= 0 || failures != int64(maxFailures) {
+			continue
+		}
+		locks := loginLocks.Incr(k, loginLocksCount, 1, maxLockDuration)
+		d := lockDuration << min(max(locks-1, 0), 30)
+		if d <= 0 || d > maxLockDuration {
+			d = maxLockDuration
+		}
+		until := now.Add(d)
+		loginLocks.Incr(k, loginFailures, -failures, d+maxLockDuration)
+		loginLocks.Set(k, map[string]int64{loginLockedUntil: until.UnixMilli()}, d+maxLockDuration)
+		if until.After(lockedUntil) {
+			lockedUntil = until
+		}
+	}
+
+	detail := ""
+	if reason != nil {
+		detail = reason.Error()
+	}
+	audit(model.AuditLoginFailed, username, ip, protocol, detail)
+	if !lockedUntil.IsZero() {
+		audit(model.AuditLoginLocked, username, ip, protocol, "locked until "+lockedUntil.Format(time.RFC3339))
+	}
+}
+
+// LoginSucceeded clears the failure counters of the account and the ip
+func LoginSucceeded(username, ip string) {
+	loginLocks.Del(loginKeys(username, ip)...)
+}
+
+// ListLoginLocks returns the failure counters sorted by the last failure
+func ListLoginLocks() []LoginLock {
+	all := loginLocks.All()
+	res := make([]LoginLock, 0, len(all))
+	for k, fields := range all {
+		res = append(res, *newLoginLock(k, fields))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].LastFailure.After(res[j].LastFailure)
+	})
+	return res
+}
+
+func UnlockLogin(username, ip, operator string) {
+	LoginSucceeded(username, ip)
+	audit(model.AuditLoginUnlocked, username, ip, "", "unlocked by "+operator)
+}
+
+func audit(action, username, ip, protocol, detail string) {
+	l := &model.AuditLog{
+		Time:     time.Now(),
+		Action:   action,
+		Username: username,
+		IP:       hostOf(ip),
+		Protocol: protocol,
+		Detail:   detail,
+	}
+	utils.Log.Warnf("[audit] %s: user=%s ip=%s protocol=%s %s", action, username, l.IP, protocol, detail)
+	if err := op.CreateAuditLog(l); err != nil {
+		utils.Log.Errorf("failed to save audit log: %+v", err)
+	}
+}
+
+// hostOf strips the port of a remote address, so that all the connections
+// from the same host share the counter
+func hostOf(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,92 @@
+package common
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+)
+
+func initLoginTestDB(t *testing.T) {
+	db.Init(dbtest.Open(t))
+	err := db.SaveSettingItems([]model.SettingItem{
+		{Key: conf.LoginMaxFailures, Value: "3"},
+		{Key: conf.LoginLockDuration, Value: "1"},
+		{Key: conf.LoginMaxLockDuration, Value: "3"},
+		{Key: conf.LoginCaptchaFailures, Value: "2"},
+	})
+	if err != nil {
+		t.Fatalf("failed to save settings: %v", err)
+	}
+	op.SettingCacheUpdate()
+}
+
+func TestLoginLock(t *testing.T) {
+	initLoginTestDB(t)
+	ip := "10.0.0.1:2121"
+	fail := errors.New("wrong password")
+
+	LoginFailed("alice", ip, LoginProtocolFTP, fail)
+	if CaptchaRequired("alice", "") {
+		t.Fatalf("captcha required after 1 failure")
+	}
+	LoginFailed("alice", ip, LoginProtocolFTP, fail)
+	if !CaptchaRequired("alice", "") || !CaptchaRequired("", "10.0.0.1") {
+		t.Fatalf("captcha not required after 2 failures")
+	}
+	if err := CheckLogin("alice", ip); err != nil {
+		t.Fatalf("locked after 2 failures: %v", err)
+	}
+	LoginFailed("alice", ip, LoginProtocolFTP, fail)
+	var locked *LoginLockedError
+	if err := CheckLogin("alice", "10.0.0.2"); !errors.As(err, &locked) {
+		t.Fatalf("account not locked after 3 failures: %v", err)
+	}
+	if d := time.Until(locked.Until); d <= 0 || d > time.Minute {
+		t.Fatalf("first lock lasts %s, want 1m", d)
+	}
+	if err := CheckLogin("bob", "10.0.0.1"); err == nil {
+		t.Fatalf("ip not locked after 3 failures")
+	}
+
+	// the second lock doubles
+	for i := 0; i < 3; i++ {
+		LoginFailed("alice", ip, LoginProtocolFTP, fail)
+	}
+	if err := CheckLogin("alice", ""); !errors.As(err, &locked) {
+		t.Fatalf("account not locked again: %v", err)
+	}
+	if d := time.Until(locked.Until); d <= time.Minute || d > 2*time.Minute {
+		t.Fatalf("second lock lasts %s, want 2m", d)
+	}
+	// and is capped by the max lock duration
+	for i := 0; i < 6; i++ {
+		LoginFailed("alice", ip, LoginProtocolFTP, fail)
+	}
+	if err := CheckLogin("alice", ""); !errors.As(err, &locked) {
+		t.Fatalf("account not locked again: %v", err)
+	}
+	if d := time.Until(locked.Until); d <= 2*time.Minute || d > 3*time.Minute {
+		t.Fatalf("capped lock lasts %s, want 3m", d)
+	}
+
+	UnlockLogin("alice", "10.0.0.1", "admin")
+	if err := CheckLogin("alice", ip); err != nil {
+		t.Fatalf("still locked after unlock: %v", err)
+	}
+	if len(ListLoginLocks()) != 0 {
+		t.Fatalf("counters left after unlock")
+	}
+	logs, total, err := op.GetAuditLogs(1, 100, model.AuditLoginLocked, "alice")
+	if err != nil {
+		t.Fatalf("failed to get audit logs: %v", err)
+	}
+	if total != 4 || logs[0].IP != "10.0.0.1" || logs[0].Protocol != LoginProtocolFTP {
+		t.Fatalf("unexpected audit logs: %d %+v", total, logs)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/OpenListTeam/OpenList/internal/op"
 	"github.com/OpenListTeam/OpenList/internal/setting"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/OpenListTeam/OpenList/server/common"
 	"github.com/OpenListTeam/OpenList/server/ftp"
 	ftpserver "github.com/fclairamb/ftpserverlib"
 )
@@ -26,6 +27,7 @@ type FtpMainDriver struct {
 	settings     *ftpserver.Settings
 	proxyHeader  *http.Header
 	clients      map[uint32]ftpserver.ClientContext
+	sessions     sync.Map
 	shutdownLock sync.RWMutex
 	isShutdown   bool
 	tlsConfig    *tls.Config
@@ -107,39 +109,64 @@ func (d *FtpMainDriver) ClientDisconnected(cc ftpserver.ClientContext) {
 		utils.Log.Errorf("failed to close client: %v", err)
 	}
 	delete(d.clients, cc.ID())
+	if sess, ok := d.sessions.LoadAndDelete(cc.ID()); ok {
+		sess.(*ftp.Session).Release()
+	}
 }
 
 func (d *FtpMainDriver) AuthUser(cc ftpserver.ClientContext, user, pass string) (ftpserver.ClientDriver, error) {
 	var userObj *model.User
 	var err error
+	ip := cc.RemoteAddr().String()
 	if user == "anonymous" || user == "guest" {
+		if err = common.CheckLogin("", ip); err != nil {
+			return nil, err
+		}
 		userObj, err = op.GetGuest()
 		if err != nil {
 			return nil, err
 		}
 	} else {
+		if err = common.CheckLogin(user, ip); err != nil {
+			return nil, err
+		}
 		userObj, err = op.GetUserByName(user)
 		if err != nil {
+			common.LoginFailed(user, ip, common.LoginProtocolFTP, err)
 			return nil, err
 		}
 		passHash := model.StaticHash(pass)
 		if err = userObj.ValidatePwdStaticHash(passHash); err != nil {
+			common.LoginFailed(user, ip, common.LoginProtocolFTP, err)
 			return nil, err
 		}
+		common.LoginSucceeded(user, ip)
 	}
 	if userObj.Disabled || !userObj.CanFTPAccess() {
 		return nil, errors.New("user is not allowed to access via FTP")
 	}
+	ftpUser, err := userObj.FtpUser()
+	if err != nil {
+		return nil, err
+	}
+	sess, err := ftp.AcquireSession(userObj, ip, "ftp")
+	if err != nil {
+		return nil, err
+	}
+	if old, ok := d.sessions.Swap(cc.ID(), sess); ok {
+		old.(*ftp.Session).Release()
+	}
 
 	ctx := context.Background()
-	ctx = context.WithValue(ctx, "user", userObj)
+	ctx = context.WithValue(ctx, "user", ftpUser)
 	if user == "anonymous" || user == "guest" {
 		ctx = context.WithValue(ctx, "meta_pass", pass)
 	} else {
 		ctx = context.WithValue(ctx, "meta_pass", "")
 	}
-	ctx = context.WithValue(ctx, "client_ip", cc.RemoteAddr().String())
+	ctx = context.WithValue(ctx, "client_ip", ip)
 	ctx = context.WithValue(ctx, "proxy_header", d.proxyHeader)
+	ctx = context.WithValue(ctx, "session", sess)
 	return ftp.NewAferoAdapter(ctx), nil
 }
 
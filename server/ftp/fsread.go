@@ -2,6 +2,7 @@ package ftp
 
 import (
 	"context"
+	"io"
 	fs2 "io/fs"
 	"net/http"
 	"os"
@@ -19,7 +20,12 @@ import (
 
 type FileDownloadProxy struct {
 	ftpserver.FileTransfer
-	reader stream.SStreamReadAtSeeker
+	reader  stream.SStreamReadAtSeeker
+	session *Session
+	path    string
+	start   time.Time
+	bytes   int64
+	eof     bool
 }
 
 func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownloadProxy, error) {
@@ -57,15 +63,22 @@ func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownl
 		_ = ss.Close()
 		return nil, err
 	}
-	return &FileDownloadProxy{reader: reader}, nil
+	return &FileDownloadProxy{
+		reader:  reader,
+		session: getSession(ctx),
+		path:    reqPath,
+		start:   time.Now(),
+	}, nil
 }
 
 func (f *FileDownloadProxy) Read(p []byte) (n int, err error) {
 	n, err = f.reader.Read(p)
+	f.bytes += int64(n)
 	if err != nil {
+		f.eof = err == io.EOF
 		return
 	}
-	err = stream.ClientDownloadLimit.WaitN(f.reader.GetRawStream().Ctx, n)
+	err = f.session.WaitDownload(f.reader.GetRawStream().Ctx, n)
 	return
 }
 
@@ -78,6 +91,7 @@ func (f *FileDownloadProxy) Seek(offset int64, whence int) (int64, error) {
 }
 
 func (f *FileDownloadProxy) Close() error {
+	logTransfer(f.session, f.path, f.bytes, f.start, false, f.eof)
 	return f.reader.Close()
 }
 
@@ -26,6 +26,7 @@ type FileUploadProxy struct {
 	path   string
 	ctx    context.Context
 	trunc  bool
+	start  time.Time
 }
 
 func uploadAuth(ctx context.Context, path string) error {
@@ -52,7 +53,7 @@ func OpenUpload(ctx context.Context, path string, trunc bool) (*FileUploadProxy,
 	if err != nil {
 		return nil, err
 	}
-	return &FileUploadProxy{buffer: tmpFile, path: path, ctx: ctx, trunc: trunc}, nil
+	return &FileUploadProxy{buffer: tmpFile, path: path, ctx: ctx, trunc: trunc, start: time.Now()}, nil
 }
 
 func (f *FileUploadProxy) Read(p []byte) (n int, err error) {
@@ -64,7 +65,7 @@ func (f *FileUploadProxy) Write(p []byte) (n int, err error) {
 	if err != nil {
 		return
 	}
-	err = stream.ClientUploadLimit.WaitN(f.ctx, n)
+	err = getSession(f.ctx).WaitUpload(f.ctx, n)
 	return
 }
 
@@ -72,12 +73,15 @@ func (f *FileUploadProxy) Seek(offset int64, whence int) (int64, error) {
 	return f.buffer.Seek(offset, whence)
 }
 
-func (f *FileUploadProxy) Close() error {
+func (f *FileUploadProxy) Close() (err error) {
 	dir, name := stdpath.Split(f.path)
 	size, err := f.buffer.Seek(0, io.SeekCurrent)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		logTransfer(getSession(f.ctx), f.path, size, f.start, true, err == nil)
+	}()
 	if _, err := f.buffer.Seek(0, io.SeekStart); err != nil {
 		return err
 	}
@@ -115,6 +119,8 @@ type FileUploadWithLengthProxy struct {
 	pFirst        int
 	pipeWriter    io.WriteCloser
 	errChan       chan error
+	start         time.Time
+	written       int64
 }
 
 func OpenUploadWithLength(ctx context.Context, path string, trunc bool, length int64) (*FileUploadWithLengthProxy, error) {
@@ -125,7 +131,7 @@ func OpenUploadWithLength(ctx context.Context, path string, trunc bool, length i
 	if trunc {
 		_ = fs.Remove(ctx, path)
 	}
-	return &FileUploadWithLengthProxy{ctx: ctx, path: path, length: length}, nil
+	return &FileUploadWithLengthProxy{ctx: ctx, path: path, length: length, start: time.Now()}, nil
 }
 
 func (f *FileUploadWithLengthProxy) Read(p []byte) (n int, err error) {
@@ -181,10 +187,11 @@ func (f *FileUploadWithLengthProxy) write(p []byte) (n int, err error) {
 
 func (f *FileUploadWithLengthProxy) Write(p []byte) (n int, err error) {
 	n, err = f.write(p)
+	f.written += int64(n)
 	if err != nil {
 		return
 	}
-	err = stream.ClientUploadLimit.WaitN(f.ctx, n)
+	err = getSession(f.ctx).WaitUpload(f.ctx, n)
 	return
 }
 
@@ -192,9 +199,12 @@ func (f *FileUploadWithLengthProxy) Seek(offset int64, whence int) (int64, error
 	return 0, errs.NotSupport
 }
 
-func (f *FileUploadWithLengthProxy) Close() error {
+func (f *FileUploadWithLengthProxy) Close() (err error) {
+	defer func() {
+		logTransfer(getSession(f.ctx), f.path, f.written, f.start, true, err == nil)
+	}()
 	if f.pipeWriter != nil {
-		err := f.pipeWriter.Close()
+		err = f.pipeWriter.Close()
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,134 @@
+package ftp
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/setting"
+	"github.com/OpenListTeam/OpenList/internal/stream"
+	"github.com/pkg/errors"
+)
+
+// Session is an authenticated FTP or SFTP connection
+type Session struct {
+	User     *model.User
+	IP       string
+	Protocol string
+	Guest    bool
+
+	limit   *userLimit
+	release sync.Once
+}
+
+// userLimit is shared by all the sessions of a user
+type userLimit struct {
+	sessions int
+	download stream.Limiter
+	upload   stream.Limiter
+}
+
+var sessions = struct {
+	sync.Mutex
+	users map[uint]*userLimit
+	ips   map[string]int
+}{
+	users: make(map[uint]*userLimit),
+	ips:   make(map[string]int),
+}
+
+// AcquireSession registers a new session of the user, it fails if the user
+// or the ip already reaches the max sessions. The session must be released
+// when the connection is closed.
+func AcquireSession(user *model.User, remoteAddr, protocol string) (*Session, error) {
+	ip := remoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		ip = host
+	}
+	sessions.Lock()
+	defer sessions.Unlock()
+	limit := sessions.users[user.ID]
+	if maxUser := setting.GetInt(conf.FTPMaxSessionsPerUser, 0); maxUser > 0 && limit != nil && limit.sessions >= maxUser {
+		return nil, errors.Errorf("too many sessions of user %s", user.Username)
+	}
+	if maxIP := setting.GetInt(conf.FTPMaxSessionsPerIP, 0); maxIP > 0 && sessions.ips[ip] >= maxIP {
+		return nil, errors.Errorf("too many sessions from %s", ip)
+	}
+	if limit == nil {
+		limit = &userLimit{
+			download: stream.NewSpeedLimiter(setting.GetInt(conf.FTPUserDownloadSpeed, -1)),
+			upload:   stream.NewSpeedLimiter(setting.GetInt(conf.FTPUserUploadSpeed, -1)),
+		}
+		sessions.users[user.ID] = limit
+	}
+	limit.sessions++
+	sessions.ips[ip]++
+	return &Session{
+		User:     user,
+		IP:       ip,
+		Protocol: protocol,
+		Guest:    user.IsGuest(),
+		limit:    limit,
+	}, nil
+}
+
+// Release unregisters the session, it is safe to call it more than once
+func (s *Session) Release() {
+	s.release.Do(func() {
+		sessions.Lock()
+		defer sessions.Unlock()
+		s.limit.sessions--
+		if s.limit.sessions <= 0 {
+			delete(sessions.users, s.User.ID)
+		}
+		sessions.ips[s.IP]--
+		if sessions.ips[s.IP] <= 0 {
+			delete(sessions.ips, s.IP)
+		}
+	})
+}
+
+// WaitDownload waits for both the global and the user's download limit
+func (s *Session) WaitDownload(ctx context.Context, n int) error {
+	if err := stream.ClientDownloadLimit.WaitN(ctx, n); err != nil {
+		return err
+	}
+	if s == nil {
+		return nil
+	}
+	return s.limit.download.WaitN(ctx, n)
+}
+
+// WaitUpload waits for both the global and the user's upload limit
+func (s *Session) WaitUpload(ctx context.Context, n int) error {
+	if err := stream.ClientUploadLimit.WaitN(ctx, n); err != nil {
+		return err
+	}
+	if s == nil {
+		return nil
+	}
+	return s.limit.upload.WaitN(ctx, n)
+}
+
+func getSession(ctx context.Context) *Session {
+	s, _ := ctx.Value("session").(*Session)
+	return s
+}
+
+func init() {
+	op.RegisterSettingChangingCallback(func() {
+		downLimit, downBurst := stream.SpeedLimit(setting.GetInt(conf.FTPUserDownloadSpeed, -1))
+		upLimit, upBurst := stream.SpeedLimit(setting.GetInt(conf.FTPUserUploadSpeed, -1))
+		sessions.Lock()
+		defer sessions.Unlock()
+		for _, limit := range sessions.users {
+			limit.download.SetLimit(downLimit)
+			limit.download.SetBurst(downBurst)
+			limit.upload.SetLimit(upLimit)
+			limit.upload.SetBurst(upBurst)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package ftp
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/setting"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+)
+
+var xferLog struct {
+	sync.Mutex
+	path string
+	file *os.File
+}
+
+// logTransfer appends a record of the transfer to the transfer log in the
+// xferlog format of wu-ftpd, see xferlog(5)
+func logTransfer(s *Session, path string, bytes int64, start time.Time, incoming, complete bool) {
+	if s == nil {
+		return
+	}
+	logPath := setting.GetStr(conf.FTPTransferLog)
+	now := time.Now()
+	direction, status, accessMode := "o", "i", "r"
+	if incoming {
+		direction = "i"
+	}
+	if complete {
+		status = "c"
+	}
+	if s.Guest {
+		accessMode = "a"
+	}
+	seconds := int64(now.Sub(start).Round(time.Second) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	line := fmt.Sprintf("%s %d %s %d %s b _ %s %s %s %s 0 * %s\n",
+		now.Format("Mon Jan _2 15:04:05 2006"), seconds, s.IP, bytes,
+		strings.ReplaceAll(path, " ", "_"), direction, accessMode,
+		s.User.Username, s.Protocol, status)
+
+	xferLog.Lock()
+	defer xferLog.Unlock()
+	if logPath != xferLog.path {
+		if xferLog.file != nil {
+			_ = xferLog.file.Close()
+			xferLog.file = nil
+		}
+		xferLog.path = logPath
+		if logPath != "" {
+			f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+			if err != nil {
+				utils.Log.Errorf("failed to open FTP transfer log: %+v", err)
+			} else {
+				xferLog.file = f
+			}
+		}
+	}
+	if xferLog.file == nil {
+		return
+	}
+	if _, err := xferLog.file.WriteString(line); err != nil {
+		utils.Log.Errorf("failed to write FTP transfer log: %+v", err)
+	}
+}
@@ -234,6 +234,7 @@ type ArchiveDecompressReq struct {
 	InnerPath     string        `json:"inner_path" form:"inner_path"`
 	CacheFull     bool          `json:"cache_full" form:"cache_full"`
 	PutIntoNewDir bool          `json:"put_into_new_dir" form:"put_into_new_dir"`
+	Priority      int           `json:"priority" form:"priority"`
 }
 
 func FsArchiveDecompress(c *gin.Context) {
@@ -242,6 +243,7 @@ func FsArchiveDecompress(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	c.Set(conf.TaskPriorityKey, clampPriority(c, req.Priority))
 	user := c.MustGet("user").(*model.User)
 	if !user.CanDecompress() {
 		common.ErrorResp(c, errs.PermissionDenied, 403)
@@ -3,27 +3,22 @@ package handles
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"image/png"
-	"time"
 
 	"github.com/OpenListTeam/OpenList/internal/model"
 	"github.com/OpenListTeam/OpenList/internal/op"
 	"github.com/OpenListTeam/OpenList/server/common"
-	"github.com/Xhofe/go-cache"
 	"github.com/gin-gonic/gin"
 	"github.com/pquerna/otp/totp"
 )
 
-var loginCache = cache.NewMemCache[int]()
-var (
-	defaultDuration = time.Minute * 5
-	defaultTimes    = 5
-)
-
 type LoginReq struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password"`
-	OtpCode  string `json:"otp_code"`
+	Username    string `json:"username" binding:"required"`
+	Password    string `json:"password"`
+	OtpCode     string `json:"otp_code"`
+	CaptchaID   string `json:"captcha_id"`
+	CaptchaCode string `json:"captcha_code"`
 }
 
 // Login Deprecated
@@ -47,44 +42,56 @@ func LoginHash(c *gin.Context) {
 	loginHash(c, &req)
 }
 
+// checkLoginAttempt rejects the login if the account or the ip is locked,
+// or if a captcha is required but not passed
+func checkLoginAttempt(c *gin.Context, req *LoginReq) bool {
+	ip := c.ClientIP()
+	if err := common.CheckLogin(req.Username, ip); err != nil {
+		common.ErrorResp(c, err, 429)
+		return false
+	}
+	if common.CaptchaRequired(req.Username, ip) && !verifyCaptcha(req.CaptchaID, req.CaptchaCode) {
+		common.ErrorWithDataResp(c, errors.New("captcha is required"), 428, gin.H{"captcha": true})
+		return false
+	}
+	return true
+}
+
 func loginHash(c *gin.Context, req *LoginReq) {
 	// check count of login
 	ip := c.ClientIP()
-	count, ok := loginCache.Get(ip)
-	if ok && count >= defaultTimes {
-		common.ErrorStrResp(c, "Too many unsuccessful sign-in attempts have been made using an incorrect username or password, Try again later.", 429)
-		loginCache.Expire(ip, defaultDuration)
+	if !checkLoginAttempt(c, req) {
 		return
 	}
 	// check username
 	user, err := op.GetUserByName(req.Username)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
-		loginCache.Set(ip, count+1)
+		common.LoginFailed(req.Username, ip, common.LoginProtocolWeb, err)
 		return
 	}
 	// validate password hash
 	if err := user.ValidatePwdStaticHash(req.Password); err != nil {
 		common.ErrorResp(c, err, 400)
-		loginCache.Set(ip, count+1)
+		common.LoginFailed(req.Username, ip, common.LoginProtocolWeb, err)
 		return
 	}
 	// check 2FA
 	if user.OtpSecret != "" {
 		if !totp.Validate(req.OtpCode, user.OtpSecret) {
 			common.ErrorStrResp(c, "Invalid 2FA code", 402)
-			loginCache.Set(ip, count+1)
+			common.LoginFailed(req.Username, ip, common.LoginProtocolWeb, errors.New("invalid 2FA code"))
 			return
 		}
 	}
 	// generate token
-	token, err := common.GenerateToken(user)
+	token, err := common.GenerateToken(c, user, model.LoginMethodPassword)
 	if err != nil {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
-	loginCache.Del(ip)
+	common.LoginSucceeded(req.Username, ip)
 }
 
 type UserResp struct {
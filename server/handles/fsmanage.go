@@ -5,6 +5,7 @@ import (
 	"io"
 	stdpath "path"
 
+	"github.com/OpenListTeam/OpenList/internal/conf"
 	"github.com/OpenListTeam/OpenList/internal/task"
 
 	"github.com/OpenListTeam/OpenList/internal/errs"
@@ -61,6 +62,7 @@ type MoveCopyReq struct {
 	DstDir    string   `json:"dst_dir"`
 	Names     []string `json:"names"`
 	Overwrite bool     `json:"overwrite"`
+	Priority  int      `json:"priority"`
 }
 
 func FsMove(c *gin.Context) {
@@ -112,6 +114,7 @@ func FsCopy(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	c.Set(conf.TaskPriorityKey, clampPriority(c, req.Priority))
 	if len(req.Names) == 0 {
 		common.ErrorStrResp(c, "Empty file names", 400)
 		return
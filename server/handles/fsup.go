@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/internal/conf"
 	"github.com/OpenListTeam/OpenList/internal/fs"
 	"github.com/OpenListTeam/OpenList/internal/model"
 	"github.com/OpenListTeam/OpenList/internal/stream"
@@ -35,6 +36,9 @@ func FsStream(c *gin.Context) {
 		return
 	}
 	asTask := c.GetHeader("As-Task") == "true"
+	if priority, err := strconv.Atoi(c.GetHeader("Task-Priority")); err == nil {
+		c.Set(conf.TaskPriorityKey, clampPriority(c, priority))
+	}
 	overwrite := c.GetHeader("Overwrite") != "false"
 	user := c.MustGet("user").(*model.User)
 	path, err = user.JoinPath(path)
@@ -112,6 +116,9 @@ func FsForm(c *gin.Context) {
 		return
 	}
 	asTask := c.GetHeader("As-Task") == "true"
+	if priority, err := strconv.Atoi(c.GetHeader("Task-Priority")); err == nil {
+		c.Set(conf.TaskPriorityKey, clampPriority(c, priority))
+	}
 	overwrite := c.GetHeader("Overwrite") != "false"
 	user := c.MustGet("user").(*model.User)
 	path, err = user.JoinPath(path)
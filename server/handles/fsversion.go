@@ -0,0 +1,114 @@
+package handles
+
+import (
+	"fmt"
+	stdpath "path"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/fs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/sign"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/OpenListTeam/OpenList/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FsVersionsReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+}
+
+type FsVersionResp struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	RawURL   string    `json:"raw_url"`
+}
+
+// FsListVersions lists the previous versions of a file
+func FsListVersions(c *gin.Context) {
+	var req FsVersionsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+	}
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	versions, err := fs.ListVersions(c, reqPath)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	resp := make([]FsVersionResp, 0, len(versions))
+	for _, v := range versions {
+		resp = append(resp, FsVersionResp{
+			Name:     v.GetName(),
+			Size:     v.GetSize(),
+			Modified: v.ModTime(),
+			RawURL: fmt.Sprintf("%s/d%s?sign=%s",
+				common.GetApiUrl(c.Request),
+				utils.EncodePath(v.GetPath(), true),
+				sign.Sign(v.GetPath())),
+		})
+	}
+	common.SuccessResp(c, resp)
+}
+
+type FsRestoreVersionReq struct {
+	Path     string `json:"path" form:"path" binding:"required"`
+	Version  string `json:"version" form:"version" binding:"required"`
+	Password string `json:"password" form:"password"`
+}
+
+// FsRestoreVersion makes a previous version the current content of a file
+func FsRestoreVersion(c *gin.Context) {
+	var req FsRestoreVersionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil {
+		if !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	if !user.CanWrite() && !common.CanWrite(meta, stdpath.Dir(reqPath)) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if err := fs.RestoreVersion(c, reqPath, req.Version); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c)
+}
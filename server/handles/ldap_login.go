@@ -36,10 +36,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 
 	// check count of login
 	ip := c.ClientIP()
-	count, ok := loginCache.Get(ip)
-	if ok && count >= defaultTimes {
-		common.ErrorStrResp(c, "Too many unsuccessful sign-in attempts have been made using an incorrect username or password, Try again later.", 429)
-		loginCache.Expire(ip, defaultDuration)
+	if !checkLoginAttempt(c, req) {
 		return
 	}
 
@@ -85,6 +82,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 	if len(sr.Entries) != 1 {
 		utils.Log.Errorf("User does not exist or too many entries returned")
 		common.ErrorResp(c, err, 500)
+		common.LoginFailed(req.Username, ip, common.LoginProtocolLdap, errors.New("user does not exist or too many entries returned"))
 		return
 	}
 	userDN := sr.Entries[0].DN
@@ -94,7 +92,7 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 	if err != nil {
 		utils.Log.Errorf("Failed to auth. %v", err)
 		common.ErrorResp(c, err, 400)
-		loginCache.Set(ip, count+1)
+		common.LoginFailed(req.Username, ip, common.LoginProtocolLdap, err)
 		return
 	} else {
 		utils.Log.Infof("Auth successful username:%s", req.Username)
@@ -106,19 +104,19 @@ func loginLdap(c *gin.Context, req *LoginReq) {
 		user, err = ladpRegister(req.Username)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
-			loginCache.Set(ip, count+1)
+			common.LoginFailed(req.Username, ip, common.LoginProtocolLdap, err)
 			return
 		}
 	}
 
 	// generate token
-	token, err := common.GenerateToken(user)
+	token, err := common.GenerateToken(c, user, model.LoginMethodLdap)
 	if err != nil {
 		common.ErrorResp(c, err, 400, true)
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
-	loginCache.Del(ip)
+	common.LoginSucceeded(req.Username, ip)
 }
 
 func ladpRegister(username string) (*model.User, error) {
@@ -0,0 +1,57 @@
+package handles
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func ListLoginLocks(c *gin.Context) {
+	common.SuccessResp(c, common.ListLoginLocks())
+}
+
+type UnlockLoginReq struct {
+	Username string `json:"username"`
+	IP       string `json:"ip"`
+}
+
+// UnlockLogin clears the failed login attempts of an account and/or an ip
+func UnlockLogin(c *gin.Context) {
+	var req UnlockLoginReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Username == "" && req.IP == "" {
+		common.ErrorStrResp(c, "username or ip is required", 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	common.UnlockLogin(req.Username, req.IP, user.Username)
+	common.SuccessResp(c)
+}
+
+type ListAuditLogsReq struct {
+	model.PageReq
+	Action   string `json:"action" form:"action"`
+	Username string `json:"username" form:"username"`
+}
+
+func ListAuditLogs(c *gin.Context) {
+	var req ListAuditLogsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	logs, total, err := op.GetAuditLogs(req.Page, req.PerPage, req.Action, req.Username)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/OpenListTeam/OpenList/drivers/pikpak"
 	"github.com/OpenListTeam/OpenList/drivers/thunder"
 	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/errs"
 	"github.com/OpenListTeam/OpenList/internal/model"
 	"github.com/OpenListTeam/OpenList/internal/offline_download/tool"
 	"github.com/OpenListTeam/OpenList/internal/op"
@@ -107,6 +108,41 @@ func SetTransmission(c *gin.Context) {
 	common.SuccessResp(c, "ok")
 }
 
+type SetBitTorrentReq struct {
+	Port      string `json:"port" form:"port"`
+	DHT       string `json:"dht" form:"dht"`
+	Seedtime  string `json:"seedtime" form:"seedtime"`
+	SeedRatio string `json:"seed_ratio" form:"seed_ratio"`
+}
+
+func SetBitTorrent(c *gin.Context) {
+	var req SetBitTorrentReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	items := []model.SettingItem{
+		{Key: conf.BitTorrentPort, Value: req.Port, Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+		{Key: conf.BitTorrentDHT, Value: req.DHT, Type: conf.TypeBool, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+		{Key: conf.BitTorrentSeedtime, Value: req.Seedtime, Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+		{Key: conf.BitTorrentSeedRatio, Value: req.SeedRatio, Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE},
+	}
+	if err := op.SaveSettingItems(items); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	_tool, err := tool.Tools.Get("BitTorrent")
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if _, err := _tool.Init(); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, "ok")
+}
+
 type Set115Req struct {
 	TempDir string `json:"temp_dir" form:"temp_dir"`
 }
@@ -249,6 +285,17 @@ type AddOfflineDownloadReq struct {
 	Path         string   `json:"path"`
 	Tool         string   `json:"tool"`
 	DeletePolicy string   `json:"delete_policy"`
+	Priority     int      `json:"priority"`
+	// Headers, the basic auth and the expected hash are applied to each url
+	Headers  map[string]string `json:"headers"`
+	Username string            `json:"username"`
+	Password string            `json:"password"`
+	Hash     string            `json:"hash"`
+	// SelectFiles is the indexes of the files in the torrents to download
+	SelectFiles string `json:"select_files"`
+	// Credential is the mount path of the storage whose credential is used
+	// to login the ftp, sftp, smb or webdav server
+	Credential string `json:"credential"`
 }
 
 func AddOfflineDownload(c *gin.Context) {
@@ -263,6 +310,12 @@ func AddOfflineDownload(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	c.Set(conf.TaskPriorityKey, clampPriority(c, req.Priority))
+	// the credentials of the storages are only for the admin
+	if req.Credential != "" && !user.IsAdmin() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
 	reqPath, err := user.JoinPath(req.Path)
 	if err != nil {
 		common.ErrorResp(c, err, 403)
@@ -275,6 +328,12 @@ func AddOfflineDownload(c *gin.Context) {
 			DstDirPath:   reqPath,
 			Tool:         req.Tool,
 			DeletePolicy: tool.DeletePolicy(req.DeletePolicy),
+			Headers:      req.Headers,
+			Username:     req.Username,
+			Password:     req.Password,
+			Hash:         req.Hash,
+			SelectFiles:  req.SelectFiles,
+			Credential:   req.Credential,
 		})
 		if err != nil {
 			common.ErrorResp(c, err, 500)
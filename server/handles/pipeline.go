@@ -0,0 +1,74 @@
+package handles
+
+import (
+	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/pipeline"
+	"github.com/OpenListTeam/OpenList/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type AddPipelineReq struct {
+	Name      string                 `json:"name"`
+	OnFailure pipeline.FailurePolicy `json:"on_failure"`
+	Priority  int                    `json:"priority"`
+	Steps     []*pipeline.Step       `json:"steps"`
+}
+
+func canRunStep(user *model.User, t pipeline.StepType) bool {
+	switch t {
+	case pipeline.StepOfflineDownload:
+		return user.CanAddOfflineDownloadTasks()
+	case pipeline.StepDecompress:
+		return user.CanDecompress()
+	case pipeline.StepCopy:
+		return user.CanCopy()
+	case pipeline.StepMove:
+		return user.CanMove()
+	case pipeline.StepRemove:
+		return user.CanRemove()
+	}
+	return false
+}
+
+func AddPipeline(c *gin.Context) {
+	var req AddPipelineReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	c.Set(conf.TaskPriorityKey, clampPriority(c, req.Priority))
+	if err := pipeline.Validate(req.Steps, req.OnFailure); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	for _, step := range req.Steps {
+		if !canRunStep(user, step.Type) {
+			common.ErrorResp(c, errs.PermissionDenied, 403)
+			return
+		}
+		var err error
+		for i, src := range step.Src {
+			if step.Src[i], err = user.JoinPath(src); err != nil {
+				common.ErrorResp(c, err, 403)
+				return
+			}
+		}
+		if step.DstDir != "" {
+			if step.DstDir, err = user.JoinPath(step.DstDir); err != nil {
+				common.ErrorResp(c, err, 403)
+				return
+			}
+		}
+	}
+	t, err := pipeline.Add(c, req.Name, req.OnFailure, req.Steps)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": getTaskInfo(t),
+	})
+}
@@ -0,0 +1,120 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type SessionResp struct {
+	model.Session
+	Current bool `json:"current"`
+}
+
+func toSessionResps(sessions []model.Session, current *model.Session) []SessionResp {
+	resp := make([]SessionResp, len(sessions))
+	for i, s := range sessions {
+		resp[i] = SessionResp{
+			Session: s,
+			Current: current != nil && s.ID == current.ID,
+		}
+	}
+	return resp
+}
+
+func currentSession(c *gin.Context) *model.Session {
+	s, _ := c.Value("session").(*model.Session)
+	return s
+}
+
+// ListMySessions lists where the current user is logged in
+func ListMySessions(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	if user.IsGuest() {
+		common.ErrorStrResp(c, "Guest user has no session", 403)
+		return
+	}
+	sessions, err := op.GetSessionsByUserID(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, toSessionResps(sessions, currentSession(c)))
+}
+
+// RevokeMySession logs out one session of the current user
+func RevokeMySession(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	id := c.Query("id")
+	session, err := op.GetSession(id)
+	if err != nil || session.UserID != user.ID {
+		common.ErrorStrResp(c, "session not found", 404)
+		return
+	}
+	if err := op.DeleteSession(id); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RevokeMyOtherSessions logs out all the sessions of the current user but
+// the current one
+func RevokeMyOtherSessions(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	current := currentSession(c)
+	sessions, err := op.GetSessionsByUserID(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	for _, s := range sessions {
+		if current != nil && s.ID == current.ID {
+			continue
+		}
+		if err := op.DeleteSession(s.ID); err != nil {
+			common.ErrorResp(c, err, 500, true)
+			return
+		}
+	}
+	common.SuccessResp(c)
+}
+
+func ListUserSessions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	sessions, err := op.GetSessionsByUserID(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, toSessionResps(sessions, currentSession(c)))
+}
+
+func RevokeSession(c *gin.Context) {
+	if err := op.DeleteSession(c.Query("id")); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// ForceLogout logs out all the sessions of a user
+func ForceLogout(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteSessionsByUserID(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,175 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/subscription"
+	"github.com/OpenListTeam/OpenList/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type ListSubscriptionsReq struct {
+	model.PageReq
+	UserID uint `json:"user_id" form:"user_id"`
+}
+
+// ListSubscriptions lists the subscriptions of the current user
+func ListSubscriptions(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.MustGet("user").(*model.User)
+	listSubscriptions(c, user.ID, req)
+}
+
+// ListAllSubscriptions lists the subscriptions of all the users or the
+// given user for the admin
+func ListAllSubscriptions(c *gin.Context) {
+	var req ListSubscriptionsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	listSubscriptions(c, req.UserID, req.PageReq)
+}
+
+func listSubscriptions(c *gin.Context, userId uint, req model.PageReq) {
+	subs, total, err := op.GetSubscriptions(userId, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: subs,
+		Total:   total,
+	})
+}
+
+// getSubscription returns the subscription by the id in the query, only the
+// owner and the admin can access it
+func getSubscription(c *gin.Context) (*model.Subscription, bool) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return nil, false
+	}
+	return getSubscriptionById(c, uint(id))
+}
+
+func getSubscriptionById(c *gin.Context, id uint) (*model.Subscription, bool) {
+	sub, err := op.GetSubscriptionById(id)
+	user := c.MustGet("user").(*model.User)
+	if err != nil || sub.UserID != user.ID && !user.IsAdmin() {
+		common.ErrorStrResp(c, "subscription not found", 404)
+		return nil, false
+	}
+	return sub, true
+}
+
+func GetSubscription(c *gin.Context) {
+	if sub, ok := getSubscription(c); ok {
+		common.SuccessResp(c, sub)
+	}
+}
+
+func CreateSubscription(c *gin.Context) {
+	var req model.Subscription
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.MustGet("user").(*model.User)
+	if !user.CanAddOfflineDownloadTasks() {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if _, err := tool.Tools.Get(req.Tool); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.UserID = user.ID
+	req.Initialized, req.LastFetched, req.LastError = false, nil, ""
+	if err := op.CreateSubscription(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func UpdateSubscription(c *gin.Context) {
+	var req model.Subscription
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	old, ok := getSubscriptionById(c, req.ID)
+	if !ok {
+		return
+	}
+	if _, err := tool.Tools.Get(req.Tool); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.UserID, req.Initialized, req.LastFetched, req.LastError = old.UserID, old.Initialized, old.LastFetched, old.LastError
+	if err := op.UpdateSubscription(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+func DeleteSubscription(c *gin.Context) {
+	sub, ok := getSubscription(c)
+	if !ok {
+		return
+	}
+	if err := op.DeleteSubscriptionById(sub.ID); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// FetchSubscription fetches the subscription now regardless of its interval
+func FetchSubscription(c *gin.Context) {
+	sub, ok := getSubscription(c)
+	if !ok {
+		return
+	}
+	if err := subscription.Fetch(c, sub); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, sub)
+}
+
+// ListSubscriptionHistory lists the fetched items of the subscription
+func ListSubscriptionHistory(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	sub, ok := getSubscription(c)
+	if !ok {
+		return
+	}
+	items, total, err := op.GetSubscriptionItems(sub.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: items,
+		Total:   total,
+	})
+}
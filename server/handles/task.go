@@ -2,13 +2,16 @@ package handles
 
 import (
 	"math"
+	"strconv"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/op"
 	"github.com/OpenListTeam/OpenList/internal/task"
 
 	"github.com/OpenListTeam/OpenList/internal/fs"
 	"github.com/OpenListTeam/OpenList/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/internal/pipeline"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
 	"github.com/OpenListTeam/OpenList/server/common"
 	"github.com/gin-gonic/gin"
@@ -22,6 +25,8 @@ type TaskInfo struct {
 	CreatorRole int         `json:"creator_role"`
 	State       tache.State `json:"state"`
 	Status      string      `json:"status"`
+	Priority    int         `json:"priority"`
+	Paused      bool        `json:"paused"`
 	Progress    float64     `json:"progress"`
 	StartTime   *time.Time  `json:"start_time"`
 	EndTime     *time.Time  `json:"end_time"`
@@ -52,6 +57,8 @@ func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
 		CreatorRole: creatorRole,
 		State:       task.GetState(),
 		Status:      task.GetStatus(),
+		Priority:    task.GetPriority(),
+		Paused:      task.IsPaused(),
 		Progress:    progress,
 		StartTime:   task.GetStartTime(),
 		EndTime:     task.GetEndTime(),
@@ -131,11 +138,12 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 			common.ErrorStrResp(c, "user invalid", 401)
 			return
 		}
-		common.SuccessResp(c, getTaskInfos(manager.GetByCondition(func(task T) bool {
+		common.SuccessResp(c, getTaskInfos(manager.GetByCondition(func(t T) bool {
 			// avoid directly passing the user object into the function to reduce closure size
-			return (isAdmin || uid == task.GetCreator().ID) &&
-				argsContains(task.GetState(), tache.StatePending, tache.StateRunning, tache.StateCanceling,
-					tache.StateErrored, tache.StateFailing, tache.StateWaitingRetry, tache.StateBeforeRetry)
+			// the paused tasks are canceled but listed as undone to be resumed
+			return (isAdmin || uid == t.GetCreator().ID) && (t.IsPaused() ||
+				argsContains(t.GetState(), tache.StatePending, tache.StateRunning, tache.StateCanceling,
+					tache.StateErrored, tache.StateFailing, tache.StateWaitingRetry, tache.StateBeforeRetry))
 		})))
 	})
 	g.GET("/done", func(c *gin.Context) {
@@ -146,7 +154,7 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 			return
 		}
 		common.SuccessResp(c, getTaskInfos(manager.GetByCondition(func(task T) bool {
-			return (isAdmin || uid == task.GetCreator().ID) &&
+			return (isAdmin || uid == task.GetCreator().ID) && !task.IsPaused() &&
 				argsContains(task.GetState(), tache.StateCanceled, tache.StateFailed, tache.StateSucceeded)
 		})))
 	})
@@ -165,6 +173,23 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 		manager.Retry(task.GetID())
 		common.SuccessResp(c)
 	}))
+	g.POST("/pause", getTargetedHandler(manager, func(c *gin.Context, task T) {
+		manager.Pause(task.GetID())
+		common.SuccessResp(c)
+	}))
+	g.POST("/resume", getTargetedHandler(manager, func(c *gin.Context, task T) {
+		manager.Resume(task.GetID())
+		common.SuccessResp(c)
+	}))
+	g.POST("/set_priority", getTargetedHandler(manager, func(c *gin.Context, task T) {
+		priority, err := strconv.Atoi(c.Query("priority"))
+		if err != nil {
+			common.ErrorStrResp(c, "invalid priority", 400)
+			return
+		}
+		manager.SetPriority(task.GetID(), clampPriority(c, priority))
+		common.SuccessResp(c)
+	}))
 	g.POST("/cancel_some", getBatchHandler(manager, func(task T) {
 		manager.Cancel(task.GetID())
 	}))
@@ -174,6 +199,12 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 	g.POST("/retry_some", getBatchHandler(manager, func(task T) {
 		manager.Retry(task.GetID())
 	}))
+	g.POST("/pause_some", getBatchHandler(manager, func(task T) {
+		manager.Pause(task.GetID())
+	}))
+	g.POST("/resume_some", getBatchHandler(manager, func(task T) {
+		manager.Resume(task.GetID())
+	}))
 	g.POST("/clear_done", func(c *gin.Context) {
 		isAdmin, uid, ok := getUserInfo(c)
 		if !ok {
@@ -182,7 +213,7 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 			return
 		}
 		manager.RemoveByCondition(func(task T) bool {
-			return (isAdmin || uid == task.GetCreator().ID) &&
+			return (isAdmin || uid == task.GetCreator().ID) && !task.IsPaused() &&
 				argsContains(task.GetState(), tache.StateCanceled, tache.StateFailed, tache.StateSucceeded)
 		})
 		common.SuccessResp(c)
@@ -216,11 +247,53 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 	})
 }
 
+// ListTaskHistory lists the persisted tasks of all the types, including the
+// ones removed from the managers, a user only sees their own tasks
+func ListTaskHistory(c *gin.Context) {
+	isAdmin, uid, ok := getUserInfo(c)
+	if !ok {
+		// if there is no bug, here is unreachable
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req model.TaskQuery
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	if !isAdmin {
+		req.CreatorID = uid
+		req.Creator = ""
+	}
+	tasks, total, err := op.GetTasks(req)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: tasks,
+		Total:   total,
+	})
+}
+
 func SetupTaskRoute(g *gin.RouterGroup) {
+	g.GET("/history", ListTaskHistory)
 	taskRoute(g.Group("/upload"), fs.UploadTaskManager)
 	taskRoute(g.Group("/copy"), fs.CopyTaskManager)
 	taskRoute(g.Group("/offline_download"), tool.DownloadTaskManager)
 	taskRoute(g.Group("/offline_download_transfer"), tool.TransferTaskManager)
 	taskRoute(g.Group("/decompress"), fs.ArchiveDownloadTaskManager)
 	taskRoute(g.Group("/decompress_upload"), fs.ArchiveContentUploadTaskManager)
+	taskRoute(g.Group("/pipeline"), pipeline.PipelineTaskManager)
+	g.POST("/pipeline/steps", getTargetedHandler(pipeline.PipelineTaskManager, func(c *gin.Context, t *pipeline.PipelineTask) {
+		common.SuccessResp(c, t.GetSteps())
+	}))
+}
+
+// clampPriority limits the priority requested by the user, see task.ClampPriority
+func clampPriority(c *gin.Context, priority int) int {
+	user, _ := c.Get("user")
+	u, _ := user.(*model.User)
+	return task.ClampPriority(priority, u)
 }
@@ -111,7 +111,7 @@ func FinishAuthnLogin(c *gin.Context) {
 		return
 	}
 
-	token, err := common.GenerateToken(user)
+	token, err := common.GenerateToken(c, user, model.LoginMethodWebAuthn)
 	if err != nil {
 		common.ErrorResp(c, err, 400, true)
 		return
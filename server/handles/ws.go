@@ -0,0 +1,151 @@
+package handles
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/errs"
+	"github.com/OpenListTeam/OpenList/internal/fs"
+	"github.com/OpenListTeam/OpenList/internal/message"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/OpenListTeam/OpenList/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/internal/op"
+	"github.com/OpenListTeam/OpenList/internal/pipeline"
+	"github.com/OpenListTeam/OpenList/internal/task"
+	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/OpenListTeam/OpenList/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const (
+	WsTaskUpdate = "task_update"
+	WsTaskRemove = "task_remove"
+	WsDirChange  = "dir_change"
+)
+
+type WsTaskEvent struct {
+	TaskInfo
+	Type      string `json:"type"`
+	creatorID *uint
+}
+
+type WsDirEvent struct {
+	Path string `json:"path"`
+}
+
+func (e WsTaskEvent) changed(o WsTaskEvent) bool {
+	return e.State != o.State || e.Status != o.Status || e.Error != o.Error || e.Priority != o.Priority ||
+		e.TotalBytes != o.TotalBytes || (e.EndTime == nil) != (o.EndTime == nil) ||
+		math.Abs(e.Progress-o.Progress) >= 0.01
+}
+
+func collectTasks[T task.TaskExtensionInfo](dst map[string]WsTaskEvent, typ string, manager task.Manager[T]) {
+	for _, t := range manager.GetAll() {
+		e := WsTaskEvent{
+			TaskInfo: getTaskInfo(t),
+			Type:     typ,
+		}
+		if t.GetCreator() != nil {
+			e.creatorID = &t.GetCreator().ID
+		}
+		dst[typ+":"+e.ID] = e
+	}
+}
+
+var wsTaskCollectors = []func(dst map[string]WsTaskEvent){
+	func(dst map[string]WsTaskEvent) { collectTasks(dst, "upload", fs.UploadTaskManager) },
+	func(dst map[string]WsTaskEvent) { collectTasks(dst, "copy", fs.CopyTaskManager) },
+	func(dst map[string]WsTaskEvent) { collectTasks(dst, "offline_download", tool.DownloadTaskManager) },
+	func(dst map[string]WsTaskEvent) {
+		collectTasks(dst, "offline_download_transfer", tool.TransferTaskManager)
+	},
+	func(dst map[string]WsTaskEvent) { collectTasks(dst, "decompress", fs.ArchiveDownloadTaskManager) },
+	func(dst map[string]WsTaskEvent) {
+		collectTasks(dst, "decompress_upload", fs.ArchiveContentUploadTaskManager)
+	},
+	func(dst map[string]WsTaskEvent) { collectTasks(dst, "pipeline", pipeline.PipelineTaskManager) },
+}
+
+var watchTasksOnce sync.Once
+
+// watchTasks diffs the tasks of all managers every second and
+// pushes the changed ones, it does nothing while no client is connected
+func watchTasks() {
+	var last map[string]WsTaskEvent
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		if message.WsInstance.Len() == 0 {
+			last = nil
+			continue
+		}
+		cur := make(map[string]WsTaskEvent)
+		for _, collect := range wsTaskCollectors {
+			collect(cur)
+		}
+		if last != nil {
+			for k, e := range cur {
+				if o, ok := last[k]; !ok || e.changed(o) {
+					message.WsInstance.Broadcast(message.Message{Type: WsTaskUpdate, Content: e})
+				}
+			}
+			for k, e := range last {
+				if _, ok := cur[k]; !ok {
+					message.WsInstance.Broadcast(message.Message{Type: WsTaskRemove, Content: e})
+				}
+			}
+		}
+		last = cur
+	}
+}
+
+func wsFilter(user *model.User) message.WsFilter {
+	basePath := utils.FixAndCleanPath(user.BasePath)
+	return func(msg message.Message) (message.Message, bool) {
+		switch e := msg.Content.(type) {
+		case WsTaskEvent:
+			if user.IsAdmin() {
+				return msg, true
+			}
+			return msg, e.creatorID != nil && *e.creatorID == user.ID
+		case WsDirEvent:
+			if !utils.IsSubPath(basePath, e.Path) {
+				return msg, false
+			}
+			meta, err := op.GetNearestMeta(e.Path)
+			if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				return msg, false
+			}
+			if !common.CanAccess(user, meta, e.Path, "") {
+				return msg, false
+			}
+			return message.Message{
+				Type:    msg.Type,
+				Content: WsDirEvent{Path: utils.FixAndCleanPath(strings.TrimPrefix(e.Path, basePath))},
+			}, true
+		default:
+			return msg, user.IsAdmin()
+		}
+	}
+}
+
+// Ws pushes task progress and directory changes to the client
+func Ws(c *gin.Context) {
+	user := c.MustGet("user").(*model.User)
+	watchTasksOnce.Do(func() {
+		go watchTasks()
+	})
+	message.WsInstance.Handle(c, wsFilter(user))
+}
+
+func init() {
+	op.RegisterDirChangeHook(func(dir string) {
+		if message.WsInstance.Len() == 0 {
+			return
+		}
+		message.WsInstance.Broadcast(message.Message{Type: WsDirChange, Content: WsDirEvent{Path: dir}})
+	})
+}
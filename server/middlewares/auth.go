@@ -4,11 +4,13 @@ import (
 	"crypto/subtle"
 
 	"github.com/OpenListTeam/OpenList/internal/conf"
+	"github.com/OpenListTeam/OpenList/internal/message"
 	"github.com/OpenListTeam/OpenList/internal/model"
 	"github.com/OpenListTeam/OpenList/internal/op"
 	"github.com/OpenListTeam/OpenList/internal/setting"
 	"github.com/OpenListTeam/OpenList/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -51,6 +53,12 @@ func Auth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	session, err := common.GetSession(userClaims)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return
+	}
 	user, err := op.GetUserByName(userClaims.Username)
 	if err != nil {
 		common.ErrorResp(c, err, 401)
@@ -68,11 +76,33 @@ func Auth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	if session.UserID != user.ID {
+		common.ErrorStrResp(c, "token is invalidated", 401)
+		c.Abort()
+		return
+	}
+	if err := op.TouchSession(session, c.ClientIP()); err != nil {
+		log.Warnf("failed to update session activity: %+v", err)
+	}
 	c.Set("user", user)
+	c.Set("session", session)
 	log.Debugf("use login token: %+v", user)
 	c.Next()
 }
 
+// WsToken allows browsers, which can't set headers on websocket requests,
+// to pass the token as the subprotocol after message.WsProtocol, the token
+// isn't taken from the query so that it doesn't end up in the access logs
+func WsToken(c *gin.Context) {
+	if c.GetHeader("Authorization") == "" {
+		protocols := websocket.Subprotocols(c.Request)
+		if len(protocols) == 2 && protocols[0] == message.WsProtocol {
+			c.Request.Header.Set("Authorization", protocols[1])
+		}
+	}
+	c.Next()
+}
+
 func Authn(c *gin.Context) {
 	token := c.GetHeader("Authorization")
 	if subtle.ConstantTimeCompare([]byte(token), []byte(setting.GetStr(conf.Token))) == 1 {
@@ -105,6 +135,12 @@ func Authn(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	session, err := common.GetSession(userClaims)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		c.Abort()
+		return
+	}
 	user, err := op.GetUserByName(userClaims.Username)
 	if err != nil {
 		common.ErrorResp(c, err, 401)
@@ -122,7 +158,16 @@ func Authn(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	if session.UserID != user.ID {
+		common.ErrorStrResp(c, "token is invalidated", 401)
+		c.Abort()
+		return
+	}
+	if err := op.TouchSession(session, c.ClientIP()); err != nil {
+		log.Warnf("failed to update session activity: %+v", err)
+	}
 	c.Set("user", user)
+	c.Set("session", session)
 	log.Debugf("use login token: %+v", user)
 	c.Next()
 }
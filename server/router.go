@@ -61,14 +61,19 @@ func Init(e *gin.Engine) {
 	api.POST("/auth/login", handles.Login)
 	api.POST("/auth/login/hash", handles.LoginHash)
 	api.POST("/auth/login/ldap", handles.LoginLdap)
+	api.GET("/auth/captcha", handles.GetCaptcha)
 	auth.GET("/me", handles.CurrentUser)
 	auth.POST("/me/update", handles.UpdateCurrent)
 	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
 	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
 	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
+	auth.GET("/me/sessions", handles.ListMySessions)
+	auth.POST("/me/sessions/revoke", handles.RevokeMySession)
+	auth.POST("/me/sessions/revoke_others", middlewares.AuthNotGuest, handles.RevokeMyOtherSessions)
 	auth.POST("/auth/2fa/generate", handles.Generate2FA)
 	auth.POST("/auth/2fa/verify", handles.Verify2FA)
 	auth.GET("/auth/logout", handles.LogOut)
+	api.GET("/ws", middlewares.WsToken, middlewares.Auth, handles.Ws)
 
 	// auth
 	api.GET("/auth/sso", handles.SSOLoginRedirect)
@@ -92,6 +97,7 @@ func Init(e *gin.Engine) {
 
 	_fs(auth.Group("/fs"))
 	_task(auth.Group("/task", middlewares.AuthNotGuest))
+	_subscription(auth.Group("/subscription", middlewares.AuthNotGuest))
 	admin(auth.Group("/admin", middlewares.AuthAdmin))
 	if flags.Debug || flags.Dev {
 		debug(g.Group("/debug"))
@@ -119,6 +125,22 @@ func admin(g *gin.RouterGroup) {
 	user.POST("/del_cache", handles.DelUserCache)
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
+	user.GET("/login_lock/list", handles.ListLoginLocks)
+	user.POST("/login_lock/unlock", handles.UnlockLogin)
+	user.GET("/sessions", handles.ListUserSessions)
+	user.POST("/sessions/revoke", handles.RevokeSession)
+	user.POST("/logout", handles.ForceLogout)
+
+	subscription := g.Group("/subscription")
+	subscription.GET("/list", handles.ListAllSubscriptions)
+	subscription.GET("/get", handles.GetSubscription)
+	subscription.POST("/update", handles.UpdateSubscription)
+	subscription.POST("/delete", handles.DeleteSubscription)
+	subscription.POST("/fetch", handles.FetchSubscription)
+	subscription.GET("/history", handles.ListSubscriptionHistory)
+
+	audit := g.Group("/audit")
+	audit.GET("/list", handles.ListAuditLogs)
 
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
@@ -144,6 +166,7 @@ func admin(g *gin.RouterGroup) {
 	setting.POST("/set_aria2", handles.SetAria2)
 	setting.POST("/set_qbit", handles.SetQbittorrent)
 	setting.POST("/set_transmission", handles.SetTransmission)
+	setting.POST("/set_bittorrent", handles.SetBitTorrent)
 	setting.POST("/set_115", handles.Set115)
 	setting.POST("/set_pikpak", handles.SetPikPak)
 	setting.POST("/set_thunder", handles.SetThunder)
@@ -168,6 +191,8 @@ func _fs(g *gin.RouterGroup) {
 	g.Any("/search", middlewares.SearchIndex, handles.Search)
 	g.Any("/get", handles.FsGet)
 	g.Any("/other", handles.FsOther)
+	g.Any("/versions", handles.FsListVersions)
+	g.POST("/versions/restore", handles.FsRestoreVersion)
 	g.Any("/dirs", handles.FsDirs)
 	g.POST("/mkdir", handles.FsMkdir)
 	g.POST("/rename", handles.FsRename)
@@ -186,12 +211,23 @@ func _fs(g *gin.RouterGroup) {
 	// g.POST("/add_qbit", handles.AddQbittorrent)
 	// g.POST("/add_transmission", handles.SetTransmission)
 	g.POST("/add_offline_download", handles.AddOfflineDownload)
+	g.POST("/add_pipeline", handles.AddPipeline)
 	a := g.Group("/archive")
 	a.Any("/meta", handles.FsArchiveMeta)
 	a.Any("/list", handles.FsArchiveList)
 	a.POST("/decompress", handles.FsArchiveDecompress)
 }
 
+func _subscription(g *gin.RouterGroup) {
+	g.GET("/list", handles.ListSubscriptions)
+	g.GET("/get", handles.GetSubscription)
+	g.POST("/create", handles.CreateSubscription)
+	g.POST("/update", handles.UpdateSubscription)
+	g.POST("/delete", handles.DeleteSubscription)
+	g.POST("/fetch", handles.FetchSubscription)
+	g.GET("/history", handles.ListSubscriptionHistory)
+}
+
 func _task(g *gin.RouterGroup) {
 	handles.SetupTaskRoute(g)
 }
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/internal/conf"
@@ -10,6 +11,7 @@ import (
 	"github.com/OpenListTeam/OpenList/internal/op"
 	"github.com/OpenListTeam/OpenList/internal/setting"
 	"github.com/OpenListTeam/OpenList/pkg/utils"
+	"github.com/OpenListTeam/OpenList/server/common"
 	"github.com/OpenListTeam/OpenList/server/ftp"
 	"github.com/OpenListTeam/OpenList/server/sftp"
 	"github.com/OpenListTeam/sftpd-openlist"
@@ -20,6 +22,7 @@ import (
 type SftpDriver struct {
 	proxyHeader *http.Header
 	config      *sftpd.Config
+	sessions    sync.Map
 }
 
 func NewSftpDriver() (*SftpDriver, error) {
@@ -60,18 +63,50 @@ func (d *SftpDriver) GetFileSystem(sc *ssh.ServerConn) (sftpd.FileSystem, error)
 	if err != nil {
 		return nil, err
 	}
+	ftpUser, err := userObj.FtpUser()
+	if err != nil {
+		return nil, err
+	}
+	sess, err := d.acquireSession(sc, userObj)
+	if err != nil {
+		return nil, err
+	}
 	ctx := context.Background()
-	ctx = context.WithValue(ctx, "user", userObj)
+	ctx = context.WithValue(ctx, "user", ftpUser)
 	ctx = context.WithValue(ctx, "meta_pass", "")
 	ctx = context.WithValue(ctx, "client_ip", sc.RemoteAddr().String())
 	ctx = context.WithValue(ctx, "proxy_header", d.proxyHeader)
+	ctx = context.WithValue(ctx, "session", sess)
 	return &sftp.DriverAdapter{FtpDriver: ftp.NewAferoAdapter(ctx)}, nil
 }
 
+// acquireSession registers one session per ssh connection, as a client may open
+// more than one sftp channel in the same connection
+func (d *SftpDriver) acquireSession(sc *ssh.ServerConn, user *model.User) (*ftp.Session, error) {
+	key := string(sc.SessionID())
+	if sess, ok := d.sessions.Load(key); ok {
+		return sess.(*ftp.Session), nil
+	}
+	sess, err := ftp.AcquireSession(user, sc.RemoteAddr().String(), "sftp")
+	if err != nil {
+		return nil, err
+	}
+	d.sessions.Store(key, sess)
+	go func() {
+		_ = sc.Wait()
+		d.sessions.Delete(key)
+		sess.Release()
+	}()
+	return sess, nil
+}
+
 func (d *SftpDriver) Close() {
 }
 
 func (d *SftpDriver) NoClientAuth(conn ssh.ConnMetadata) (*ssh.Permissions, error) {
+	if err := common.CheckLogin("", conn.RemoteAddr().String()); err != nil {
+		return nil, err
+	}
 	if conn.User() != "guest" {
 		return nil, errors.New("only guest is allowed to login without authorization")
 	}
@@ -86,8 +121,13 @@ func (d *SftpDriver) NoClientAuth(conn ssh.ConnMetadata) (*ssh.Permissions, erro
 }
 
 func (d *SftpDriver) PasswordAuth(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	ip := conn.RemoteAddr().String()
+	if err := common.CheckLogin(conn.User(), ip); err != nil {
+		return nil, err
+	}
 	userObj, err := op.GetUserByName(conn.User())
 	if err != nil {
+		common.LoginFailed(conn.User(), ip, common.LoginProtocolSFTP, err)
 		return nil, err
 	}
 	if userObj.Disabled || !userObj.CanFTPAccess() {
@@ -95,12 +135,19 @@ func (d *SftpDriver) PasswordAuth(conn ssh.ConnMetadata, password []byte) (*ssh.
 	}
 	passHash := model.StaticHash(string(password))
 	if err = userObj.ValidatePwdStaticHash(passHash); err != nil {
+		common.LoginFailed(conn.User(), ip, common.LoginProtocolSFTP, err)
 		return nil, err
 	}
+	common.LoginSucceeded(conn.User(), ip)
 	return nil, nil
 }
 
+// PublicKeyAuth does not count the refused keys as failed attempts, since
+// clients usually offer all of their keys one by one
 func (d *SftpDriver) PublicKeyAuth(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	if err := common.CheckLogin(conn.User(), conn.RemoteAddr().String()); err != nil {
+		return nil, err
+	}
 	userObj, err := op.GetUserByName(conn.User())
 	if err != nil {
 		return nil, err
@@ -3,9 +3,12 @@ package server
 import (
 	"context"
 	"crypto/subtle"
+	"errors"
 	"net/http"
 	"path"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/OpenListTeam/OpenList/internal/stream"
 	"github.com/OpenListTeam/OpenList/server/middlewares"
@@ -14,6 +17,7 @@ import (
 	"github.com/OpenListTeam/OpenList/internal/model"
 	"github.com/OpenListTeam/OpenList/internal/op"
 	"github.com/OpenListTeam/OpenList/internal/setting"
+	"github.com/OpenListTeam/OpenList/server/common"
 	"github.com/OpenListTeam/OpenList/server/webdav"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
@@ -24,7 +28,8 @@ var handler *webdav.Handler
 func WebDav(dav *gin.RouterGroup) {
 	handler = &webdav.Handler{
 		Prefix:     path.Join(conf.URL.Path, "/dav"),
-		LockSystem: webdav.NewMemLS(),
+		LockSystem: webdav.NewDBLS(),
+		PropSystem: webdav.NewDBPropSystem(),
 		Logger: func(request *http.Request, err error) {
 			log.Errorf("%s %s %+v", request.Method, request.URL.Path, err)
 		},
@@ -82,17 +87,32 @@ func WebDAVAuth(c *gin.Context) {
 		c.Abort()
 		return
 	}
+	ip := c.ClientIP()
+	if err := common.CheckLogin(username, ip); err != nil {
+		var locked *common.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(locked.Until).Seconds())+1))
+		}
+		c.Status(http.StatusTooManyRequests)
+		c.Abort()
+		return
+	}
 	user, err := op.GetUserByName(username)
-	if err != nil || user.ValidateRawPassword(password) != nil {
+	if err == nil {
+		err = user.ValidateRawPassword(password)
+	}
+	if err != nil {
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
 			c.Next()
 			return
 		}
+		common.LoginFailed(username, ip, common.LoginProtocolWebDAV, err)
 		c.Status(http.StatusUnauthorized)
 		c.Abort()
 		return
 	}
+	common.LoginSucceeded(username, ip)
 	if user.Disabled || !user.CanWebdavRead() {
 		if c.Request.Method == "OPTIONS" {
 			c.Set("user", guest)
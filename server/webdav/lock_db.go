@@ -0,0 +1,277 @@
+package webdav
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/model"
+	"github.com/google/uuid"
+)
+
+// ActiveLock is a lock applying to a resource, as listed by a LockLister.
+type ActiveLock struct {
+	Token   string
+	Details LockDetails
+}
+
+// LockLister is an optional interface for a LockSystem. If implemented, it
+// is used to report the DAV:lockdiscovery property.
+type LockLister interface {
+	// Locks returns the active locks applying to the named resource, either
+	// directly or through an infinite depth lock on one of its ancestors.
+	Locks(now time.Time, name string) ([]ActiveLock, error)
+}
+
+// NewDBLS returns a new LockSystem that persists the locks in the database,
+// so they survive restarts and are shared by all the instances using the
+// same database. Only the short-lived "held" state of Confirm is kept in
+// memory.
+func NewDBLS() LockSystem {
+	return &dbLS{
+		held: make(map[string]struct{}),
+	}
+}
+
+type dbLS struct {
+	mu   sync.Mutex
+	held map[string]struct{}
+}
+
+func toLockDetails(l *model.WebdavLock) LockDetails {
+	duration := time.Duration(infiniteTimeout)
+	if l.Duration >= 0 {
+		duration = time.Duration(l.Duration) * time.Second
+	}
+	return LockDetails{
+		Root:      l.Root,
+		Duration:  duration,
+		OwnerXML:  l.OwnerXML,
+		ZeroDepth: l.ZeroDepth,
+	}
+}
+
+func setLockExpiry(l *model.WebdavLock, now time.Time, duration time.Duration) {
+	if duration < 0 {
+		l.Duration, l.Expiry = -1, 0
+		return
+	}
+	l.Duration = int64(duration / time.Second)
+	l.Expiry = now.Add(duration).Unix()
+}
+
+func ancestors(name string) []string {
+	var names []string
+	walkToRoot(name, func(name0 string, first bool) bool {
+		names = append(names, name0)
+		return true
+	})
+	return names
+}
+
+func (m *dbLS) collectExpiredLocks(now time.Time) error {
+	return db.DeleteExpiredWebdavLocks(now.Unix())
+}
+
+func (m *dbLS) Confirm(now time.Time, name0, name1 string, conditions ...Condition) (func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.collectExpiredLocks(now); err != nil {
+		return nil, err
+	}
+
+	var t0, t1 string
+	var err error
+	if name0 != "" {
+		if t0, err = m.lookup(slashClean(name0), conditions...); err != nil {
+			return nil, err
+		}
+		if t0 == "" {
+			return nil, ErrConfirmationFailed
+		}
+	}
+	if name1 != "" {
+		if t1, err = m.lookup(slashClean(name1), conditions...); err != nil {
+			return nil, err
+		}
+		if t1 == "" {
+			return nil, ErrConfirmationFailed
+		}
+	}
+
+	// Don't hold the same lock twice.
+	if t1 == t0 {
+		t1 = ""
+	}
+
+	if t0 != "" {
+		m.held[t0] = struct{}{}
+	}
+	if t1 != "" {
+		m.held[t1] = struct{}{}
+	}
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if t1 != "" {
+			delete(m.held, t1)
+		}
+		if t0 != "" {
+			delete(m.held, t0)
+		}
+	}, nil
+}
+
+// lookup returns the token of the lock that locks the named resource,
+// provided that it matches at least one of the given conditions and that
+// lock isn't held by another party. Otherwise, it returns "".
+func (m *dbLS) lookup(name string, conditions ...Condition) (string, error) {
+	for _, c := range conditions {
+		if c.Token == "" {
+			continue
+		}
+		if _, ok := m.held[c.Token]; ok {
+			continue
+		}
+		l, err := db.GetWebdavLockByToken(c.Token)
+		if err != nil {
+			return "", err
+		}
+		if l == nil {
+			continue
+		}
+		if name == l.Root {
+			return l.Token, nil
+		}
+		if l.ZeroDepth {
+			continue
+		}
+		if l.Root == "/" || strings.HasPrefix(name, l.Root+"/") {
+			return l.Token, nil
+		}
+	}
+	return "", nil
+}
+
+func (m *dbLS) Create(now time.Time, details LockDetails) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.collectExpiredLocks(now); err != nil {
+		return "", err
+	}
+	details.Root = slashClean(details.Root)
+
+	ok, err := m.canCreate(details.Root, details.ZeroDepth)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrLocked
+	}
+	l := &model.WebdavLock{
+		Token:     "opaquelocktoken:" + uuid.NewString(),
+		Root:      details.Root,
+		OwnerXML:  details.OwnerXML,
+		ZeroDepth: details.ZeroDepth,
+	}
+	setLockExpiry(l, now, details.Duration)
+	if err := db.CreateWebdavLock(l); err != nil {
+		return "", err
+	}
+	return l.Token, nil
+}
+
+func (m *dbLS) canCreate(name string, zeroDepth bool) (bool, error) {
+	locks, err := db.GetWebdavLocksByRoots(ancestors(name))
+	if err != nil {
+		return false, err
+	}
+	for _, l := range locks {
+		if l.Root == name {
+			// The target node is already locked.
+			return false, nil
+		}
+		if !l.ZeroDepth {
+			// An ancestor of the target node is locked with infinite depth.
+			return false, nil
+		}
+	}
+	if zeroDepth {
+		return true, nil
+	}
+	// The requested lock depth is infinite, so no descendant may be locked.
+	locks, err = db.GetWebdavLocksUnder(name)
+	if err != nil {
+		return false, err
+	}
+	return len(locks) == 0, nil
+}
+
+func (m *dbLS) Refresh(now time.Time, token string, duration time.Duration) (LockDetails, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.collectExpiredLocks(now); err != nil {
+		return LockDetails{}, err
+	}
+
+	l, err := db.GetWebdavLockByToken(token)
+	if err != nil {
+		return LockDetails{}, err
+	}
+	if l == nil {
+		return LockDetails{}, ErrNoSuchLock
+	}
+	if _, ok := m.held[token]; ok {
+		return LockDetails{}, ErrLocked
+	}
+	setLockExpiry(l, now, duration)
+	if err := db.UpdateWebdavLock(l); err != nil {
+		return LockDetails{}, err
+	}
+	return toLockDetails(l), nil
+}
+
+func (m *dbLS) Unlock(now time.Time, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.collectExpiredLocks(now); err != nil {
+		return err
+	}
+
+	l, err := db.GetWebdavLockByToken(token)
+	if err != nil {
+		return err
+	}
+	if l == nil {
+		return ErrNoSuchLock
+	}
+	if _, ok := m.held[token]; ok {
+		return ErrLocked
+	}
+	return db.DeleteWebdavLock(token)
+}
+
+func (m *dbLS) Locks(now time.Time, name string) ([]ActiveLock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.collectExpiredLocks(now); err != nil {
+		return nil, err
+	}
+	name = slashClean(name)
+	locks, err := db.GetWebdavLocksByRoots(ancestors(name))
+	if err != nil {
+		return nil, err
+	}
+	var res []ActiveLock
+	for i := range locks {
+		if locks[i].Root != name && locks[i].ZeroDepth {
+			continue
+		}
+		res = append(res, ActiveLock{
+			Token:   locks[i].Token,
+			Details: toLockDetails(&locks[i]),
+		})
+	}
+	return res, nil
+}
@@ -0,0 +1,87 @@
+package webdav
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/db/dbtest"
+)
+
+func TestDBLS(t *testing.T) {
+	db.Init(dbtest.Open(t))
+	now := time.Unix(0, 0).Add(time.Hour * 24 * 365 * 50)
+	ls := NewDBLS()
+
+	tk, err := ls.Create(now, LockDetails{Root: "/a/b", Duration: time.Minute})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := ls.Create(now, LockDetails{Root: "/a", Duration: -1}); err != ErrLocked {
+		t.Fatalf("create infinite lock over a locked descendant: got %v, want ErrLocked", err)
+	}
+	if _, err := ls.Create(now, LockDetails{Root: "/a/b/c", Duration: -1, ZeroDepth: true}); err != ErrLocked {
+		t.Fatalf("create lock under an infinite lock: got %v, want ErrLocked", err)
+	}
+	if _, err := ls.Create(now, LockDetails{Root: "/a", Duration: -1, ZeroDepth: true}); err != nil {
+		t.Fatalf("create zero depth lock on the parent: %v", err)
+	}
+
+	release, err := ls.Confirm(now, "/a/b/c", "", Condition{Token: tk})
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if err := ls.Unlock(now, tk); err != ErrLocked {
+		t.Fatalf("unlock held lock: got %v, want ErrLocked", err)
+	}
+	release()
+
+	locks, err := ls.(LockLister).Locks(now, "/a/b/c")
+	if err != nil || len(locks) != 1 || locks[0].Token != tk {
+		t.Fatalf("locks: got %v, %v", locks, err)
+	}
+
+	if _, err := ls.Refresh(now.Add(2*time.Minute), tk, time.Minute); err != ErrNoSuchLock {
+		t.Fatalf("refresh expired lock: got %v, want ErrNoSuchLock", err)
+	}
+	if err := ls.Unlock(now, tk); err != ErrNoSuchLock {
+		t.Fatalf("unlock expired lock: got %v, want ErrNoSuchLock", err)
+	}
+}
+
+func TestDBPropSystem(t *testing.T) {
+	db.Init(dbtest.Open(t))
+	ps := NewDBPropSystem()
+	pn := xml.Name{Space: "http://example.com/ns", Local: "color"}
+	_, err := ps.Patch("/dir/file", []Proppatch{
+		{Props: []Property{{XMLName: pn, InnerXML: []byte("red")}}},
+	})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if err := ps.Move("/dir", "/moved"); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if err := ps.Copy("/moved/file", "/copied"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	for _, name := range []string{"/moved/file", "/copied"} {
+		props, err := ps.DeadProps(name)
+		if err != nil {
+			t.Fatalf("dead props of %s: %v", name, err)
+		}
+		if string(props[pn].InnerXML) != "red" {
+			t.Errorf("dead props of %s: got %q, want %q", name, props[pn].InnerXML, "red")
+		}
+	}
+	if props, _ := ps.DeadProps("/dir/file"); len(props) != 0 {
+		t.Errorf("dead props left behind after move: %v", props)
+	}
+	if err := ps.Remove("/moved"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if props, _ := ps.DeadProps("/moved/file"); len(props) != 0 {
+		t.Errorf("dead props left behind after remove: %v", props)
+	}
+}
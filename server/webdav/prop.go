@@ -154,8 +154,8 @@ var liveProps = map[xml.Name]struct {
 		dir: false,
 	},
 
-	// TODO: The lockdiscovery property requires LockSystem to list the
-	// active locks on a resource.
+	// The lockdiscovery property requires LockSystem to list the active
+	// locks on a resource, see findLockDiscovery.
 	{Space: "DAV:", Local: "lockdiscovery"}: {},
 	{Space: "DAV:", Local: "supportedlock"}: {
 		findFn: findSupportedLock,
@@ -173,7 +173,7 @@ var liveProps = map[xml.Name]struct {
 //
 // Each Propstat has a unique status and each property name will only be part
 // of one Propstat element.
-func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
+func props(ctx context.Context, ls LockSystem, ps PropSystem, name string, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
 	//if err != nil {
 	//	return nil, err
@@ -186,13 +186,13 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 	isDir := fi.IsDir()
 
 	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
+	if ps != nil {
+		var err error
+		deadProps, err = ps.DeadProps(name)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	pstatOK := Propstat{Status: http.StatusOK}
 	pstatNotFound := Propstat{Status: http.StatusNotFound}
@@ -202,6 +202,17 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 			pstatOK.Props = append(pstatOK.Props, dp)
 			continue
 		}
+		if pn == lockDiscoveryName {
+			if innerXML, ok, err := findLockDiscovery(ls, name); err != nil {
+				return nil, err
+			} else if ok {
+				pstatOK.Props = append(pstatOK.Props, Property{
+					XMLName:  pn,
+					InnerXML: []byte(innerXML),
+				})
+				continue
+			}
+		}
 		// Otherwise, it must either be a live property or we don't know it.
 		if prop := liveProps[pn]; prop.findFn != nil && (prop.dir || !isDir) {
 			innerXML, err := prop.findFn(ctx, ls, fi.GetName(), fi)
@@ -222,7 +233,7 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 }
 
 // Propnames returns the property names defined for resource name.
-func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, error) {
+func propnames(ctx context.Context, ls LockSystem, ps PropSystem, name string, fi model.Obj) ([]xml.Name, error) {
 	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
 	//if err != nil {
 	//	return nil, err
@@ -235,13 +246,13 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 	isDir := fi.IsDir()
 
 	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
+	if ps != nil {
+		var err error
+		deadProps, err = ps.DeadProps(name)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	pnames := make([]xml.Name, 0, len(liveProps)+len(deadProps))
 	for pn, prop := range liveProps {
@@ -249,6 +260,9 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 			pnames = append(pnames, pn)
 		}
 	}
+	if _, ok := ls.(LockLister); ok {
+		pnames = append(pnames, lockDiscoveryName)
+	}
 	for pn := range deadProps {
 		pnames = append(pnames, pn)
 	}
@@ -263,8 +277,8 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 // returned if they are named in 'include'.
 //
 // See http://www.webdav.org/specs/rfc4918.html#METHOD_PROPFIND
-func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Name) ([]Propstat, error) {
-	pnames, err := propnames(ctx, ls, fi)
+func allprop(ctx context.Context, ls LockSystem, ps PropSystem, name string, fi model.Obj, include []xml.Name) ([]Propstat, error) {
+	pnames, err := propnames(ctx, ls, ps, name, fi)
 	if err != nil {
 		return nil, err
 	}
@@ -278,12 +292,12 @@ func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Nam
 			pnames = append(pnames, pn)
 		}
 	}
-	return props(ctx, ls, fi, pnames)
+	return props(ctx, ls, ps, name, fi, pnames)
 }
 
 // Patch patches the properties of resource name. The return values are
 // constrained in the same manner as DeadPropsHolder.Patch.
-func patch(ctx context.Context, ls LockSystem, name string, patches []Proppatch) ([]Propstat, error) {
+func patch(ctx context.Context, ls LockSystem, ps PropSystem, name string, patches []Proppatch) ([]Propstat, error) {
 	conflict := false
 loop:
 	for _, patch := range patches {
@@ -314,31 +328,24 @@ loop:
 		return makePropstats(pstatForbidden, pstatFailedDep), nil
 	}
 
-	// ------------------------------------------------------------
-	//f, err := fs.OpenFile(ctx, name, os.O_RDWR, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	ret, err := dph.Patch(patches)
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//	// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
-	//	// "The contents of the prop XML element must only list the names of
-	//	// properties to which the result in the status element applies."
-	//	for _, pstat := range ret {
-	//		for i, p := range pstat.Props {
-	//			pstat.Props[i] = Property{XMLName: p.XMLName}
-	//		}
-	//	}
-	//	return ret, nil
-	//}
-	// ------------------------------------------------------------
+	if ps != nil {
+		ret, err := ps.Patch(name, patches)
+		if err != nil {
+			return nil, err
+		}
+		// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
+		// "The contents of the prop XML element must only list the names of
+		// properties to which the result in the status element applies."
+		for _, pstat := range ret {
+			for i, p := range pstat.Props {
+				pstat.Props[i] = Property{XMLName: p.XMLName}
+			}
+		}
+		return ret, nil
+	}
 
-	// The file doesn't implement the optional DeadPropsHolder interface, so
-	// all patches are forbidden.
+	// There is no PropSystem to store the dead properties, so all patches
+	// are forbidden.
 	pstat := Propstat{Status: http.StatusForbidden}
 	for _, patch := range patches {
 		for _, p := range patch.Props {
@@ -481,6 +488,26 @@ func findETag(ctx context.Context, ls LockSystem, name string, fi model.Obj) (st
 	return common.GetEtag(fi), nil
 }
 
+var lockDiscoveryName = xml.Name{Space: "DAV:", Local: "lockdiscovery"}
+
+// findLockDiscovery reports the active locks of name if the LockSystem
+// implements LockLister.
+func findLockDiscovery(ls LockSystem, name string) (string, bool, error) {
+	ll, ok := ls.(LockLister)
+	if !ok {
+		return "", false, nil
+	}
+	locks, err := ll.Locks(time.Now(), name)
+	if err != nil {
+		return "", false, err
+	}
+	var b strings.Builder
+	for _, l := range locks {
+		b.WriteString(activeLockXML(l.Token, l.Details))
+	}
+	return b.String(), true, nil
+}
+
 func findSupportedLock(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
 	return `` +
 		`<D:lockentry xmlns:D="DAV:">` +
@@ -0,0 +1,95 @@
+package webdav
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	"github.com/OpenListTeam/OpenList/internal/db"
+	"github.com/OpenListTeam/OpenList/internal/model"
+)
+
+// PropSystem stores the dead properties of resources by path. The elements
+// in a name are separated by slash ('/', U+002F) characters.
+type PropSystem interface {
+	// DeadProps returns a copy of the dead properties of the named resource.
+	DeadProps(name string) (map[xml.Name]Property, error)
+	// Patch patches the dead properties of the named resource, with the same
+	// semantics as DeadPropsHolder.Patch.
+	Patch(name string, patches []Proppatch) ([]Propstat, error)
+	// Copy copies the dead properties of src and its descendants to dst.
+	Copy(src, dst string) error
+	// Move moves the dead properties of src and its descendants to dst.
+	Move(src, dst string) error
+	// Remove removes the dead properties of the named resource and its descendants.
+	Remove(name string) error
+}
+
+// NewDBPropSystem returns a new PropSystem that persists the dead properties
+// in the database.
+func NewDBPropSystem() PropSystem {
+	return &dbPropSystem{}
+}
+
+type dbPropSystem struct{}
+
+func (p *dbPropSystem) DeadProps(name string) (map[xml.Name]Property, error) {
+	items, err := db.GetWebdavProps(slashClean(name))
+	if err != nil {
+		return nil, err
+	}
+	props := make(map[xml.Name]Property, len(items))
+	for _, item := range items {
+		pn := xml.Name{Space: item.Space, Local: item.Local}
+		props[pn] = Property{
+			XMLName:  pn,
+			Lang:     item.Lang,
+			InnerXML: []byte(item.InnerXML),
+		}
+	}
+	return props, nil
+}
+
+func (p *dbPropSystem) Patch(name string, patches []Proppatch) ([]Propstat, error) {
+	// later patches of the same property override the earlier ones
+	final := make(map[xml.Name]*model.WebdavProp)
+	pstat := Propstat{Status: http.StatusOK}
+	for _, patch := range patches {
+		for _, prop := range patch.Props {
+			if patch.Remove {
+				final[prop.XMLName] = nil
+			} else {
+				final[prop.XMLName] = &model.WebdavProp{
+					Space:    prop.XMLName.Space,
+					Local:    prop.XMLName.Local,
+					Lang:     prop.Lang,
+					InnerXML: string(prop.InnerXML),
+				}
+			}
+			pstat.Props = append(pstat.Props, Property{XMLName: prop.XMLName})
+		}
+	}
+	var set, remove []model.WebdavProp
+	for pn, item := range final {
+		if item == nil {
+			remove = append(remove, model.WebdavProp{Space: pn.Space, Local: pn.Local})
+		} else {
+			set = append(set, *item)
+		}
+	}
+	if err := db.PatchWebdavProps(slashClean(name), set, remove); err != nil {
+		return nil, err
+	}
+	return []Propstat{pstat}, nil
+}
+
+func (p *dbPropSystem) Copy(src, dst string) error {
+	return db.CopyWebdavProps(slashClean(src), slashClean(dst))
+}
+
+func (p *dbPropSystem) Move(src, dst string) error {
+	return db.MoveWebdavProps(slashClean(src), slashClean(dst))
+}
+
+func (p *dbPropSystem) Remove(name string) error {
+	return db.DeleteWebdavProps(slashClean(name))
+}
@@ -31,6 +31,9 @@ type Handler struct {
 	Prefix string
 	// LockSystem is the lock management system.
 	LockSystem LockSystem
+	// PropSystem is an optional dead property store. If nil, all PROPPATCH
+	// requests are forbidden.
+	PropSystem PropSystem
 	// Logger is an optional error logger. If non-nil, it will be called
 	// for all HTTP requests.
 	Logger func(*http.Request, error)
@@ -300,6 +303,11 @@ func (h *Handler) handleDelete(w http.ResponseWriter, r *http.Request) (status i
 	if err := fs.Remove(ctx, reqPath); err != nil {
 		return http.StatusMethodNotAllowed, err
 	}
+	if h.PropSystem != nil {
+		if err := h.PropSystem.Remove(reqPath); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
 	//fs.ClearCache(path.Dir(reqPath))
 	return http.StatusNoContent, nil
 }
@@ -471,7 +479,14 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status
 				return http.StatusBadRequest, errInvalidDepth
 			}
 		}
-		return copyFiles(ctx, src, dst, r.Header.Get("Overwrite") != "F")
+		status, err = copyFiles(ctx, src, dst, r.Header.Get("Overwrite") != "F")
+		if err == nil && h.PropSystem != nil {
+			// copyFiles keeps the name of src
+			if err = h.PropSystem.Copy(src, path.Join(path.Dir(dst), path.Base(src))); err != nil {
+				return http.StatusInternalServerError, err
+			}
+		}
+		return status, err
 	}
 
 	release, status, err := h.confirmLocks(r, src, dst)
@@ -488,7 +503,13 @@ func (h *Handler) handleCopyMove(w http.ResponseWriter, r *http.Request) (status
 			return http.StatusBadRequest, errInvalidDepth
 		}
 	}
-	return moveFiles(ctx, src, dst, r.Header.Get("Overwrite") == "T")
+	status, err = moveFiles(ctx, src, dst, r.Header.Get("Overwrite") == "T")
+	if err == nil && status == http.StatusCreated && h.PropSystem != nil {
+		if err = h.PropSystem.Move(src, dst); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	return status, err
 }
 
 func (h *Handler) handleLock(w http.ResponseWriter, r *http.Request) (retStatus int, retErr error) {
@@ -654,7 +675,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		}
 		var pstats []Propstat
 		if pf.Propname != nil {
-			pnames, err := propnames(ctx, h.LockSystem, info)
+			pnames, err := propnames(ctx, h.LockSystem, h.PropSystem, reqPath, info)
 			if err != nil {
 				return err
 			}
@@ -664,9 +685,9 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			}
 			pstats = append(pstats, pstat)
 		} else if pf.Allprop != nil {
-			pstats, err = allprop(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = allprop(ctx, h.LockSystem, h.PropSystem, reqPath, info, pf.Prop)
 		} else {
-			pstats, err = props(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = props(ctx, h.LockSystem, h.PropSystem, reqPath, info, pf.Prop)
 		}
 		if err != nil {
 			return err
@@ -716,7 +737,7 @@ func (h *Handler) handleProppatch(w http.ResponseWriter, r *http.Request) (statu
 	if err != nil {
 		return status, err
 	}
-	pstats, err := patch(ctx, h.LockSystem, reqPath, patches)
+	pstats, err := patch(ctx, h.LockSystem, h.PropSystem, reqPath, patches)
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}